@@ -2,9 +2,13 @@ package main
 
 import (
     "context"
+    "encoding/json"
+    "errors"
     "fmt"
     "net"
     "os"
+    "regexp"
+    "strings"
     "time"
 
     "github.com/spf13/viper"
@@ -15,6 +19,7 @@ import (
 
     "github.com/parishadmk/log-system-analysis/internal/api/auth"
     "github.com/parishadmk/log-system-analysis/internal/lib"
+    "github.com/jackc/pgx/v5"
     "github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -32,6 +37,49 @@ func initConfig() {
     }
 }
 
+// parseRedactionRules decodes the redaction_rules column. A rule that cannot
+// be compiled fails the lookup rather than letting PII through.
+func parseRedactionRules(raw []byte) ([]*auth.RedactionRule, error) {
+    var decoded []struct {
+        Name     string   `json:"name"`
+        Detector string   `json:"detector"`
+        Pattern  string   `json:"pattern"`
+        Keys     []string `json:"keys"`
+        Action   string   `json:"action"`
+    }
+    if err := json.Unmarshal(raw, &decoded); err != nil {
+        return nil, fmt.Errorf("redaction_rules: %w", err)
+    }
+    rules := make([]*auth.RedactionRule, 0, len(decoded))
+    for i, r := range decoded {
+        rule := &auth.RedactionRule{Name: r.Name, Pattern: r.Pattern, Keys: r.Keys}
+        if r.Detector != "" {
+            d, ok := auth.Detector_value["DETECTOR_"+strings.ToUpper(r.Detector)]
+            if !ok {
+                return nil, fmt.Errorf("redaction_rules[%d]: unknown detector %q", i, r.Detector)
+            }
+            rule.Detector = auth.Detector(d)
+        }
+        if r.Pattern != "" {
+            if _, err := regexp.Compile(r.Pattern); err != nil {
+                return nil, fmt.Errorf("redaction_rules[%d]: %w", i, err)
+            }
+        }
+        if rule.Detector == auth.Detector_DETECTOR_NONE && r.Pattern == "" && len(r.Keys) == 0 {
+            return nil, fmt.Errorf("redaction_rules[%d]: needs a detector, pattern or keys", i)
+        }
+        if r.Action != "" {
+            a, ok := auth.RedactionAction_value["REDACT_"+strings.ToUpper(r.Action)]
+            if !ok {
+                return nil, fmt.Errorf("redaction_rules[%d]: unknown action %q", i, r.Action)
+            }
+            rule.Action = auth.RedactionAction(a)
+        }
+        rules = append(rules, rule)
+    }
+    return rules, nil
+}
+
 func main() {
     // 1) Logger
     if err := lib.InitLogger(); err != nil {
@@ -104,15 +152,96 @@ func (s *server) Login(ctx context.Context, req *auth.LoginRequest) (*auth.Login
     return &auth.LoginResponse{Token: signed}, nil
 }
 
-// ValidateApiKey checks project_id + api_key
+// ValidateApiKey checks project_id + api_key and returns the project's
+// ingest limits. When project_id is empty the project is resolved from the
+// (unique) api_key alone.
 func (s *server) ValidateApiKey(ctx context.Context, req *auth.ApiKeyRequest) (*auth.ApiKeyResponse, error) {
-    var ok bool
+    var (
+        projectID  string
+        limits     auth.ProjectLimits
+        schema     auth.ProjectSchema
+        strictness string
+        keyTypes   []byte
+        timestamps auth.TimestampPolicy
+        tsPolicy   string
+        redaction  auth.RedactionPolicy
+        rules      []byte
+        maxKeys, maxKeyBytes, maxValueBytes int64
+    )
     err := s.db.QueryRow(ctx,
-        `SELECT EXISTS (SELECT 1 FROM projects WHERE id=$1 AND api_key=$2)`,
-        req.ProjectId, req.ApiKey,
-    ).Scan(&ok)
+        `SELECT id::STRING, rate_limit_eps, rate_limit_bps, daily_quota_events, daily_quota_bytes,
+                schema_strictness, required_keys, allowed_event_names, key_types,
+                max_keys, max_key_bytes, max_value_bytes,
+                timestamp_policy, max_past_skew_seconds, max_future_skew_seconds,
+                redaction_rules, redaction_salt
+           FROM projects
+          WHERE api_key=$1 AND ($2 = '' OR id::STRING = $2) AND archived_at IS NULL`,
+        req.ApiKey, req.ProjectId,
+    ).Scan(&projectID, &limits.EventsPerSecond, &limits.BytesPerSecond, &limits.DailyEvents, &limits.DailyBytes,
+        &strictness, &schema.RequiredKeys, &schema.EventNames, &keyTypes,
+        &maxKeys, &maxKeyBytes, &maxValueBytes,
+        &tsPolicy, &timestamps.MaxPastSeconds, &timestamps.MaxFutureSeconds,
+        &rules, &redaction.Salt)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return &auth.ApiKeyResponse{Valid: false}, nil
+    }
     if err != nil {
         return nil, err
     }
-    return &auth.ApiKeyResponse{Valid: ok}, nil
-}
\ No newline at end of file
+    switch strictness {
+    case "reject":
+        schema.Strictness = auth.SchemaStrictness_SCHEMA_REJECT
+    case "quarantine":
+        schema.Strictness = auth.SchemaStrictness_SCHEMA_QUARANTINE
+    }
+    if schema.KeyTypes, err = parseKeyTypes(keyTypes); err != nil {
+        return nil, fmt.Errorf("project %s: %w", projectID, err)
+    }
+    schema.MaxKeys = int32(maxKeys)
+    schema.MaxKeyBytes = int32(maxKeyBytes)
+    schema.MaxValueBytes = int32(maxValueBytes)
+    switch tsPolicy {
+    case "clamp":
+        timestamps.Mode = auth.TimestampMode_TIMESTAMP_CLAMP
+    case "reject":
+        timestamps.Mode = auth.TimestampMode_TIMESTAMP_REJECT
+    }
+    if redaction.Rules, err = parseRedactionRules(rules); err != nil {
+        return nil, fmt.Errorf("project %s: %w", projectID, err)
+    }
+    return &auth.ApiKeyResponse{
+        Valid:      true,
+        ProjectId:  projectID,
+        Limits:     &limits,
+        Schema:     &schema,
+        Timestamps: &timestamps,
+        Redaction:  &redaction,
+    }, nil
+}
+
+// parseKeyTypes decodes the key_types column: a JSON object mapping each key
+// to a type name, or to the array of allowed values of an enum.
+func parseKeyTypes(raw []byte) (map[string]*auth.KeyType, error) {
+    var decoded map[string]json.RawMessage
+    if err := json.Unmarshal(raw, &decoded); err != nil {
+        return nil, fmt.Errorf("key_types: %w", err)
+    }
+    types := make(map[string]*auth.KeyType, len(decoded))
+    for key, v := range decoded {
+        var values []string
+        if err := json.Unmarshal(v, &values); err == nil {
+            types[key] = &auth.KeyType{Type: auth.ValueType_VALUE_ENUM, EnumValues: values}
+            continue
+        }
+        var name string
+        if err := json.Unmarshal(v, &name); err != nil {
+            return nil, fmt.Errorf("key_types[%q]: want a type name or an array of values", key)
+        }
+        t, ok := auth.ValueType_value["VALUE_"+strings.ToUpper(name)]
+        if !ok || auth.ValueType(t) == auth.ValueType_VALUE_ENUM {
+            return nil, fmt.Errorf("key_types[%q]: unknown type %q", key, name)
+        }
+        types[key] = &auth.KeyType{Type: auth.ValueType(t)}
+    }
+    return types, nil
+}
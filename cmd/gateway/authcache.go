@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+)
+
+// apiKeyEntry is one cached ValidateApiKey answer.
+type apiKeyEntry struct {
+	resp    *authpb.ApiKeyResponse
+	fetched time.Time
+}
+
+// apiKeyCache keeps validated (project_id, api_key) pairs in process so the
+// ingest path does not call AuthSvc per request:
+//
+//   - valid pairs are fresh for ttl, invalid ones for negativeTTL;
+//   - a valid pair up to stale past its ttl is still served while it is
+//     refreshed in the background, which also carries ingestion through
+//     short AuthSvc outages;
+//   - concurrent lookups of the same pair share one AuthSvc call.
+//
+// AuthSvc errors are never cached.
+type apiKeyCache struct {
+	client      authpb.AuthServiceClient
+	ttl         time.Duration
+	negativeTTL time.Duration
+	stale       time.Duration
+	maxEntries  int
+	timeout     time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*apiKeyEntry
+	group   singleflight.Group
+	now     func() time.Time
+}
+
+func newAPIKeyCache(client authpb.AuthServiceClient, ttl, negativeTTL, stale time.Duration, maxEntries int) *apiKeyCache {
+	return &apiKeyCache{
+		client:      client,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		stale:       stale,
+		maxEntries:  maxEntries,
+		timeout:     2 * time.Second,
+		entries:     map[string]*apiKeyEntry{},
+		now:         time.Now,
+	}
+}
+
+// validate answers like AuthService.ValidateApiKey, from cache when it can.
+func (c *apiKeyCache) validate(ctx context.Context, projectID, apiKey string) (*authpb.ApiKeyResponse, error) {
+	key := projectID + "\x00" + apiKey
+	c.mu.Lock()
+	e := c.entries[key]
+	c.mu.Unlock()
+
+	if e != nil {
+		age := c.now().Sub(e.fetched)
+		switch {
+		case e.resp.Valid && age < c.ttl, !e.resp.Valid && age < c.negativeTTL:
+			authCacheCounter.WithLabelValues("hit").Inc()
+			return e.resp, nil
+		case e.resp.Valid && age < c.ttl+c.stale:
+			authCacheCounter.WithLabelValues("stale").Inc()
+			go c.group.Do(key, c.load(key, projectID, apiKey))
+			return e.resp, nil
+		}
+	}
+
+	authCacheCounter.WithLabelValues("miss").Inc()
+	ch := c.group.DoChan(key, c.load(key, projectID, apiKey))
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*authpb.ApiKeyResponse), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// load calls AuthSvc and caches the answer. It always runs under the
+// singleflight group and detached from any one caller's context, since its
+// result is shared by every waiting caller.
+func (c *apiKeyCache) load(key, projectID, apiKey string) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+		resp, err := c.client.ValidateApiKey(ctx, &authpb.ApiKeyRequest{
+			ProjectId: projectID,
+			ApiKey:    apiKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errAuthUnavailable, err)
+		}
+		c.store(key, resp)
+		return resp, nil
+	}
+}
+
+func (c *apiKeyCache) store(key string, resp *authpb.ApiKeyResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		// evict an arbitrary entry; map iteration order is random
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = &apiKeyEntry{resp: resp, fetched: c.now()}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+)
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func newTestCache(now *time.Time) (*apiKeyCache, *fakeAuth) {
+	fa := &fakeAuth{keys: map[string]*authpb.ApiKeyResponse{
+		testKey: {Valid: true, ProjectId: testProject},
+	}}
+	c := newAPIKeyCache(fa, time.Minute, 10*time.Second, 5*time.Minute, 100)
+	c.now = func() time.Time { return *now }
+	return c, fa
+}
+
+func TestAPIKeyCacheExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	c, fa := newTestCache(&now)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if resp, err := c.validate(ctx, testProject, testKey); err != nil || !resp.Valid {
+			t.Fatalf("validate = %v, %v", resp, err)
+		}
+	}
+	if n := fa.callCount(); n != 1 {
+		t.Errorf("fresh entry: %d AuthSvc calls, want 1", n)
+	}
+
+	// past ttl the cached answer is served and refreshed in the background
+	now = now.Add(2 * time.Minute)
+	if resp, err := c.validate(ctx, testProject, testKey); err != nil || !resp.Valid {
+		t.Fatalf("stale validate = %v, %v", resp, err)
+	}
+	waitFor(t, "background refresh", func() bool { return fa.callCount() == 2 })
+	waitFor(t, "refreshed entry", func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.entries[testProject+"\x00"+testKey].fetched.Equal(now)
+	})
+
+	// past ttl+stale the caller waits for AuthSvc, and sees its errors
+	now = now.Add(6*time.Minute + time.Second)
+	fa.mu.Lock()
+	fa.err = errors.New("connection refused")
+	fa.mu.Unlock()
+	if _, err := c.validate(ctx, testProject, testKey); !errors.Is(err, errAuthUnavailable) {
+		t.Fatalf("expired entry, AuthSvc down: err = %v, want errAuthUnavailable", err)
+	}
+	// errors are not cached
+	fa.mu.Lock()
+	fa.err = nil
+	fa.mu.Unlock()
+	if resp, err := c.validate(ctx, testProject, testKey); err != nil || !resp.Valid {
+		t.Fatalf("after the outage: %v, %v", resp, err)
+	}
+	if n := fa.callCount(); n != 4 {
+		t.Errorf("%d AuthSvc calls, want 4", n)
+	}
+}
+
+func TestAPIKeyCacheNegativeTTL(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	c, fa := newTestCache(&now)
+	ctx := context.Background()
+
+	for _, d := range []time.Duration{0, 9 * time.Second} {
+		now = now.Add(d)
+		if resp, err := c.validate(ctx, testProject, "wrong"); err != nil || resp.Valid {
+			t.Fatalf("invalid key = %v, %v", resp, err)
+		}
+	}
+	if n := fa.callCount(); n != 1 {
+		t.Errorf("within negative ttl: %d AuthSvc calls, want 1", n)
+	}
+	// an invalid key is not served stale: a key added since must work
+	now = now.Add(2 * time.Second)
+	fa.mu.Lock()
+	fa.keys["wrong"] = &authpb.ApiKeyResponse{Valid: true, ProjectId: testProject}
+	fa.mu.Unlock()
+	if resp, err := c.validate(ctx, testProject, "wrong"); err != nil || !resp.Valid {
+		t.Errorf("after negative ttl = %v, %v", resp, err)
+	}
+}
+
+// TestAPIKeyCacheSingleflight checks that concurrent misses on one key
+// share one AuthSvc call, and that a caller giving up does not cancel it
+// for the others.
+func TestAPIKeyCacheSingleflight(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	c, fa := newTestCache(&now)
+	fa.block = make(chan struct{})
+	misses := authCacheCounter.WithLabelValues("miss")
+	before := counterValue(misses)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.validate(context.Background(), testProject, testKey)
+			if err == nil && !resp.Valid {
+				err = errors.New("invalid")
+			}
+			errs <- err
+		}()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := c.validate(ctx, testProject, testKey)
+		cancelled <- err
+	}()
+	waitFor(t, "all callers to miss", func() bool { return counterValue(misses)-before == callers+1 })
+	// the counter is bumped just before joining the flight
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller: err = %v", err)
+	}
+	close(fa.block)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if n := fa.callCount(); n != 1 {
+		t.Errorf("%d AuthSvc calls, want 1", n)
+	}
+}
+
+func TestAPIKeyCacheMaxEntries(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	c, _ := newTestCache(&now)
+	c.maxEntries = 2
+	for _, key := range []string{"a", "b", "c", "d"} {
+		if _, err := c.validate(context.Background(), testProject, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(c.entries); n != 2 {
+		t.Errorf("%d entries, want 2", n)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// request shape matches ingestpb.LogBatchRequest
+type httpLogBatchRequest struct {
+	ProjectID string                 `json:"project_id"`
+	ApiKey    string                 `json:"api_key"`
+	Payloads  []*ingestpb.LogPayload `json:"payloads"`
+}
+
+type httpLogResult struct {
+	Index    int32  `json:"index"`
+	Accepted bool   `json:"accepted"`
+	Error    string `json:"error,omitempty"`
+}
+
+type httpLogBatchResponse struct {
+	Accepted int32           `json:"accepted"`
+	Rejected int32           `json:"rejected"`
+	Results  []httpLogResult `json:"results"`
+}
+
+// handleSendLogBatch accepts many events per request, either as
+//
+//   - application/json: {"project_id", "api_key", "payloads": [...]} or a
+//     bare [...] array of payloads, or
+//   - application/x-ndjson: one payload object per line,
+//
+// and answers with an accepted/rejected status for every event. When the
+// body does not carry credentials they are read from the X-Project-ID and
+// X-API-Key headers.
+func (g *gatewayServer) handleSendLogBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req httpLogBatchRequest
+	req.ProjectID, req.ApiKey = httpCredentials(r.Header)
+	var parseErrs map[int]error
+	var err error
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch ct {
+	case "application/x-ndjson", "application/jsonl":
+		req.Payloads, parseErrs, err = decodeNDJSON(r.Body)
+	default:
+		err = decodeBatchJSON(r.Body, &req)
+	}
+	if err != nil {
+		g.logger.Warn("invalid batch body", zap.Error(err))
+		writeBodyError(w, err)
+		return
+	}
+
+	resp, err := g.sendBatch(r.Context(), &ingestpb.LogBatchRequest{
+		ProjectId: req.ProjectID,
+		ApiKey:    req.ApiKey,
+		Payloads:  req.Payloads,
+	})
+	if err != nil {
+		writeIngestError(w, err)
+		return
+	}
+
+	out := httpLogBatchResponse{
+		Accepted: resp.Accepted,
+		Rejected: resp.Rejected,
+		Results:  make([]httpLogResult, len(resp.Results)),
+	}
+	for i, res := range resp.Results {
+		out.Results[i] = httpLogResult{Index: res.Index, Accepted: res.Accepted, Error: res.Error}
+		if perr, ok := parseErrs[i]; ok {
+			out.Results[i].Error = perr.Error()
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(out)
+}
+
+// decodeBatchJSON reads either the envelope object or a bare payload array.
+func decodeBatchJSON(body io.Reader, req *httpLogBatchRequest) error {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		return json.Unmarshal(raw, &req.Payloads)
+	}
+	var env httpLogBatchRequest
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return err
+	}
+	if env.ProjectID != "" {
+		req.ProjectID = env.ProjectID
+	}
+	if env.ApiKey != "" {
+		req.ApiKey = env.ApiKey
+	}
+	req.Payloads = env.Payloads
+	return nil
+}
+
+// decodeNDJSON parses one payload per non-empty line. A malformed line does
+// not fail the batch: its slot is left nil and the parse error is returned
+// by position so it can be reported as that event's rejection reason.
+func decodeNDJSON(body io.Reader) ([]*ingestpb.LogPayload, map[int]error, error) {
+	var (
+		payloads []*ingestpb.LogPayload
+		errs     = map[int]error{}
+	)
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var p ingestpb.LogPayload
+		if err := json.Unmarshal(line, &p); err != nil {
+			errs[len(payloads)] = fmt.Errorf("invalid JSON: %v", err)
+			payloads = append(payloads, nil)
+			continue
+		}
+		payloads = append(payloads, &p)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	return payloads, errs, nil
+}
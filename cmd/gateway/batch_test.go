@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// postBatch sends body to /v1/logs/batch with the test credentials in
+// headers.
+func postBatch(g *gatewayServer, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/logs/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-API-Key", testKey)
+	rec := httptest.NewRecorder()
+	g.handleSendLogBatch(rec, req)
+	return rec
+}
+
+func TestHandleSendLogBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        httpLogBatchResponse
+	}{
+		{
+			"envelope",
+			"application/json",
+			`{"project_id":"` + testProject + `","payloads":[{"name":"a"},{"name":""},{"name":"c"}]}`,
+			httpLogBatchResponse{Accepted: 2, Rejected: 1, Results: []httpLogResult{
+				{Index: 0, Accepted: true},
+				{Index: 1, Error: "invalid payload: name is required"},
+				{Index: 2, Accepted: true},
+			}},
+		},
+		{
+			"bare array",
+			"application/json",
+			`[{"name":"a"},{"name":"b"}]`,
+			httpLogBatchResponse{Accepted: 2, Results: []httpLogResult{{Index: 0, Accepted: true}, {Index: 1, Accepted: true}}},
+		},
+		{
+			"ndjson with a malformed line",
+			"application/x-ndjson",
+			"{\"name\":\"a\"}\n\n{\"name\":\n{\"name\":\"c\"}\n",
+			httpLogBatchResponse{Accepted: 2, Rejected: 1, Results: []httpLogResult{
+				{Index: 0, Accepted: true},
+				{Index: 1, Error: "invalid JSON: unexpected end of JSON input"},
+				{Index: 2, Accepted: true},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		p := &fakeProducer{}
+		g, _ := newTestGateway(p, nil)
+		rec := postBatch(g, tt.contentType, tt.body)
+		if rec.Code != http.StatusAccepted {
+			t.Errorf("%s: status = %d: %s", tt.name, rec.Code, rec.Body)
+			continue
+		}
+		var got httpLogBatchResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: response = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if n := len(p.sentValues()); n != int(tt.want.Accepted) {
+			t.Errorf("%s: %d events produced, want %d", tt.name, n, tt.want.Accepted)
+		}
+	}
+}
+
+func TestHandleSendLogBatchErrors(t *testing.T) {
+	g, _ := newTestGateway(&fakeProducer{}, &authpb.ApiKeyResponse{Limits: &authpb.ProjectLimits{DailyEvents: 2}})
+	g.maxBatch = 3
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"over max batch", `[{"name":"a"},{"name":"b"},{"name":"c"},{"name":"d"}]`, http.StatusRequestEntityTooLarge},
+		{"over quota", `[{"name":"a"},{"name":"b"},{"name":"c"}]`, http.StatusTooManyRequests},
+		{"malformed", `{"payloads":[`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := postBatch(g, "application/json", tt.body); rec.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.code)
+		}
+	}
+	// the quota counts only valid events
+	if rec := postBatch(g, "application/json", `[{"name":"a"},{"name":""},{"name":"c"}]`); rec.Code != http.StatusAccepted {
+		t.Errorf("two valid events: status = %d", rec.Code)
+	}
+}
+
+// TestSendBatchProducerErrors checks that an event Kafka did not take is
+// reported as rejected at its input position.
+func TestSendBatchProducerErrors(t *testing.T) {
+	// position 1 is invalid, so the second message is input position 2
+	p := &fakeProducer{failAt: map[int]bool{1: true}}
+	g, _ := newTestGateway(p, nil)
+	resp, err := g.sendBatch(context.Background(), &ingestpb.LogBatchRequest{
+		ApiKey:   testKey,
+		Payloads: []*ingestpb.LogPayload{{Name: "a"}, {}, {Name: "c"}, {Name: "d"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := make([]bool, len(resp.Results))
+	for i, r := range resp.Results {
+		accepted[i] = r.Accepted
+	}
+	if want := []bool{true, false, false, true}; !reflect.DeepEqual(accepted, want) {
+		t.Errorf("accepted = %v, want %v", accepted, want)
+	}
+	if resp.Accepted != 2 || resp.Rejected != 2 || resp.Results[2].Error != "server error" {
+		t.Errorf("response = %v", resp)
+	}
+}
+
+// TestSendAllPartial checks that sendAll keeps the results of the chunks
+// produced before one failed.
+func TestSendAllPartial(t *testing.T) {
+	p := &fakeProducer{}
+	g, _ := newTestGateway(p, &authpb.ApiKeyResponse{Limits: &authpb.ProjectLimits{DailyEvents: 4}})
+	g.maxBatch = 2
+	payloads := []*ingestpb.LogPayload{{Name: "a"}, {}, {Name: "c"}, {Name: "d"}, {Name: "e"}, {Name: "f"}}
+
+	resp, err := g.sendAll(context.Background(), "", testKey, payloads)
+	var partial *partialSendError
+	if !errors.As(err, &partial) || partial.unsent != 4 || !errors.Is(err, errRateLimited) {
+		t.Fatalf("err = %v, want a partial send from 4", err)
+	}
+	if len(resp.Results) != len(payloads) || resp.Accepted != 3 || resp.Rejected != 3 {
+		t.Fatalf("response = %v", resp)
+	}
+	for i, r := range resp.Results {
+		if r.Index != int32(i) {
+			t.Errorf("result %d has index %d", i, r.Index)
+		}
+	}
+	if n := len(p.sentValues()); n != 3 {
+		t.Errorf("%d events produced, want 3", n)
+	}
+
+	// a failure of the first chunk fails the call
+	if _, err := g.sendAll(context.Background(), "", testKey, payloads[2:]); !errors.Is(err, errRateLimited) || errors.As(err, &partial) {
+		t.Errorf("first chunk rejected: err = %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// ingestInfo travels in the request context of HTTP ingest endpoints so the
+// middleware can attribute what it measured to the project authorize
+// resolved.
+type ingestInfo struct {
+	projectID string
+}
+
+type ingestInfoKey struct{}
+
+// setIngestProject records the resolved project on the request, if any.
+func setIngestProject(ctx context.Context, projectID string) {
+	if info, ok := ctx.Value(ingestInfoKey{}).(*ingestInfo); ok {
+		info.projectID = projectID
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// limitReader fails with errBodyTooLarge instead of silently truncating
+// once more than n bytes have been read.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, errBodyTooLarge
+	}
+	return n, err
+}
+
+// withBodyLimits wraps an ingest handler: it enforces ingest.max_body_bytes
+// on the body as sent, transparently decodes a gzip, zstd or snappy
+// Content-Encoding, enforces ingest.max_decompressed_bytes on the result
+// (so a small body cannot expand without bound) and records the
+// compression ratio per project.
+func (g *gatewayServer) withBodyLimits(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > g.maxBodyBytes {
+			http.Error(w, errBodyTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		wire := &countingReader{r: http.MaxBytesReader(w, r.Body, g.maxBodyBytes)}
+
+		encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+		var body io.Reader
+		switch encoding {
+		case "", "identity":
+			body = wire
+		case "gzip", "x-gzip":
+			zr, err := gzip.NewReader(wire)
+			if err != nil {
+				writeBodyError(w, fmt.Errorf("gzip: %w", err))
+				return
+			}
+			defer zr.Close()
+			body = zr
+		case "zstd":
+			zr, err := zstd.NewReader(wire, zstd.WithDecoderMaxMemory(uint64(g.maxDecompressedBytes)))
+			if err != nil {
+				writeBodyError(w, fmt.Errorf("zstd: %w", err))
+				return
+			}
+			defer zr.Close()
+			body = zr
+		case "snappy":
+			zr, err := snappyReader(wire, g.maxDecompressedBytes)
+			if err != nil {
+				writeBodyError(w, err)
+				return
+			}
+			body = zr
+		default:
+			http.Error(w, "unsupported Content-Encoding "+encoding, http.StatusUnsupportedMediaType)
+			return
+		}
+		plain := &countingReader{r: &limitReader{r: body, n: g.maxDecompressedBytes}}
+		r.Body = io.NopCloser(plain)
+		r.Header.Del("Content-Encoding")
+		r.ContentLength = -1
+
+		info := &ingestInfo{}
+		r = r.WithContext(context.WithValue(r.Context(), ingestInfoKey{}, info))
+		next(w, r)
+
+		if encoding != "" && encoding != "identity" && info.projectID != "" && wire.n > 0 {
+			compressionRatioHist.WithLabelValues(info.projectID, encoding).
+				Observe(float64(plain.n) / float64(wire.n))
+		}
+	}
+}
+
+// grpcBody is what grpcBodyLimits measured of one gRPC call's messages.
+type grpcBody struct {
+	mu       sync.Mutex
+	encoding string
+	wire     int64 // all messages, as sent
+	plain    int64 // all messages, decompressed
+	last     int64 // the last message, as sent
+}
+
+type grpcBodyKey struct{}
+
+// grpcBodyLimits gives gRPC calls what withBodyLimits gives HTTP requests:
+// as a stats.Handler it measures every received message, and its
+// interceptors reject one over ingest.max_body_bytes as sent.
+// ingest.max_decompressed_bytes is the server's receive limit, which gRPC
+// applies while decompressing. The compression ratio is recorded per
+// project once the call ends.
+type grpcBodyLimits struct {
+	maxBodyBytes int64
+}
+
+func (l *grpcBodyLimits) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	ctx = context.WithValue(ctx, ingestInfoKey{}, &ingestInfo{})
+	return context.WithValue(ctx, grpcBodyKey{}, &grpcBody{})
+}
+
+func (l *grpcBodyLimits) HandleRPC(ctx context.Context, st stats.RPCStats) {
+	body, ok := ctx.Value(grpcBodyKey{}).(*grpcBody)
+	if !ok {
+		return
+	}
+	body.mu.Lock()
+	defer body.mu.Unlock()
+	switch st := st.(type) {
+	case *stats.InHeader:
+		body.encoding = strings.ToLower(st.Compression)
+	case *stats.InPayload:
+		body.wire += int64(st.CompressedLength)
+		body.plain += int64(st.Length)
+		body.last = int64(st.CompressedLength)
+	case *stats.End:
+		info, _ := ctx.Value(ingestInfoKey{}).(*ingestInfo)
+		if body.encoding != "" && body.encoding != "identity" && info != nil && info.projectID != "" && body.wire > 0 {
+			compressionRatioHist.WithLabelValues(info.projectID, body.encoding).
+				Observe(float64(body.plain) / float64(body.wire))
+		}
+	}
+}
+
+func (l *grpcBodyLimits) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (l *grpcBodyLimits) HandleConn(context.Context, stats.ConnStats) {}
+
+// check fails when the message last received on ctx was over the limit.
+func (l *grpcBodyLimits) check(ctx context.Context) error {
+	body, ok := ctx.Value(grpcBodyKey{}).(*grpcBody)
+	if !ok {
+		return nil
+	}
+	body.mu.Lock()
+	defer body.mu.Unlock()
+	if body.last > l.maxBodyBytes {
+		return status.Errorf(codes.ResourceExhausted, "%v: %d bytes, at most %d", errBodyTooLarge, body.last, l.maxBodyBytes)
+	}
+	return nil
+}
+
+func (l *grpcBodyLimits) unaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := l.check(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (l *grpcBodyLimits) streamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &limitedServerStream{ServerStream: ss, limits: l})
+}
+
+// limitedServerStream checks every message of a stream like one request.
+type limitedServerStream struct {
+	grpc.ServerStream
+	limits *grpcBodyLimits
+}
+
+func (s *limitedServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.limits.check(s.Context())
+}
+
+// snappyReader accepts both the snappy framing format and a bare snappy
+// block, as used by Prometheus and Loki clients. A block announces its
+// decoded length up front, so a bomb is rejected before decoding.
+func snappyReader(r io.Reader, limit int64) (io.Reader, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(raw, []byte("\xff\x06\x00\x00sNaPpY")) {
+		return snappy.NewReader(bytes.NewReader(raw)), nil
+	}
+	n, err := snappy.DecodedLen(raw)
+	if err != nil {
+		return nil, fmt.Errorf("snappy: %w", err)
+	}
+	if int64(n) > limit {
+		return nil, errBodyTooLarge
+	}
+	out, err := snappy.Decode(nil, raw)
+	if err != nil {
+		return nil, fmt.Errorf("snappy: %w", err)
+	}
+	return bytes.NewReader(out), nil
+}
+
+// writeBodyError answers a failed body read: 413 when a size limit was
+// hit, 400 for anything else (malformed JSON, corrupt compression, ...).
+func writeBodyError(w http.ResponseWriter, err error) {
+	var maxErr *http.MaxBytesError
+	// the zstd decoder stops on its own at the decoded limit
+	if errors.Is(err, errBodyTooLarge) || errors.As(err, &maxErr) ||
+		errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+		http.Error(w, errBodyTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, "bad request", http.StatusBadRequest)
+}
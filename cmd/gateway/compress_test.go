@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	gz "compress/gzip"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// ratioSamples is how many compression ratios were observed for a project
+// and encoding, and their sum.
+func ratioSamples(projectID, encoding string) (uint64, float64) {
+	var m dto.Metric
+	if err := compressionRatioHist.WithLabelValues(projectID, encoding).(prometheus.Metric).Write(&m); err != nil {
+		panic(err)
+	}
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
+
+// dialTestGRPC serves g over an in-memory listener the way main does.
+func dialTestGRPC(t *testing.T, g *gatewayServer) ingestpb.IngestServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	limits := &grpcBodyLimits{maxBodyBytes: g.maxBodyBytes}
+	srv := grpc.NewServer(
+		grpc.MaxRecvMsgSize(int(g.maxDecompressedBytes)),
+		grpc.StatsHandler(limits),
+		grpc.UnaryInterceptor(limits.unaryInterceptor),
+		grpc.StreamInterceptor(limits.streamInterceptor),
+	)
+	ingestpb.RegisterIngestServiceServer(srv, g)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return ingestpb.NewIngestServiceClient(conn)
+}
+
+func TestGRPCBodyLimits(t *testing.T) {
+	p := &fakeProducer{}
+	g, _ := newTestGateway(p, nil)
+	g.maxBodyBytes = 1024
+	g.maxDecompressedBytes = 16 << 10
+	client := dialTestGRPC(t, g)
+	ctx := context.Background()
+	request := func(size int) *ingestpb.LogRequest {
+		return &ingestpb.LogRequest{ProjectId: testProject, ApiKey: testKey, Payload: &ingestpb.LogPayload{
+			Name: "x", Data: map[string]string{"message": strings.Repeat("a", size)},
+		}}
+	}
+
+	// 8 KiB of one letter compresses far below the limit as sent
+	count, sum := ratioSamples(testProject, "gzip")
+	if _, err := client.SendLog(ctx, request(8<<10), grpc.UseCompressor(gzip.Name)); err != nil {
+		t.Fatalf("compressed call: %v", err)
+	}
+	if n, s := ratioSamples(testProject, "gzip"); n != count+1 || s-sum < 10 {
+		t.Errorf("ratio samples = %d (sum %v), want one more, above 10", n-count, s-sum)
+	}
+
+	// the same message uncompressed is over the limit as sent
+	_, err := client.SendLog(ctx, request(8<<10))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("uncompressed call: err = %v, want ResourceExhausted", err)
+	}
+	// and gRPC's own receive limit applies to the decompressed message
+	_, err = client.SendLog(ctx, request(32<<10), grpc.UseCompressor(gzip.Name))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("compression bomb: err = %v, want ResourceExhausted", err)
+	}
+	if n := len(p.sentValues()); n != 1 {
+		t.Errorf("%d events produced, want 1", n)
+	}
+
+	// every message of a stream is held to the limit
+	stream, err := client.StreamLogs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for seq, size := range []int{10, 8 << 10} {
+		req := request(size)
+		if err := stream.Send(&ingestpb.StreamLogRequest{
+			ProjectId: req.ProjectId, ApiKey: req.ApiKey, Sequence: uint64(seq + 1), Payload: req.Payload,
+		}); err != nil {
+			break
+		}
+	}
+	stream.CloseSend()
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("stream: err = %v, want ResourceExhausted", err)
+	}
+}
+
+func compressBody(t *testing.T, encoding string, plain []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	switch encoding {
+	case "gzip":
+		w := gz.NewWriter(&buf)
+		w.Write(plain)
+		w.Close()
+	case "zstd":
+		w, err := zstd.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(plain)
+		w.Close()
+	case "snappy":
+		return snappy.Encode(nil, plain)
+	case "snappy-framed":
+		w := snappy.NewBufferedWriter(&buf)
+		w.Write(plain)
+		w.Close()
+	default:
+		return plain
+	}
+	return buf.Bytes()
+}
+
+// TestWithBodyLimits checks the limits on bodies as sent and as decoded,
+// for every encoding, and that the ratio is recorded for the project the
+// handler resolved.
+func TestWithBodyLimits(t *testing.T) {
+	g, _ := newTestGateway(&fakeProducer{}, nil)
+	g.maxBodyBytes = 1024
+	g.maxDecompressedBytes = 16 << 10
+	var got []byte
+	handler := g.withBodyLimits(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if got, err = io.ReadAll(r.Body); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		setIngestProject(r.Context(), testProject)
+	})
+	post := func(encoding string, body []byte) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/logs", bytes.NewReader(body))
+		if encoding != "" {
+			req.Header.Set("Content-Encoding", strings.TrimSuffix(encoding, "-framed"))
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+
+	small := bytes.Repeat([]byte("a"), 8<<10)
+	bomb := bytes.Repeat([]byte("a"), 17<<10)
+	for _, enc := range []string{"gzip", "zstd", "snappy", "snappy-framed"} {
+		label := strings.TrimSuffix(enc, "-framed")
+		count, sum := ratioSamples(testProject, label)
+		if code := post(enc, compressBody(t, enc, small)); code != http.StatusOK || !bytes.Equal(got, small) {
+			t.Errorf("%s: status %d, %d bytes decoded", enc, code, len(got))
+		}
+		if n, s := ratioSamples(testProject, label); n != count+1 || s-sum < 10 {
+			t.Errorf("%s: %d ratio samples (sum %v), want one above 10", enc, n-count, s-sum)
+		}
+		if code := post(enc, compressBody(t, enc, bomb)); code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: decoded over the limit: status %d, want 413", enc, code)
+		}
+	}
+
+	if code := post("", small); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("plain over the limit: status %d, want 413", code)
+	}
+	if code := post("", small[:1024]); code != http.StatusOK {
+		t.Errorf("plain at the limit: status %d", code)
+	}
+	if code := post("gzip", []byte("not gzip")); code != http.StatusBadRequest {
+		t.Errorf("corrupt gzip: status %d, want 400", code)
+	}
+	if code := post("br", []byte("x")); code != http.StatusUnsupportedMediaType {
+		t.Errorf("brotli: status %d, want 415", code)
+	}
+}
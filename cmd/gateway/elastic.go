@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// esVersion is the Elasticsearch version reported to shippers that probe
+// the cluster before sending (Filebeat, Vector).
+const esVersion = "8.11.0"
+
+type esBulkItem struct {
+	Index  string         `json:"_index,omitempty"`
+	ID     string         `json:"_id,omitempty"`
+	Status int            `json:"status"`
+	Result string         `json:"result,omitempty"`
+	Error  *esBulkItemErr `json:"error,omitempty"`
+}
+
+type esBulkItemErr struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+type esBulkResponse struct {
+	Took   int64                   `json:"took"`
+	Errors bool                    `json:"errors"`
+	Items  []map[string]esBulkItem `json:"items"`
+}
+
+// handleESRoot answers the version probe of Elasticsearch clients.
+func (g *gatewayServer) handleESRoot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":         "log-gateway",
+		"cluster_name": "log-system",
+		"version": map[string]string{
+			"number":       esVersion,
+			"build_flavor": "default",
+		},
+		"tagline": "You Know, for Search",
+	})
+}
+
+// handleESBulk accepts the Elasticsearch _bulk NDJSON format. index and
+// create actions become events; the document's event.name (or event_name,
+// or else the target index) is the event name, @timestamp its timestamp,
+// and every field is flattened into Data with dotted keys. update and
+// delete actions are answered with a per-item error.
+func (g *gatewayServer) handleESBulk(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+
+	type pendingItem struct {
+		action  string
+		item    esBulkItem
+		payload int // index into payloads, -1 when rejected while parsing
+	}
+	var (
+		items    []pendingItem
+		payloads []*ingestpb.LogPayload
+	)
+	defaultIndex := r.PathValue("index")
+
+	sc := bufio.NewScanner(r.Body)
+	sc.Buffer(make([]byte, 64*1024), 10<<20)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var action map[string]struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		}
+		if err := json.Unmarshal(line, &action); err != nil || len(action) != 1 {
+			http.Error(w, "malformed action/metadata line", http.StatusBadRequest)
+			return
+		}
+		for name, meta := range action {
+			it := pendingItem{action: name, payload: -1, item: esBulkItem{Index: meta.Index, ID: meta.ID}}
+			if it.item.Index == "" {
+				it.item.Index = defaultIndex
+			}
+			switch name {
+			case "index", "create":
+				if !sc.Scan() {
+					http.Error(w, "missing document line", http.StatusBadRequest)
+					return
+				}
+				p, err := esDocToPayload(sc.Bytes(), it.item.Index)
+				if err != nil {
+					it.item.Status = http.StatusBadRequest
+					it.item.Error = &esBulkItemErr{Type: "mapper_parsing_exception", Reason: err.Error()}
+				} else {
+					// a document _id makes client retries idempotent
+					p.EventId = meta.ID
+					it.payload = len(payloads)
+					payloads = append(payloads, p)
+				}
+			case "update":
+				// skip the partial document
+				sc.Scan()
+				fallthrough
+			case "delete":
+				it.item.Status = http.StatusBadRequest
+				it.item.Error = &esBulkItemErr{
+					Type:   "illegal_argument_exception",
+					Reason: name + " is not supported; events are append-only",
+				}
+			default:
+				http.Error(w, "unknown bulk action "+strconv.Quote(name), http.StatusBadRequest)
+				return
+			}
+			items = append(items, it)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		g.logger.Warn("invalid _bulk body", zap.Error(err))
+		writeBodyError(w, err)
+		return
+	}
+
+	projectID, apiKey := httpCredentials(r.Header)
+	resp, err := g.sendAll(r.Context(), projectID, apiKey, payloads)
+	var partial *partialSendError
+	if err != nil && !errors.As(err, &partial) {
+		writeIngestError(w, err)
+		return
+	}
+	// documents that were not sent get a status bulk clients retry
+	unsentStatus := http.StatusServiceUnavailable
+	if errors.Is(err, errRateLimited) {
+		unsentStatus = http.StatusTooManyRequests
+	}
+
+	out := esBulkResponse{Items: make([]map[string]esBulkItem, len(items))}
+	for i, it := range items {
+		if it.payload >= 0 {
+			if res := resp.Results[it.payload]; res.Accepted {
+				it.item.Status = http.StatusCreated
+				it.item.Result = "created"
+			} else if partial != nil && it.payload >= partial.unsent {
+				it.item.Status = unsentStatus
+				it.item.Error = &esBulkItemErr{Type: "es_rejected_execution_exception", Reason: res.Error}
+			} else {
+				it.item.Status = http.StatusBadRequest
+				it.item.Error = &esBulkItemErr{Type: "illegal_argument_exception", Reason: res.Error}
+			}
+		}
+		if it.item.Error != nil {
+			out.Errors = true
+		}
+		out.Items[i] = map[string]esBulkItem{it.action: it.item}
+	}
+	out.Took = time.Since(start).Milliseconds()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// esDocToPayload flattens one _bulk source document.
+func esDocToPayload(doc []byte, index string) (*ingestpb.LogPayload, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var src map[string]interface{}
+	if err := dec.Decode(&src); err != nil {
+		return nil, fmt.Errorf("document is not a JSON object: %v", err)
+	}
+	data := map[string]string{}
+	for k, v := range src {
+		flattenJSON(data, k, v)
+	}
+
+	p := &ingestpb.LogPayload{Data: data}
+	for _, key := range []string{"event.name", "event_name"} {
+		if name := data[key]; name != "" {
+			p.Name = name
+			break
+		}
+	}
+	if p.Name == "" {
+		p.Name = index
+	}
+	if p.Name == "" {
+		p.Name = "elasticsearch"
+	}
+
+	switch ts := src["@timestamp"].(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse @timestamp %q", ts)
+		}
+		p.Timestamp = t.UnixNano()
+	case json.Number:
+		// epoch_millis, the other default date format
+		ms, err := ts.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse @timestamp %q", ts)
+		}
+		p.Timestamp = time.UnixMilli(ms).UnixNano()
+	default:
+		p.Timestamp = time.Now().UnixNano()
+	}
+	return p, nil
+}
+
+// flattenJSON stores a decoded JSON value under key, expanding objects and
+// arrays into dotted keys since Data only holds strings.
+func flattenJSON(dst map[string]string, key string, v interface{}) {
+	switch val := v.(type) {
+	case nil:
+	case string:
+		dst[key] = val
+	case json.Number:
+		dst[key] = val.String()
+	case bool:
+		dst[key] = strconv.FormatBool(val)
+	case float64:
+		dst[key] = strconv.FormatFloat(val, 'g', -1, 64)
+	case map[string]interface{}:
+		for k, item := range val {
+			flattenJSON(dst, key+"."+k, item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			flattenJSON(dst, key+"."+strconv.Itoa(i), item)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+)
+
+func postBulk(g *gatewayServer, index, body string) (*httptest.ResponseRecorder, esBulkResponse) {
+	req := httptest.NewRequest(http.MethodPost, "/_bulk", strings.NewReader(body))
+	req.SetPathValue("index", index)
+	req.SetBasicAuth(testProject, testKey)
+	rec := httptest.NewRecorder()
+	g.handleESBulk(rec, req)
+	var resp esBulkResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp
+}
+
+// itemStatuses lists the action and status of every bulk item.
+func itemStatuses(resp esBulkResponse) []string {
+	var out []string
+	for _, item := range resp.Items {
+		for action, it := range item {
+			out = append(out, action+" "+http.StatusText(it.Status))
+		}
+	}
+	return out
+}
+
+func TestHandleESBulk(t *testing.T) {
+	p := &fakeProducer{}
+	g, _ := newTestGateway(p, nil)
+	body := strings.Join([]string{
+		`{"index":{"_id":"doc-1"}}`,
+		`{"@timestamp":"2026-10-17T10:00:00Z","message":"hi","host":{"name":"web-1"},"tags":["a","b"]}`,
+		`{"create":{"_index":"audit"}}`,
+		`{"event":{"name":"login"},"@timestamp":1760695200000,"ok":true,"n":1.5}`,
+		`{"delete":{"_id":"doc-1"}}`,
+		`{"update":{"_id":"doc-1"}}`,
+		`{"doc":{"message":"changed"}}`,
+		`{"index":{}}`,
+		`{"@timestamp":"yesterday"}`,
+		``,
+	}, "\n")
+	rec, resp := postBulk(g, "app-logs", body)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Elastic-Product") != "Elasticsearch" {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	want := []string{"index Created", "create Created", "delete Bad Request", "update Bad Request", "index Bad Request"}
+	if got := itemStatuses(resp); !resp.Errors || !reflect.DeepEqual(got, want) {
+		t.Errorf("items = %v (errors %v), want %v", got, resp.Errors, want)
+	}
+
+	sent := sentRequests(t, p)
+	if len(sent) != 2 {
+		t.Fatalf("%d events produced, want 2", len(sent))
+	}
+	first, second := sent[0].Payload, sent[1].Payload
+	wantData := map[string]string{"@timestamp": "2026-10-17T10:00:00Z", "message": "hi", "host.name": "web-1", "tags.0": "a", "tags.1": "b"}
+	if first.Name != "app-logs" || first.EventId != "doc-1" || !reflect.DeepEqual(first.Data, wantData) ||
+		first.Timestamp != time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC).UnixNano() {
+		t.Errorf("first event = %v", first)
+	}
+	if second.Name != "login" || second.EventId != "" || second.Data["ok"] != "true" || second.Data["n"] != "1.5" ||
+		second.Timestamp != time.UnixMilli(1760695200000).UnixNano() {
+		t.Errorf("second event = %v", second)
+	}
+
+	if rec, _ := postBulk(g, "", `{"index":{}}`+"\n"); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing document: status %d", rec.Code)
+	}
+	if rec, _ := postBulk(g, "", `{"upsert":{}}`+"\n{}\n"); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown action: status %d", rec.Code)
+	}
+}
+
+// TestHandleESBulkPartial checks that documents of a chunk that was not
+// sent get a status bulk clients retry, unlike invalid ones.
+func TestHandleESBulkPartial(t *testing.T) {
+	p := &fakeProducer{}
+	g, _ := newTestGateway(p, &authpb.ApiKeyResponse{Limits: &authpb.ProjectLimits{DailyEvents: 2}})
+	g.maxBatch = 2
+	body := strings.Repeat(`{"index":{}}`+"\n"+`{"message":"x"}`+"\n", 3) + `{"index":{}}` + "\n" + `{"@timestamp":"bad"}` + "\n"
+	_, resp := postBulk(g, "logs", body)
+	want := []string{"index Created", "index Created", "index Too Many Requests", "index Bad Request"}
+	if got := itemStatuses(resp); !reflect.DeepEqual(got, want) {
+		t.Errorf("items = %v, want %v", got, want)
+	}
+	if n := len(p.sentValues()); n != 2 {
+		t.Errorf("%d events produced, want 2", n)
+	}
+}
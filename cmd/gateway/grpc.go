@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// SendLog implements ingestpb.IngestServiceServer on top of the same
+// code path as the HTTP /v1/logs handler.
+func (g *gatewayServer) SendLog(ctx context.Context, req *ingestpb.LogRequest) (*ingestpb.LogResponse, error) {
+	if err := g.sendLog(ctx, req); err != nil {
+		return nil, grpcIngestError(err)
+	}
+	return &ingestpb.LogResponse{Accepted: true}, nil
+}
+
+// SendLogBatch implements ingestpb.IngestServiceServer; rejected events are
+// reported per position in the response rather than failing the call.
+func (g *gatewayServer) SendLogBatch(ctx context.Context, req *ingestpb.LogBatchRequest) (*ingestpb.LogBatchResponse, error) {
+	resp, err := g.sendBatch(ctx, req)
+	if err != nil {
+		return nil, grpcIngestError(err)
+	}
+	return resp, nil
+}
+
+// grpcIngestError maps an ingest pipeline error onto a gRPC status.
+func grpcIngestError(err error) error {
+	switch {
+	case errors.Is(err, errUnauthorized):
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	case errors.Is(err, errAuthUnavailable):
+		return status.Error(codes.Unavailable, "api key validation unavailable")
+	case errors.Is(err, errInvalidPayload):
+		st := status.New(codes.InvalidArgument, err.Error())
+		var serr *schemaError
+		if errors.As(err, &serr) {
+			br := &errdetails.BadRequest{}
+			for _, f := range serr.Fields {
+				br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+					Field:       "payload." + f.Field,
+					Description: f.Message,
+				})
+			}
+			if withInfo, derr := st.WithDetails(br); derr == nil {
+				st = withInfo
+			}
+		}
+		return st.Err()
+	case errors.Is(err, errBatchTooLarge):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, errRateLimited):
+		st := status.New(codes.ResourceExhausted, err.Error())
+		var rl *rateLimitError
+		if errors.As(err, &rl) {
+			if withInfo, derr := st.WithDetails(&errdetails.RetryInfo{
+				RetryDelay: durationpb.New(rl.RetryAfter),
+			}); derr == nil {
+				st = withInfo
+			}
+		}
+		return st.Err()
+	case errors.Is(err, errKafkaUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, "server error")
+	}
+}
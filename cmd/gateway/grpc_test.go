@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+func TestSendLogGRPC(t *testing.T) {
+	p := &fakeProducer{}
+	g, _ := newTestGateway(p, nil)
+	client := dialTestGRPC(t, g)
+	ctx := context.Background()
+
+	// the project is resolved from the key when the client leaves it out
+	resp, err := client.SendLog(ctx, &ingestpb.LogRequest{
+		ApiKey:  testKey,
+		Payload: &ingestpb.LogPayload{Name: "login", Timestamp: 1, Data: map[string]string{"user": "bob"}},
+	})
+	if err != nil || !resp.Accepted {
+		t.Fatalf("SendLog = %v, %v", resp, err)
+	}
+	sent := sentRequests(t, p)
+	if len(sent) != 1 || sent[0].ProjectId != testProject || sent[0].ApiKey != testKey ||
+		sent[0].Payload.Name != "login" || sent[0].Payload.Data["user"] != "bob" || sent[0].Payload.ReceivedAt == 0 {
+		t.Fatalf("produced %v", sent)
+	}
+
+	tests := []struct {
+		name string
+		req  *ingestpb.LogRequest
+		code codes.Code
+	}{
+		{"no key", &ingestpb.LogRequest{Payload: &ingestpb.LogPayload{Name: "x"}}, codes.Unauthenticated},
+		{"wrong key", &ingestpb.LogRequest{ApiKey: "wrong", Payload: &ingestpb.LogPayload{Name: "x"}}, codes.Unauthenticated},
+		{"no payload", &ingestpb.LogRequest{ApiKey: testKey}, codes.InvalidArgument},
+		{"no name", &ingestpb.LogRequest{ApiKey: testKey, Payload: &ingestpb.LogPayload{}}, codes.InvalidArgument},
+		{"long event id", &ingestpb.LogRequest{ApiKey: testKey, Payload: &ingestpb.LogPayload{
+			Name: "x", EventId: strings.Repeat("e", maxEventIDLen+1),
+		}}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		if _, err := client.SendLog(ctx, tt.req); status.Code(err) != tt.code {
+			t.Errorf("%s: err = %v, want %s", tt.name, err, tt.code)
+		}
+	}
+
+	// without a spool a Kafka failure is Unavailable, so clients retry
+	p.mu.Lock()
+	p.failures = 1
+	p.mu.Unlock()
+	if _, err := client.SendLog(ctx, &ingestpb.LogRequest{ApiKey: testKey, Payload: &ingestpb.LogPayload{Name: "x"}}); status.Code(err) != codes.Unavailable {
+		t.Errorf("kafka down: err = %v, want Unavailable", err)
+	}
+	if n := len(p.sentValues()); n != 1 {
+		t.Errorf("%d events produced, want 1", n)
+	}
+}
+
+func TestGRPCIngestError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{errUnauthorized, codes.Unauthenticated},
+		{errAuthUnavailable, codes.Unavailable},
+		{fmt.Errorf("%w: name is required", errInvalidPayload), codes.InvalidArgument},
+		{fmt.Errorf("%w: 2 events, limit is 1", errBatchTooLarge), codes.ResourceExhausted},
+		{errKafkaUnavailable, codes.Unavailable},
+		{errors.New("boom"), codes.Internal},
+	}
+	for _, tt := range tests {
+		if got := status.Code(grpcIngestError(tt.err)); got != tt.code {
+			t.Errorf("grpcIngestError(%v) = %s, want %s", tt.err, got, tt.code)
+		}
+	}
+
+	st := status.Convert(grpcIngestError(&rateLimitError{Reason: "events_per_second", RetryAfter: 1500 * time.Millisecond}))
+	if st.Code() != codes.ResourceExhausted || len(st.Details()) != 1 {
+		t.Fatalf("rate limited: %v", st)
+	}
+	if ri, ok := st.Details()[0].(*errdetails.RetryInfo); !ok || ri.RetryDelay.AsDuration() != 1500*time.Millisecond {
+		t.Errorf("rate limited: details = %v", st.Details())
+	}
+
+	st = status.Convert(grpcIngestError(&schemaError{Fields: []fieldError{{"data.user", "required key is missing"}}}))
+	if st.Code() != codes.InvalidArgument || len(st.Details()) != 1 {
+		t.Fatalf("schema: %v", st)
+	}
+	br, ok := st.Details()[0].(*errdetails.BadRequest)
+	if !ok || len(br.FieldViolations) != 1 || br.FieldViolations[0].Field != "payload.data.user" {
+		t.Errorf("schema: details = %v", st.Details())
+	}
+}
+
+// TestHandleSendLog checks that /v1/logs answers like SendLog.
+func TestHandleSendLog(t *testing.T) {
+	p := &fakeProducer{}
+	g, _ := newTestGateway(p, &authpb.ApiKeyResponse{Limits: &authpb.ProjectLimits{DailyEvents: 1}})
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		g.handleSendLog(rec, httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader(body)))
+		return rec
+	}
+	event := `{"project_id":"` + testProject + `","api_key":"` + testKey + `","payload":{"name":"login"}}`
+
+	if rec := post(event); rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := post(event); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("over quota: status = %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := post(`{"api_key":"wrong","payload":{"name":"login"}}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong key: status = %d", rec.Code)
+	}
+	if rec := post(`{"api_key":`); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad JSON: status = %d", rec.Code)
+	}
+	if n := len(p.sentValues()); n != 1 {
+		t.Errorf("%d events produced, want 1", n)
+	}
+}
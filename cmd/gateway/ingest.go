@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+var (
+	errUnauthorized    = errors.New("invalid project_id or api_key")
+	errAuthUnavailable = errors.New("api key validation unavailable")
+	errInvalidPayload  = errors.New("invalid payload")
+	errBatchTooLarge   = errors.New("batch too large")
+	// the event could neither be produced to Kafka nor spooled
+	errKafkaUnavailable = errors.New("event store unavailable")
+)
+
+// maxEventIDLen bounds client-supplied event IDs, which become storage keys.
+const maxEventIDLen = 128
+
+// writeIngestError maps an ingest pipeline error onto an HTTP status.
+func writeIngestError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errUnauthorized):
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, errAuthUnavailable):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "api key validation unavailable", http.StatusServiceUnavailable)
+	case errors.Is(err, errInvalidPayload):
+		var serr *schemaError
+		if errors.As(err, &serr) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":  "schema violation",
+				"fields": serr.Fields,
+			})
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errBatchTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, errRateLimited):
+		var rl *rateLimitError
+		if errors.As(err, &rl) {
+			w.Header().Set("Retry-After", strconv.Itoa(rl.retryAfterSeconds()))
+		}
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, errKafkaUnavailable):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "server error", http.StatusInternalServerError)
+	}
+}
+
+// httpCredentials reads the project and API key of receivers whose body
+// format has no place for them. Besides the X-Project-ID and X-API-Key
+// headers, the key may be sent as a bearer token (OpenTelemetry exporters)
+// or as basic auth with the project as user name (Beats, Vector, Promtail),
+// and Loki clients name the project in X-Scope-OrgID.
+func httpCredentials(h http.Header) (projectID, apiKey string) {
+	projectID = h.Get("X-Project-ID")
+	if projectID == "" {
+		projectID = h.Get("X-Scope-OrgID")
+	}
+	apiKey = h.Get("X-API-Key")
+	if apiKey != "" {
+		return projectID, apiKey
+	}
+	auth := h.Get("Authorization")
+	if strings.HasPrefix(auth, "Bearer ") {
+		return projectID, strings.TrimPrefix(auth, "Bearer ")
+	}
+	r := http.Request{Header: http.Header{"Authorization": {auth}}}
+	if user, pass, ok := r.BasicAuth(); ok {
+		if user != "" {
+			projectID = user
+		}
+		apiKey = pass
+	}
+	return projectID, apiKey
+}
+
+// grpcCredentials is httpCredentials for incoming gRPC metadata.
+func grpcCredentials(ctx context.Context) (projectID, apiKey string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	h := http.Header{}
+	for _, k := range []string{"x-project-id", "x-api-key", "authorization"} {
+		if v := md.Get(k); len(v) > 0 {
+			h.Set(k, v[0])
+		}
+	}
+	return httpCredentials(h)
+}
+
+// authorize validates the project's API key (through the key cache) and
+// returns the project it belongs to; an empty projectID is resolved from
+// the key alone.
+func (g *gatewayServer) authorize(ctx context.Context, projectID, apiKey string) (string, error) {
+	if apiKey == "" {
+		return "", errUnauthorized
+	}
+	authResp, err := g.authCache.validate(ctx, projectID, apiKey)
+	if err != nil {
+		g.logger.Warn("api key validation failed", zap.Error(err))
+		return "", errAuthUnavailable
+	}
+	if !authResp.Valid {
+		return "", errUnauthorized
+	}
+	if authResp.ProjectId != "" {
+		projectID = authResp.ProjectId
+	}
+	setIngestProject(ctx, projectID)
+	g.limiter.setLimits(projectID, authResp.Limits)
+	g.policies.set(projectID, authResp)
+	return projectID, nil
+}
+
+// validatePayload rejects events the processor could not store.
+func validatePayload(p *ingestpb.LogPayload) error {
+	if p == nil {
+		return fmt.Errorf("%w: payload is required", errInvalidPayload)
+	}
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", errInvalidPayload)
+	}
+	if len(p.EventId) > maxEventIDLen {
+		return fmt.Errorf("%w: event_id is longer than %d bytes", errInvalidPayload, maxEventIDLen)
+	}
+	return nil
+}
+
+// newMessage wraps one event into a Kafka message for topic, keyed by
+// project for ordering.
+func (g *gatewayServer) newMessage(topic, projectID, apiKey string, p *ingestpb.LogPayload) (*sarama.ProducerMessage, error) {
+	data, err := proto.Marshal(&ingestpb.LogRequest{
+		ProjectId: projectID,
+		ApiKey:    apiKey,
+		Payload:   p,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(projectID),
+		Value: sarama.ByteEncoder(data),
+	}, nil
+}
+
+// sendLog is the single-event ingest path shared by the HTTP and gRPC
+// front-ends: it validates the API key and produces the request to Kafka.
+func (g *gatewayServer) sendLog(ctx context.Context, req *ingestpb.LogRequest) error {
+	projectID, err := g.authorize(ctx, req.ProjectId, req.ApiKey)
+	if err != nil {
+		return err
+	}
+	topic, err := g.validate(projectID, req.Payload)
+	if err != nil {
+		return err
+	}
+	msg, err := g.newMessage(topic, projectID, req.ApiKey, req.Payload)
+	if err != nil {
+		g.logger.Error("proto marshal failed", zap.Error(err))
+		return err
+	}
+	if err := g.limiter.allow(projectID, 1, int64(msg.Value.Length())); err != nil {
+		return err
+	}
+	msg.Metadata = 0
+	if len(g.produceAll([]*sarama.ProducerMessage{msg})) > 0 {
+		return errKafkaUnavailable
+	}
+	return nil
+}
+
+// sendBatch validates the API key once, produces every valid event with a
+// single SendMessages call and reports a result for each input position.
+func (g *gatewayServer) sendBatch(ctx context.Context, req *ingestpb.LogBatchRequest) (*ingestpb.LogBatchResponse, error) {
+	if g.maxBatch > 0 && len(req.Payloads) > g.maxBatch {
+		return nil, fmt.Errorf("%w: %d events, limit is %d", errBatchTooLarge, len(req.Payloads), g.maxBatch)
+	}
+	projectID, err := g.authorize(ctx, req.ProjectId, req.ApiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*ingestpb.LogResult, len(req.Payloads))
+	msgs := make([]*sarama.ProducerMessage, 0, len(req.Payloads))
+	for i, p := range req.Payloads {
+		results[i] = &ingestpb.LogResult{Index: int32(i), Accepted: true}
+		topic, err := g.validate(projectID, p)
+		if err != nil {
+			results[i].Accepted = false
+			results[i].Error = err.Error()
+			continue
+		}
+		msg, err := g.newMessage(topic, projectID, req.ApiKey, p)
+		if err != nil {
+			results[i].Accepted = false
+			results[i].Error = "server error"
+			continue
+		}
+		// remember the input position so producer errors map back to it
+		msg.Metadata = i
+		msgs = append(msgs, msg)
+	}
+	if err := g.limiter.allow(projectID, int64(len(msgs)), messagesBytes(msgs)); err != nil {
+		return nil, err
+	}
+
+	for i := range g.produceAll(msgs) {
+		results[i].Accepted = false
+		results[i].Error = "server error"
+	}
+
+	resp := &ingestpb.LogBatchResponse{Results: results}
+	for _, r := range results {
+		if r.Accepted {
+			resp.Accepted++
+		} else {
+			resp.Rejected++
+		}
+	}
+	return resp, nil
+}
+
+// partialSendError is returned by sendAll when a chunk fails after earlier
+// ones were produced. The events before unsent are settled; those from
+// unsent on are rejected in the response and may be resent.
+type partialSendError struct {
+	unsent int
+	err    error
+}
+
+func (e *partialSendError) Error() string { return e.err.Error() }
+
+func (e *partialSendError) Unwrap() error { return e.err }
+
+// sendAll is sendBatch for receivers whose clients choose their own batch
+// size: payloads are split into chunks of at most maxBatch events and the
+// per-event results are merged back in input order. When a chunk fails, a
+// response is still returned if earlier chunks were produced, along with a
+// *partialSendError, so that clients resend only the unsent events.
+func (g *gatewayServer) sendAll(ctx context.Context, projectID, apiKey string, payloads []*ingestpb.LogPayload) (*ingestpb.LogBatchResponse, error) {
+	size := g.maxBatch
+	if size <= 0 {
+		size = len(payloads)
+	}
+	out := &ingestpb.LogBatchResponse{}
+	for start := 0; start < len(payloads); start += size {
+		end := min(start+size, len(payloads))
+		resp, err := g.sendBatch(ctx, &ingestpb.LogBatchRequest{
+			ProjectId: projectID,
+			ApiKey:    apiKey,
+			Payloads:  payloads[start:end],
+		})
+		if err != nil {
+			if start == 0 {
+				return nil, err
+			}
+			for i := start; i < len(payloads); i++ {
+				out.Results = append(out.Results, &ingestpb.LogResult{Index: int32(i), Error: err.Error()})
+				out.Rejected++
+			}
+			return out, &partialSendError{unsent: start, err: err}
+		}
+		for _, r := range resp.Results {
+			r.Index += int32(start)
+			out.Results = append(out.Results, r)
+		}
+		out.Accepted += resp.Accepted
+		out.Rejected += resp.Rejected
+	}
+	return out, nil
+}
+
+// messagesBytes is the total encoded size of msgs, as counted against a
+// project's byte limits.
+func messagesBytes(msgs []*sarama.ProducerMessage) int64 {
+	var n int64
+	for _, msg := range msgs {
+		n += int64(msg.Value.Length())
+	}
+	return n
+}
+
+// produceAll sends msgs with a single SendMessages call. Each message's
+// Metadata must hold its int position; the positions that could neither be
+// produced nor spooled are returned. While the spool holds events, new ones
+// are appended behind them instead so that Kafka receives them in order.
+func (g *gatewayServer) produceAll(msgs []*sarama.ProducerMessage) map[int]bool {
+	failed := map[int]bool{}
+	if len(msgs) == 0 {
+		return failed
+	}
+	if g.spool != nil && g.spool.pending() {
+		return g.spoolAll(msgs)
+	}
+	err := g.kafkaProd.SendMessages(msgs)
+	if err == nil {
+		return failed
+	}
+	g.logger.Error("kafka batch send failed", zap.Error(err))
+	unsent := sendFailures(err)
+	if g.spool != nil {
+		// Messages after the first failure may be in Kafka already, but
+		// spooling only the failed ones would replay them behind those.
+		// The whole tail is spooled instead: events with an event ID are
+		// de-duplicated by the processor, and those without one are stored
+		// twice, as counted in gateway_spool_resent_events_total.
+		first, resent := resendTail(msgs, unsent)
+		failed = g.spoolAll(msgs[first:])
+		if len(failed) == 0 {
+			spoolResentCounter.Add(float64(resent))
+		}
+		return failed
+	}
+	for _, msg := range msgs {
+		if unsent == nil || unsent[msg] {
+			failed[msg.Metadata.(int)] = true
+		}
+	}
+	return failed
+}
+
+// sendFailures returns the messages of a failed SendMessages call that
+// were not produced, or nil when the whole call failed.
+func sendFailures(err error) map[*sarama.ProducerMessage]bool {
+	var perrs sarama.ProducerErrors
+	if !errors.As(err, &perrs) {
+		return nil
+	}
+	unsent := make(map[*sarama.ProducerMessage]bool, len(perrs))
+	for _, perr := range perrs {
+		unsent[perr.Msg] = true
+	}
+	return unsent
+}
+
+// resendTail returns the position of the first of msgs that was not
+// produced, and how many after it Kafka accepted anyway; resending the
+// tail in order produces those again. A nil unsent means none was
+// produced.
+func resendTail(msgs []*sarama.ProducerMessage, unsent map[*sarama.ProducerMessage]bool) (first, resent int) {
+	if unsent == nil {
+		return 0, 0
+	}
+	first = len(msgs)
+	for i, msg := range msgs {
+		if unsent[msg] {
+			first = i
+			break
+		}
+	}
+	for _, msg := range msgs[first:] {
+		if !unsent[msg] {
+			resent++
+		}
+	}
+	return first, resent
+}
+
+// spoolAll appends msgs to the spool; on failure every position is returned
+// as failed.
+func (g *gatewayServer) spoolAll(msgs []*sarama.ProducerMessage) map[int]bool {
+	failed := map[int]bool{}
+	if err := g.spool.append(msgs); err != nil {
+		g.logger.Error("spool append failed", zap.Error(err), zap.Int("events", len(msgs)))
+		for _, msg := range msgs {
+			failed[msg.Metadata.(int)] = true
+		}
+	}
+	return failed
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+const (
+	testProject = "7b0b7a51-4a4b-4a8e-9d1e-5c1f0f4f7e11"
+	testKey     = "key-1"
+)
+
+// fakeAuth answers ValidateApiKey from keys; unknown keys are invalid.
+// While block is set, calls wait until it is closed.
+type fakeAuth struct {
+	authpb.AuthServiceClient
+
+	mu    sync.Mutex
+	keys  map[string]*authpb.ApiKeyResponse
+	err   error
+	block chan struct{}
+	calls int
+}
+
+func (a *fakeAuth) ValidateApiKey(ctx context.Context, req *authpb.ApiKeyRequest, _ ...grpc.CallOption) (*authpb.ApiKeyResponse, error) {
+	a.mu.Lock()
+	a.calls++
+	resp, ok := a.keys[req.ApiKey]
+	err, block := a.err, a.block
+	a.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &authpb.ApiKeyResponse{}, nil
+	}
+	return resp, nil
+}
+
+func (a *fakeAuth) callCount() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.calls
+}
+
+// newTestGateway is a gateway producing to p whose only API key, testKey,
+// answers with auth; a nil auth is a plain valid key of testProject.
+func newTestGateway(p sarama.SyncProducer, auth *authpb.ApiKeyResponse) (*gatewayServer, *fakeAuth) {
+	if auth == nil {
+		auth = &authpb.ApiKeyResponse{}
+	}
+	auth.Valid, auth.ProjectId = true, testProject
+	fa := &fakeAuth{keys: map[string]*authpb.ApiKeyResponse{testKey: auth}}
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	return &gatewayServer{
+		logger:               zap.NewNop(),
+		kafkaProd:            p,
+		kafkaTopic:           "logs_raw",
+		authCache:            newAPIKeyCache(fa, time.Minute, time.Minute, 0, 100),
+		limiter:              newRateLimiter(),
+		policies:             newPolicyRegistry(zap.NewNop()),
+		maxBatch:             1000,
+		quarantineTopic:      "logs_quarantine",
+		maxBodyBytes:         1 << 20,
+		maxDecompressedBytes: 4 << 20,
+		streamBatch:          10,
+		streamFlush:          10 * time.Millisecond,
+		now:                  func() time.Time { return now },
+	}, fa
+}
+
+// sentRequests decodes what p was sent.
+func sentRequests(t *testing.T, p *fakeProducer) []*ingestpb.LogRequest {
+	t.Helper()
+	var out []*ingestpb.LogRequest
+	for _, v := range p.sentValues() {
+		req := &ingestpb.LogRequest{}
+		if err := proto.Unmarshal([]byte(v), req); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, req)
+	}
+	return out
+}
+
+func TestHTTPCredentials(t *testing.T) {
+	basic := func(user, pass string) string {
+		r := http.Request{Header: http.Header{}}
+		r.SetBasicAuth(user, pass)
+		return r.Header.Get("Authorization")
+	}
+	tests := []struct {
+		name            string
+		header          http.Header
+		project, apiKey string
+	}{
+		{"headers", http.Header{"X-Project-Id": {"p1"}, "X-Api-Key": {"k1"}}, "p1", "k1"},
+		{"loki tenant", http.Header{"X-Scope-Orgid": {"p2"}, "X-Api-Key": {"k1"}}, "p2", "k1"},
+		{"X-Project-ID wins", http.Header{"X-Project-Id": {"p1"}, "X-Scope-Orgid": {"p2"}}, "p1", ""},
+		{"bearer", http.Header{"Authorization": {"Bearer k2"}}, "", "k2"},
+		{"X-API-Key wins", http.Header{"X-Api-Key": {"k1"}, "Authorization": {"Bearer k2"}}, "", "k1"},
+		{"basic", http.Header{"Authorization": {basic("p3", "k3")}}, "p3", "k3"},
+		{"basic without user", http.Header{"X-Project-Id": {"p1"}, "Authorization": {basic("", "k3")}}, "p1", "k3"},
+		{"none", http.Header{}, "", ""},
+	}
+	for _, tt := range tests {
+		project, apiKey := httpCredentials(tt.header)
+		if project != tt.project || apiKey != tt.apiKey {
+			t.Errorf("%s: httpCredentials = %q, %q, want %q, %q", tt.name, project, apiKey, tt.project, tt.apiKey)
+		}
+	}
+}
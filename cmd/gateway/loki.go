@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang/snappy"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+	lokipb "github.com/parishadmk/log-system-analysis/internal/api/loki"
+)
+
+// lokiPushJSON is the JSON form of a Loki push; each value is
+// [ "<unix nanos>", "<line>" ] with optional structured metadata as a
+// third element.
+type lokiPushJSON struct {
+	Streams []struct {
+		Stream map[string]string   `json:"stream"`
+		Values [][]json.RawMessage `json:"values"`
+	} `json:"streams"`
+}
+
+// handleLokiPush accepts Loki's /loki/api/v1/push in both the snappy
+// compressed protobuf and the JSON encoding. Stream labels and structured
+// metadata are stored in Data, the line as data["message"], and the
+// event_name (else job) label names the event.
+func (g *gatewayServer) handleLokiPush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	var payloads []*ingestpb.LogPayload
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if ct == "application/json" {
+		payloads, err = decodeLokiJSON(body)
+	} else {
+		payloads, err = decodeLokiProto(body, g.maxDecompressedBytes)
+	}
+	if errors.Is(err, errBodyTooLarge) {
+		writeBodyError(w, err)
+		return
+	}
+	if err != nil {
+		g.logger.Warn("invalid loki push", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	projectID, apiKey := httpCredentials(r.Header)
+	resp, err := g.sendAll(r.Context(), projectID, apiKey, payloads)
+	var partial *partialSendError
+	if err != nil && !errors.As(err, &partial) {
+		writeIngestError(w, err)
+		return
+	}
+	// like Loki, keep the valid entries and report the invalid ones with
+	// 400. Clients do not retry a 400, which matters for a push that was
+	// only partly sent: retrying it whole would store the entries already
+	// produced twice, since Loki entries have no event ID.
+	if resp.Rejected > 0 {
+		for _, res := range resp.Results {
+			if !res.Accepted {
+				http.Error(w, fmt.Sprintf("%d entries rejected, first: entry %d: %s",
+					resp.Rejected, res.Index, res.Error), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeLokiProto(body []byte, limit int64) ([]*ingestpb.LogPayload, error) {
+	n, err := snappy.DecodedLen(body)
+	if err != nil {
+		return nil, fmt.Errorf("snappy: %w", err)
+	}
+	if int64(n) > limit {
+		return nil, errBodyTooLarge
+	}
+	raw, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("snappy: %w", err)
+	}
+	var req lokipb.PushRequest
+	if err := proto.Unmarshal(raw, &req); err != nil {
+		return nil, fmt.Errorf("protobuf: %w", err)
+	}
+	var payloads []*ingestpb.LogPayload
+	for _, st := range req.Streams {
+		labels, err := parseLokiLabels(st.Labels)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range st.Entries {
+			p := lokiPayload(labels, e.Line, e.Timestamp.AsTime().UnixNano())
+			for _, md := range e.StructuredMetadata {
+				p.Data[md.Name] = md.Value
+			}
+			payloads = append(payloads, p)
+		}
+	}
+	return payloads, nil
+}
+
+func decodeLokiJSON(body []byte) ([]*ingestpb.LogPayload, error) {
+	var req lokiPushJSON
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	var payloads []*ingestpb.LogPayload
+	for _, st := range req.Streams {
+		for _, v := range st.Values {
+			if len(v) < 2 {
+				return nil, fmt.Errorf("json: value must be [timestamp, line]")
+			}
+			var tsStr, line string
+			if err := json.Unmarshal(v[0], &tsStr); err != nil {
+				return nil, fmt.Errorf("json: timestamp must be a string of unix nanoseconds")
+			}
+			ts, err := strconv.ParseInt(tsStr, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("json: invalid timestamp %q", tsStr)
+			}
+			if err := json.Unmarshal(v[1], &line); err != nil {
+				return nil, fmt.Errorf("json: line must be a string")
+			}
+			p := lokiPayload(st.Stream, line, ts)
+			if len(v) > 2 {
+				var md map[string]string
+				if err := json.Unmarshal(v[2], &md); err != nil {
+					return nil, fmt.Errorf("json: structured metadata must be an object of strings")
+				}
+				for k, val := range md {
+					p.Data[k] = val
+				}
+			}
+			payloads = append(payloads, p)
+		}
+	}
+	return payloads, nil
+}
+
+func lokiPayload(labels map[string]string, line string, ts int64) *ingestpb.LogPayload {
+	data := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		data[k] = v
+	}
+	data["message"] = line
+	name := labels["event_name"]
+	if name == "" {
+		name = labels["job"]
+	}
+	if name == "" {
+		name = "loki"
+	}
+	return &ingestpb.LogPayload{Name: name, Timestamp: ts, Data: data}
+}
+
+// parseLokiLabels parses a Prometheus label set such as
+// {job="varlogs", path="C:\\logs"}.
+func parseLokiLabels(s string) (map[string]string, error) {
+	labels := map[string]string{}
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("labels: expected {...}, got %q", s)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("labels: expected name=\"value\" in %q", s)
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = strings.TrimSpace(s[eq+1:])
+		if !strings.HasPrefix(s, `"`) {
+			return nil, fmt.Errorf("labels: value of %s must be quoted", name)
+		}
+		// find the closing quote, skipping escaped characters
+		end := 1
+		for end < len(s) && s[end] != '"' {
+			if s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(s) {
+			return nil, fmt.Errorf("labels: unterminated value of %s", name)
+		}
+		value, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("labels: invalid value of %s: %v", name, err)
+		}
+		labels[name] = value
+		s = strings.TrimSpace(s[end+1:])
+		s = strings.TrimSpace(strings.TrimPrefix(s, ","))
+	}
+	return labels, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+	lokipb "github.com/parishadmk/log-system-analysis/internal/api/loki"
+)
+
+func TestParseLokiLabels(t *testing.T) {
+	tests := []struct {
+		in   string
+		want map[string]string
+	}{
+		{`{}`, map[string]string{}},
+		{`{job="varlogs"}`, map[string]string{"job": "varlogs"}},
+		{` { job = "a" , host="b",} `, map[string]string{"job": "a", "host": "b"}},
+		{`{path="C:\\logs", msg="say \"hi\", ok"}`, map[string]string{"path": `C:\logs`, "msg": `say "hi", ok`}},
+	}
+	for _, tt := range tests {
+		got, err := parseLokiLabels(tt.in)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseLokiLabels(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{`job="a"`, `{job=a}`, `{="a"}`, `{job="a}`, `{job="\q"}`} {
+		if _, err := parseLokiLabels(in); err == nil {
+			t.Errorf("parseLokiLabels(%q) did not fail", in)
+		}
+	}
+}
+
+// TestDecodeLoki checks that the protobuf and JSON encodings of one push
+// decode to the same events.
+func TestDecodeLoki(t *testing.T) {
+	ts := time.Unix(1700000000, 5)
+	push := &lokipb.PushRequest{Streams: []*lokipb.StreamAdapter{
+		{Labels: `{job="app", env="prod"}`, Entries: []*lokipb.EntryAdapter{
+			{Timestamp: timestamppb.New(ts), Line: "started", StructuredMetadata: []*lokipb.LabelPairAdapter{{Name: "trace_id", Value: "abc"}}},
+		}},
+		{Labels: `{event_name="login", job="auth"}`, Entries: []*lokipb.EntryAdapter{
+			{Timestamp: timestamppb.New(ts), Line: "bob"},
+		}},
+		{Labels: `{}`, Entries: []*lokipb.EntryAdapter{{Timestamp: timestamppb.New(ts), Line: "bare"}}},
+	}}
+	want := []*ingestpb.LogPayload{
+		{Name: "app", Timestamp: ts.UnixNano(), Data: map[string]string{"job": "app", "env": "prod", "message": "started", "trace_id": "abc"}},
+		{Name: "login", Timestamp: ts.UnixNano(), Data: map[string]string{"event_name": "login", "job": "auth", "message": "bob"}},
+		{Name: "loki", Timestamp: ts.UnixNano(), Data: map[string]string{"message": "bare"}},
+	}
+
+	raw, err := proto.Marshal(push)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromProto, err := decodeLokiProto(snappy.Encode(nil, raw), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := decodeLokiJSON([]byte(`{"streams":[
+		{"stream":{"job":"app","env":"prod"},"values":[["1700000000000000005","started",{"trace_id":"abc"}]]},
+		{"stream":{"event_name":"login","job":"auth"},"values":[["1700000000000000005","bob"]]},
+		{"stream":{},"values":[["1700000000000000005","bare"]]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, got := range map[string][]*ingestpb.LogPayload{"protobuf": fromProto, "json": fromJSON} {
+		if len(got) != len(want) {
+			t.Errorf("%s: %d events, want %d", name, len(got), len(want))
+			continue
+		}
+		for i := range want {
+			if !proto.Equal(got[i], want[i]) {
+				t.Errorf("%s: event %d = %v, want %v", name, i, got[i], want[i])
+			}
+		}
+	}
+
+	if _, err := decodeLokiProto(snappy.Encode(nil, raw), int64(len(raw)-1)); !errors.Is(err, errBodyTooLarge) {
+		t.Errorf("over the decoded limit: err = %v", err)
+	}
+	for _, body := range []string{
+		`{"streams":[{"stream":{},"values":[["1"]]}]}`,
+		`{"streams":[{"stream":{},"values":[[1,"x"]]}]}`,
+		`{"streams":[{"stream":{},"values":[["x","x"]]}]}`,
+		`{"streams":[{"stream":{},"values":[["1",{}]]}]}`,
+		`{"streams":[{"stream":{},"values":[["1","x",{"n":1}]]}]}`,
+	} {
+		if _, err := decodeLokiJSON([]byte(body)); err == nil {
+			t.Errorf("decodeLokiJSON(%s) did not fail", body)
+		}
+	}
+}
+
+func TestLokiPushProtobuf(t *testing.T) {
+	p := &fakeProducer{}
+	g, _ := newTestGateway(p, nil)
+	raw, _ := proto.Marshal(&lokipb.PushRequest{Streams: []*lokipb.StreamAdapter{
+		{Labels: `{job="app"}`, Entries: []*lokipb.EntryAdapter{{Timestamp: timestamppb.Now(), Line: "x"}}},
+	}})
+	req := httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", bytes.NewReader(snappy.Encode(nil, raw)))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Scope-OrgID", testProject)
+	req.Header.Set("Authorization", "Bearer "+testKey)
+	rec := httptest.NewRecorder()
+	g.handleLokiPush(rec, req)
+	if rec.Code != http.StatusNoContent || len(p.sentValues()) != 1 {
+		t.Errorf("status %d, %d produced: %s", rec.Code, len(p.sentValues()), rec.Body)
+	}
+}
+
+// TestLokiPushPartialSend checks that a push cut short by a later chunk is
+// answered with 400, which Promtail does not retry, and not with the
+// chunk's 429, after which it would resend the entries already produced.
+func TestLokiPushPartialSend(t *testing.T) {
+	p := &fakeProducer{}
+	g, _ := newTestGateway(p, &authpb.ApiKeyResponse{Limits: &authpb.ProjectLimits{DailyEvents: 2}})
+	g.maxBatch = 2
+
+	body := `{"streams":[{"stream":{"job":"app"},"values":[
+		["1700000000000000000","one"],["1700000000000000001","two"],
+		["1700000000000000002","three"],["1700000000000000003","four"]]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", testKey)
+	rec := httptest.NewRecorder()
+	g.handleLokiPush(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), "2 entries rejected, first: entry 2") {
+		t.Errorf("body = %q", rec.Body)
+	}
+	sent := sentRequests(t, p)
+	if len(sent) != 2 || sent[0].Payload.Data["message"] != "one" || sent[1].Payload.Data["message"] != "two" {
+		t.Fatalf("sent %v, want the first chunk", sent)
+	}
+}
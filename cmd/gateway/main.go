@@ -1,132 +1,221 @@
 package main
 
 import (
-    "context"
-    "encoding/json"
-    "fmt"
-    "net/http"
-    "time"
-
-    "github.com/spf13/viper"
-    "go.uber.org/zap"
-    "github.com/IBM/sarama"
-    "google.golang.org/grpc"
-    "google.golang.org/protobuf/proto"
-
-    authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
-    ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
-    "github.com/parishadmk/log-system-analysis/internal/lib"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/spf13/viper"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+	"github.com/parishadmk/log-system-analysis/internal/lib"
 )
 
 type gatewayServer struct {
-    logger     *zap.Logger
-    kafkaProd  sarama.SyncProducer
-    kafkaTopic string
-    authClient authpb.AuthServiceClient
+	ingestpb.UnimplementedIngestServiceServer
+	logger     *zap.Logger
+	kafkaProd  sarama.SyncProducer
+	kafkaTopic string
+	authCache  *apiKeyCache
+	limiter    *rateLimiter
+	policies   *policyRegistry
+	maxBatch   int
+	// on-disk spool for Kafka outages; nil when disabled
+	spool *spool
+
+	// invalid events of quarantine-mode projects are produced here
+	quarantineTopic string
+
+	// request body limits, before and after Content-Encoding
+	maxBodyBytes         int64
+	maxDecompressedBytes int64
+
+	// StreamLogs flush thresholds
+	streamBatch int
+	streamFlush time.Duration
+
+	// clock for received_at stamps
+	now func() time.Time
 }
 
 // request shape matches ingestpb.LogRequest
 type httpLogRequest struct {
-    ProjectID string            `json:"project_id"`
-    ApiKey    string            `json:"api_key"`
-    Payload   ingestpb.LogPayload `json:"payload"`
+	ProjectID string              `json:"project_id"`
+	ApiKey    string              `json:"api_key"`
+	Payload   ingestpb.LogPayload `json:"payload"`
 }
 
 func main() {
-    // Init logger
-    if err := lib.InitLogger(); err != nil {
-        panic(err)
-    }
-    logger := lib.Log
-
-    // Load config
-    viper.SetConfigFile("/etc/gateway/config.yml")
-    if err := lib.LoadConfig(viper.ConfigFileUsed()); err != nil {
-        logger.Fatal("failed to load config", zap.Error(err))
-    }
-    brokers := viper.GetStringSlice("kafka.brokers")
-    topic := viper.GetString("kafka.topic")
-    authAddr := viper.GetString("authsvc.address")
-    port := viper.GetString("server.port")
-
-    // Kafka producer
-    prod, err := lib.NewKafkaProducer(brokers)
-    if err != nil {
-        logger.Fatal("kafka producer init failed", zap.Error(err))
-    }
-
-    // gRPC auth client
-    conn, err := grpc.Dial(authAddr, grpc.WithInsecure())
-    if err != nil {
-        logger.Fatal("failed to dial authsvc", zap.Error(err))
-    }
-    authClient := authpb.NewAuthServiceClient(conn)
-
-    srv := &gatewayServer{
-        logger:     logger,
-        kafkaProd:  prod,
-        kafkaTopic: topic,
-        authClient: authClient,
-    }
-
-    http.HandleFunc("/v1/logs", srv.handleSendLog)
-    logger.Info("Gateway listening", zap.String("port", port))
-    if err := http.ListenAndServe(":"+port, nil); err != nil {
-        logger.Fatal("HTTP server failed", zap.Error(err))
-    }
+	// Init logger
+	if err := lib.InitLogger(); err != nil {
+		panic(err)
+	}
+	logger := lib.Log
+
+	// Load config
+	viper.SetConfigFile("/etc/gateway/config.yml")
+	if err := lib.LoadConfig(viper.ConfigFileUsed()); err != nil {
+		logger.Fatal("failed to load config", zap.Error(err))
+	}
+	brokers := viper.GetStringSlice("kafka.brokers")
+	topic := viper.GetString("kafka.topic")
+	viper.SetDefault("kafka.quarantine_topic", "logs_quarantine")
+	authAddr := viper.GetString("authsvc.address")
+	port := viper.GetString("server.port")
+	grpcPort := viper.GetString("server.grpc_port")
+	viper.SetDefault("ingest.max_batch_size", 1000)
+	maxBatch := viper.GetInt("ingest.max_batch_size")
+	viper.SetDefault("authcache.ttl", time.Minute)
+	viper.SetDefault("authcache.negative_ttl", 10*time.Second)
+	viper.SetDefault("authcache.stale", 5*time.Minute)
+	viper.SetDefault("authcache.max_entries", 100000)
+	viper.SetDefault("ingest.max_body_bytes", 10<<20)
+	viper.SetDefault("ingest.max_decompressed_bytes", 50<<20)
+	viper.SetDefault("stream.batch_size", 500)
+	viper.SetDefault("stream.flush_interval", time.Second)
+	viper.SetDefault("spool.enabled", true)
+	viper.SetDefault("spool.dir", "/var/lib/gateway/spool")
+	viper.SetDefault("spool.max_bytes", 1<<30)
+	viper.SetDefault("spool.segment_bytes", 16<<20)
+	viper.SetDefault("spool.overflow", spoolOverflowReject)
+	viper.SetDefault("ratelimit.idle_timeout", 10*time.Minute)
+
+	// Kafka producer
+	prod, err := lib.NewKafkaProducer(brokers)
+	if err != nil {
+		logger.Fatal("kafka producer init failed", zap.Error(err))
+	}
+
+	// gRPC auth client
+	conn, err := grpc.Dial(authAddr, grpc.WithInsecure())
+	if err != nil {
+		logger.Fatal("failed to dial authsvc", zap.Error(err))
+	}
+	authClient := authpb.NewAuthServiceClient(conn)
+	authCache := newAPIKeyCache(authClient,
+		viper.GetDuration("authcache.ttl"),
+		viper.GetDuration("authcache.negative_ttl"),
+		viper.GetDuration("authcache.stale"),
+		viper.GetInt("authcache.max_entries"))
+
+	srv := &gatewayServer{
+		logger:     logger,
+		kafkaProd:  prod,
+		kafkaTopic: topic,
+		authCache:  authCache,
+		limiter:    newRateLimiter(),
+		policies:   newPolicyRegistry(logger),
+		maxBatch:   maxBatch,
+
+		quarantineTopic: viper.GetString("kafka.quarantine_topic"),
+
+		maxBodyBytes:         viper.GetInt64("ingest.max_body_bytes"),
+		maxDecompressedBytes: viper.GetInt64("ingest.max_decompressed_bytes"),
+
+		streamBatch: viper.GetInt("stream.batch_size"),
+		streamFlush: viper.GetDuration("stream.flush_interval"),
+
+		now: time.Now,
+	}
+	if idle := viper.GetDuration("ratelimit.idle_timeout"); idle > 0 {
+		go srv.limiter.evictLoop(idle)
+	}
+
+	// Local spool: events are kept on disk while Kafka is unavailable and
+	// replayed in order once the brokers are back
+	if viper.GetBool("spool.enabled") {
+		sp, err := openSpool(viper.GetString("spool.dir"),
+			viper.GetInt64("spool.max_bytes"), viper.GetInt64("spool.segment_bytes"),
+			viper.GetString("spool.overflow"), logger)
+		if err != nil {
+			logger.Fatal("spool init failed", zap.Error(err))
+		}
+		srv.spool = sp
+		go sp.replay(prod)
+	}
+
+	// Syslog receivers
+	var syslogListeners []syslogListenerConfig
+	if err := viper.UnmarshalKey("syslog.listeners", &syslogListeners); err != nil {
+		logger.Fatal("invalid syslog config", zap.Error(err))
+	}
+	viper.SetDefault("syslog.batch_size", 500)
+	viper.SetDefault("syslog.flush_interval", time.Second)
+	if err := srv.startSyslogListeners(syslogListeners,
+		viper.GetInt("syslog.batch_size"), viper.GetDuration("syslog.flush_interval")); err != nil {
+		logger.Fatal("syslog listener init failed", zap.Error(err))
+	}
+
+	// gRPC ingest server
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		logger.Fatal("grpc listen failed", zap.Error(err))
+	}
+	// gzip-compressed calls are accepted via the registered codec; the
+	// receive limit applies to the decompressed message, the body limits
+	// to each message as sent
+	bodyLimits := &grpcBodyLimits{maxBodyBytes: srv.maxBodyBytes}
+	grpcServer := grpc.NewServer(
+		grpc.MaxRecvMsgSize(int(srv.maxDecompressedBytes)),
+		grpc.StatsHandler(bodyLimits),
+		grpc.UnaryInterceptor(bodyLimits.unaryInterceptor),
+		grpc.StreamInterceptor(bodyLimits.streamInterceptor),
+	)
+	ingestpb.RegisterIngestServiceServer(grpcServer, srv)
+	collogspb.RegisterLogsServiceServer(grpcServer, &otlpLogsServer{g: srv})
+	go func() {
+		logger.Info("Gateway gRPC listening", zap.String("port", grpcPort))
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Fatal("gRPC serve failed", zap.Error(err))
+		}
+	}()
+
+	initMetrics()
+	http.HandleFunc("/v1/logs", srv.withBodyLimits(srv.handleSendLog))
+	http.HandleFunc("/v1/logs/batch", srv.withBodyLimits(srv.handleSendLogBatch))
+	http.HandleFunc("/v1/otlp/logs", srv.withBodyLimits(srv.handleOTLPLogs))
+	// drop-in receivers for existing shippers
+	http.HandleFunc("GET /{$}", srv.handleESRoot)
+	http.HandleFunc("POST /_bulk", srv.withBodyLimits(srv.handleESBulk))
+	http.HandleFunc("POST /{index}/_bulk", srv.withBodyLimits(srv.handleESBulk))
+	http.HandleFunc("/loki/api/v1/push", srv.withBodyLimits(srv.handleLokiPush))
+	logger.Info("Gateway listening", zap.String("port", port))
+	if err := http.ListenAndServe(":"+port, nil); err != nil {
+		logger.Fatal("HTTP server failed", zap.Error(err))
+	}
 }
 
 func (g *gatewayServer) handleSendLog(w http.ResponseWriter, r *http.Request) {
-    if r.Method != http.MethodPost {
-        http.Error(w, "only POST allowed", http.StatusMethodNotAllowed)
-        return
-    }
-
-    var req httpLogRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        g.logger.Warn("invalid JSON", zap.Error(err))
-        http.Error(w, "bad request", http.StatusBadRequest)
-        return
-    }
-
-    // 1) Validate API key
-    ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
-    defer cancel()
-    authResp, err := g.authClient.ValidateApiKey(ctx, &authpb.ApiKeyRequest{
-        ProjectId: req.ProjectID,
-        ApiKey:    req.ApiKey,
-    })
-    if err != nil || !authResp.Valid {
-        http.Error(w, "unauthorized", http.StatusUnauthorized)
-        return
-    }
-
-    // 2) Build protobuf message
-    pb := &ingestpb.LogRequest{
-        ProjectId: req.ProjectID,
-        ApiKey:    req.ApiKey,
-        Payload:   &req.Payload,
-    }
-    data, err := proto.Marshal(pb)
-    if err != nil {
-        g.logger.Error("proto marshal failed", zap.Error(err))
-        http.Error(w, "server error", http.StatusInternalServerError)
-        return
-    }
-
-    // 3) Produce to Kafka (keyed by project for ordering)
-    msg := &sarama.ProducerMessage{
-        Topic: g.kafkaTopic,
-        Key:   sarama.StringEncoder(req.ProjectID),
-        Value: sarama.ByteEncoder(data),
-    }
-    if _, _, err := g.kafkaProd.SendMessage(msg); err != nil {
-        g.logger.Error("kafka send failed", zap.Error(err))
-        http.Error(w, "server error", http.StatusInternalServerError)
-        return
-    }
-
-    // 4) Ack
-    w.WriteHeader(http.StatusAccepted)
-    fmt.Fprint(w, `{"accepted":true}`)
-}
\ No newline at end of file
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req httpLogRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		g.logger.Warn("invalid JSON", zap.Error(err))
+		writeBodyError(w, err)
+		return
+	}
+
+	err := g.sendLog(r.Context(), &ingestpb.LogRequest{
+		ProjectId: req.ProjectID,
+		ApiKey:    req.ApiKey,
+		Payload:   &req.Payload,
+	})
+	if err != nil {
+		writeIngestError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprint(w, `{"accepted":true}`)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	syslogMessagesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_syslog_messages_total",
+		Help: "Total number of syslog messages received, per listener",
+	}, []string{"listener"})
+	syslogParseErrorCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_syslog_parse_errors_total",
+		Help: "Total number of syslog messages that could not be parsed, per listener",
+	}, []string{"listener"})
+	syslogDroppedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_syslog_dropped_messages_total",
+		Help: "Total number of UDP syslog messages dropped because the listener's queue was full, per listener",
+	}, []string{"listener"})
+	compressionRatioHist = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_request_compression_ratio",
+		Help:    "Decompressed/compressed size of compressed ingest request bodies, per project",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 8),
+	}, []string{"project_id", "encoding"})
+	authCacheCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_auth_cache_lookups_total",
+		Help: "API key validations by cache outcome (hit, stale, miss)",
+	}, []string{"result"})
+	rateLimitedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_rate_limited_requests_total",
+		Help: "Total number of ingest requests rejected by a project's rate limits or quotas",
+	}, []string{"project_id", "reason"})
+	schemaViolationCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_schema_violations_total",
+		Help: "Events that failed their project's schema, by action (rejected, quarantined)",
+	}, []string{"project_id", "action"})
+	timestampAdjustedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_timestamp_adjustments_total",
+		Help: "Event timestamps defaulted, clamped or rejected by the gateway, per project",
+	}, []string{"project_id", "action"})
+	redactionCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_redactions_total",
+		Help: "Data values redacted at ingest, per project, rule and action",
+	}, []string{"project_id", "rule", "action"})
+	spoolEventsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_spool_events",
+		Help: "Number of events waiting in the local spool for Kafka",
+	})
+	spoolBytesGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_spool_bytes",
+		Help: "Size in bytes of the events waiting in the local spool",
+	})
+	spoolAgeGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_spool_oldest_age_seconds",
+		Help: "Age of the oldest event waiting in the local spool",
+	})
+	spoolReplayedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_spool_replayed_events_total",
+		Help: "Total number of spooled events replayed to Kafka",
+	})
+	spoolDroppedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_spool_dropped_events_total",
+		Help: "Total number of spooled events discarded by the drop_oldest overflow policy",
+	})
+	spoolCorruptCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_spool_corrupt_events_total",
+		Help: "Total number of spooled events skipped because their record was corrupt",
+	})
+	spoolResentCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_spool_resent_events_total",
+		Help: "Events Kafka had already accepted that were produced again behind an earlier failure to keep their order",
+	})
+)
+
+func initMetrics() {
+	prometheus.MustRegister(syslogMessagesCounter, syslogParseErrorCounter, syslogDroppedCounter, compressionRatioHist, authCacheCounter, rateLimitedCounter, schemaViolationCounter, timestampAdjustedCounter, redactionCounter,
+		spoolEventsGauge, spoolBytesGauge, spoolAgeGauge, spoolReplayedCounter, spoolDroppedCounter, spoolCorruptCounter, spoolResentCounter)
+	http.Handle("/metrics", promhttp.Handler())
+}
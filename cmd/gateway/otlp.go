@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// defaultOTLPEventName is used for records that carry neither an event
+// name nor a string body.
+const defaultOTLPEventName = "otlp.log"
+
+// otlpLogsServer serves the OTLP/gRPC LogsService. It is a separate type
+// because gatewayServer already embeds the IngestService base.
+type otlpLogsServer struct {
+	collogspb.UnimplementedLogsServiceServer
+	g *gatewayServer
+}
+
+// Export implements collogspb.LogsServiceServer.
+func (s *otlpLogsServer) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	projectID, apiKey := grpcCredentials(ctx)
+	resp, err := s.g.exportOTLP(ctx, projectID, apiKey, req)
+	if err != nil {
+		return nil, grpcIngestError(err)
+	}
+	return resp, nil
+}
+
+// handleOTLPLogs is the OTLP/HTTP receiver. Bodies are protobuf by default
+// or JSON with Content-Type: application/json, and the response is written
+// in the same encoding as the request, as the OTLP spec requires.
+func (g *gatewayServer) handleOTLPLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := ct == "application/json"
+	var req collogspb.ExportLogsServiceRequest
+	if isJSON {
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, &req)
+	} else {
+		err = proto.Unmarshal(body, &req)
+	}
+	if err != nil {
+		g.logger.Warn("invalid OTLP body", zap.Error(err))
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	projectID, apiKey := httpCredentials(r.Header)
+	resp, err := g.exportOTLP(r.Context(), projectID, apiKey, &req)
+	if err != nil {
+		writeIngestError(w, err)
+		return
+	}
+
+	var out []byte
+	if isJSON {
+		w.Header().Set("Content-Type", "application/json")
+		out, err = protojson.Marshal(resp)
+	} else {
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		out, err = proto.Marshal(resp)
+	}
+	if err != nil {
+		http.Error(w, "server error", http.StatusInternalServerError)
+		return
+	}
+	w.Write(out)
+}
+
+// exportOTLP maps every LogRecord onto a LogPayload and sends them down the
+// regular batch path; rejected records are reported as a partial success.
+func (g *gatewayServer) exportOTLP(ctx context.Context, projectID, apiKey string, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	var payloads []*ingestpb.LogPayload
+	for _, rl := range req.ResourceLogs {
+		resource := map[string]string{}
+		for _, kv := range rl.GetResource().GetAttributes() {
+			flattenOTLPValue(resource, "resource."+kv.Key, kv.Value)
+		}
+		for _, sl := range rl.ScopeLogs {
+			scope := map[string]string{}
+			if sc := sl.GetScope(); sc != nil {
+				if sc.Name != "" {
+					scope["scope.name"] = sc.Name
+				}
+				if sc.Version != "" {
+					scope["scope.version"] = sc.Version
+				}
+				for _, kv := range sc.Attributes {
+					flattenOTLPValue(scope, "scope."+kv.Key, kv.Value)
+				}
+			}
+			for _, lr := range sl.LogRecords {
+				payloads = append(payloads, otlpToPayload(lr, resource, scope))
+			}
+		}
+	}
+	if len(payloads) == 0 {
+		return &collogspb.ExportLogsServiceResponse{}, nil
+	}
+
+	resp, err := g.sendAll(ctx, projectID, apiKey, payloads)
+	var partial *partialSendError
+	if err != nil && !errors.As(err, &partial) {
+		return nil, err
+	}
+	out := &collogspb.ExportLogsServiceResponse{}
+	if resp.Rejected > 0 {
+		var firstErr string
+		for _, r := range resp.Results {
+			if !r.Accepted {
+				firstErr = r.Error
+				break
+			}
+		}
+		out.PartialSuccess = &collogspb.ExportLogsPartialSuccess{
+			RejectedLogRecords: int64(resp.Rejected),
+			ErrorMessage:       firstErr,
+		}
+	}
+	return out, nil
+}
+
+// otlpToPayload converts one LogRecord. The event name is taken from
+// event_name, then the event.name attribute, then a string body; the body,
+// record attributes and the already flattened resource and scope
+// attributes all end up in Data.
+func otlpToPayload(lr *logspb.LogRecord, resource, scope map[string]string) *ingestpb.LogPayload {
+	data := make(map[string]string, len(resource)+len(scope)+len(lr.Attributes)+4)
+	for k, v := range resource {
+		data[k] = v
+	}
+	for k, v := range scope {
+		data[k] = v
+	}
+	for _, kv := range lr.Attributes {
+		flattenOTLPValue(data, kv.Key, kv.Value)
+	}
+
+	name := lr.EventName
+	if name == "" {
+		name = data["event.name"]
+	}
+	if body := lr.GetBody(); body != nil {
+		if kvs, ok := body.Value.(*commonpb.AnyValue_KvlistValue); ok {
+			for _, kv := range kvs.KvlistValue.GetValues() {
+				flattenOTLPValue(data, kv.Key, kv.Value)
+			}
+		} else {
+			flattenOTLPValue(data, "body", body)
+			if s, ok := body.Value.(*commonpb.AnyValue_StringValue); ok && name == "" {
+				name = s.StringValue
+			}
+		}
+	}
+	if name == "" {
+		name = defaultOTLPEventName
+	}
+
+	if lr.SeverityText != "" {
+		data["severity_text"] = lr.SeverityText
+	}
+	if lr.SeverityNumber != logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED {
+		data["severity_number"] = strconv.Itoa(int(lr.SeverityNumber))
+	}
+	if id := otlpID(lr.TraceId, 16); id != "" {
+		data["trace_id"] = id
+	}
+	if id := otlpID(lr.SpanId, 8); id != "" {
+		data["span_id"] = id
+	}
+
+	ts := lr.TimeUnixNano
+	if ts == 0 {
+		ts = lr.ObservedTimeUnixNano
+	}
+	return &ingestpb.LogPayload{
+		Name:      name,
+		Timestamp: int64(ts),
+		Data:      data,
+	}
+}
+
+// flattenOTLPValue stores v under key, expanding maps and arrays into
+// dotted keys since Data only holds strings.
+func flattenOTLPValue(dst map[string]string, key string, v *commonpb.AnyValue) {
+	switch val := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		dst[key] = val.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		dst[key] = strconv.FormatBool(val.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		dst[key] = strconv.FormatInt(val.IntValue, 10)
+	case *commonpb.AnyValue_DoubleValue:
+		dst[key] = strconv.FormatFloat(val.DoubleValue, 'g', -1, 64)
+	case *commonpb.AnyValue_BytesValue:
+		dst[key] = base64.StdEncoding.EncodeToString(val.BytesValue)
+	case *commonpb.AnyValue_KvlistValue:
+		for _, kv := range val.KvlistValue.GetValues() {
+			flattenOTLPValue(dst, key+"."+kv.Key, kv.Value)
+		}
+	case *commonpb.AnyValue_ArrayValue:
+		for i, item := range val.ArrayValue.GetValues() {
+			flattenOTLPValue(dst, fmt.Sprintf("%s.%d", key, i), item)
+		}
+	}
+}
+
+// otlpID renders a trace or span id as lowercase hex. OTLP/JSON sends ids
+// as hex strings, which protojson decodes as base64 into 3/4 of the
+// expected length; those are turned back into the original text.
+func otlpID(b []byte, size int) string {
+	switch len(b) {
+	case 0:
+		return ""
+	case size:
+		return hex.EncodeToString(b)
+	case size * 3 / 2:
+		return strings.ToLower(base64.StdEncoding.EncodeToString(b))
+	default:
+		return hex.EncodeToString(b)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+)
+
+func strValue(s string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+}
+
+func TestOTLPToPayload(t *testing.T) {
+	resource := map[string]string{"resource.service.name": "api"}
+	scope := map[string]string{"scope.name": "http"}
+	lr := &logspb.LogRecord{
+		SeverityNumber:       logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+		SeverityText:         "WARN",
+		TraceId:              []byte{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x03, 0x81, 0x03, 0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0x0c},
+		SpanId:               []byte{0xee, 0xe1, 0x9b, 0x7e, 0xc3, 0xc1, 0xb1, 0x74},
+		ObservedTimeUnixNano: 1700000000000000000,
+		Body:                 strValue("user logged in"),
+		Attributes: []*commonpb.KeyValue{
+			{Key: "http", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{
+				Values: []*commonpb.KeyValue{{Key: "status", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 200}}}},
+			}}}},
+			{Key: "tags", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{
+				Values: []*commonpb.AnyValue{strValue("a"), {Value: &commonpb.AnyValue_BoolValue{BoolValue: true}}},
+			}}}},
+			{Key: "ratio", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: 0.25}}},
+			{Key: "raw", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: []byte("hi")}}},
+		},
+	}
+	p := otlpToPayload(lr, resource, scope)
+	if p.Name != "user logged in" || p.Timestamp != 1700000000000000000 {
+		t.Errorf("name, timestamp = %q, %d", p.Name, p.Timestamp)
+	}
+	want := map[string]string{
+		"resource.service.name": "api",
+		"scope.name":            "http",
+		"body":                  "user logged in",
+		"http.status":           "200",
+		"tags.0":                "a",
+		"tags.1":                "true",
+		"ratio":                 "0.25",
+		"raw":                   "aGk=",
+		"severity_text":         "WARN",
+		"severity_number":       "13",
+		"trace_id":              "5b8efff798038103d269b633813fc60c",
+		"span_id":               "eee19b7ec3c1b174",
+	}
+	if !reflect.DeepEqual(p.Data, want) {
+		t.Errorf("data = %v\nwant %v", p.Data, want)
+	}
+}
+
+func TestOTLPID(t *testing.T) {
+	const hexID = "5b8efff798038103d269b633813fc60c"
+	// OTLP/JSON sends the hex text, which protojson decodes as base64
+	fromJSON, err := base64.StdEncoding.DecodeString(hexID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := hex.DecodeString(hexID)
+	for name, b := range map[string][]byte{"protobuf": raw, "json": fromJSON} {
+		if got := otlpID(b, 16); got != hexID {
+			t.Errorf("%s: otlpID = %q, want %q", name, got, hexID)
+		}
+	}
+	if got := otlpID(nil, 16); got != "" {
+		t.Errorf("empty id = %q", got)
+	}
+}
+
+func TestOTLPEventName(t *testing.T) {
+	kvBody := &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{
+		Values: []*commonpb.KeyValue{{Key: "msg", Value: strValue("hello")}},
+	}}}
+	eventAttr := []*commonpb.KeyValue{{Key: "event.name", Value: strValue("from.attribute")}}
+	tests := []struct {
+		name string
+		lr   *logspb.LogRecord
+		want string
+	}{
+		{"event_name first", &logspb.LogRecord{EventName: "from.field", Attributes: eventAttr, Body: strValue("body")}, "from.field"},
+		{"then the attribute", &logspb.LogRecord{Attributes: eventAttr, Body: strValue("body")}, "from.attribute"},
+		{"then a string body", &logspb.LogRecord{Body: strValue("body")}, "body"},
+		{"a map body is data", &logspb.LogRecord{Body: kvBody}, defaultOTLPEventName},
+		{"nothing", &logspb.LogRecord{}, defaultOTLPEventName},
+	}
+	for _, tt := range tests {
+		if got := otlpToPayload(tt.lr, nil, nil).Name; got != tt.want {
+			t.Errorf("%s: name = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := otlpToPayload(&logspb.LogRecord{Body: kvBody}, nil, nil).Data["msg"]; got != "hello" {
+		t.Errorf("map body: msg = %q", got)
+	}
+}
+
+// TestHandleOTLPLogs checks both encodings and that rejected records are
+// reported as a partial success.
+func TestHandleOTLPLogs(t *testing.T) {
+	req := &collogspb.ExportLogsServiceRequest{ResourceLogs: []*logspb.ResourceLogs{{
+		ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{
+			{Body: strValue("one")}, {Body: strValue("two")}, {Body: strValue("three")},
+		}}},
+	}}}
+	for _, contentType := range []string{"application/x-protobuf", "application/json"} {
+		p := &fakeProducer{}
+		g, _ := newTestGateway(p, &authpb.ApiKeyResponse{Limits: &authpb.ProjectLimits{DailyEvents: 2}})
+		g.maxBatch = 2
+
+		var body []byte
+		var err error
+		if contentType == "application/json" {
+			body, err = protojson.Marshal(req)
+		} else {
+			body, err = proto.Marshal(req)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := httptest.NewRequest(http.MethodPost, "/v1/otlp/logs", bytes.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		r.Header.Set("Authorization", "Bearer "+testKey)
+		rec := httptest.NewRecorder()
+		g.handleOTLPLogs(rec, r)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != contentType {
+			t.Fatalf("%s: status %d, content type %q: %s", contentType, rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+		}
+
+		var resp collogspb.ExportLogsServiceResponse
+		if contentType == "application/json" {
+			err = protojson.Unmarshal(rec.Body.Bytes(), &resp)
+		} else {
+			err = proto.Unmarshal(rec.Body.Bytes(), &resp)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		ps := resp.GetPartialSuccess()
+		if ps.GetRejectedLogRecords() != 1 || !strings.Contains(ps.GetErrorMessage(), "daily_events") {
+			t.Errorf("%s: partial success = %v", contentType, ps)
+		}
+		if n := len(p.sentValues()); n != 2 {
+			t.Errorf("%s: %d events produced, want 2", contentType, n)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sync"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+	"go.uber.org/zap"
+)
+
+// projectPolicy is how the gateway treats a project's events beyond its
+// rate limits; nil fields mean the project sets no policy.
+type projectPolicy struct {
+	schema     *authpb.ProjectSchema
+	timestamps *authpb.TimestampPolicy
+	redaction  *redactor
+
+	// the policy redaction was compiled from, to recompile only on change
+	redactionSrc *authpb.RedactionPolicy
+}
+
+// policyRegistry holds the policies last returned by AuthSvc for each project.
+type policyRegistry struct {
+	mu       sync.RWMutex
+	projects map[string]projectPolicy
+	logger   *zap.Logger
+}
+
+func newPolicyRegistry(logger *zap.Logger) *policyRegistry {
+	return &policyRegistry{projects: map[string]projectPolicy{}, logger: logger}
+}
+
+func (r *policyRegistry) set(projectID string, resp *authpb.ApiKeyResponse) {
+	var p projectPolicy
+	if resp.Schema != nil && resp.Schema.Strictness != authpb.SchemaStrictness_SCHEMA_OFF {
+		p.schema = resp.Schema
+	}
+	if ts := resp.Timestamps; ts != nil && ts.Mode != authpb.TimestampMode_TIMESTAMP_KEEP &&
+		(ts.MaxPastSeconds > 0 || ts.MaxFutureSeconds > 0) {
+		p.timestamps = ts
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if resp.Redaction != nil && len(resp.Redaction.Rules) > 0 {
+		// AuthSvc responses are cached, so this is usually the same policy
+		if old := r.projects[projectID]; old.redactionSrc == resp.Redaction {
+			p.redaction = old.redaction
+		} else {
+			p.redaction = newRedactor(resp.Redaction, r.logger)
+		}
+		p.redactionSrc = resp.Redaction
+	}
+	if p == (projectPolicy{}) {
+		delete(r.projects, projectID)
+		return
+	}
+	r.projects[projectID] = p
+}
+
+func (r *policyRegistry) get(projectID string) projectPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.projects[projectID]
+}
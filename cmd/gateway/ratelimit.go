@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+)
+
+var errRateLimited = errors.New("rate limited")
+
+// rateLimitError rejects a request that exceeds one of the project's
+// limits; RetryAfter is when the same request would next be admitted.
+type rateLimitError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("rate limited (%s), retry after %ds", e.Reason, e.retryAfterSeconds())
+}
+
+func (e *rateLimitError) Is(target error) bool { return target == errRateLimited }
+
+// retryAfterSeconds renders RetryAfter for the Retry-After header.
+func (e *rateLimitError) retryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// tokenBucket refills at rate tokens per second up to one second's worth.
+// A request may take more tokens than the bucket holds as long as the
+// bucket is not in debt, so batches larger than the rate still pass and
+// the long-run rate holds.
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if b.last.IsZero() {
+		b.tokens = b.rate
+	} else {
+		b.tokens = math.Min(b.rate, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+// wait is how long until n tokens may be taken, 0 if they may be now.
+func (b *tokenBucket) wait(n float64) time.Duration {
+	need := math.Min(n, b.rate)
+	if b.tokens >= need {
+		return 0
+	}
+	return time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+}
+
+// projectLimiter holds the buckets and today's usage of one project.
+type projectLimiter struct {
+	limits    *authpb.ProjectLimits
+	events    tokenBucket
+	bytes     tokenBucket
+	day       string
+	dayEvents int64
+	dayBytes  int64
+	lastUsed  time.Time
+}
+
+// rateLimiter enforces the per-project limits AuthSvc returns with every
+// API key validation. State is per gateway instance, so with N replicas
+// behind a balancer each admits up to its share of the configured limits.
+type rateLimiter struct {
+	mu       sync.Mutex
+	projects map[string]*projectLimiter
+	now      func() time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{projects: map[string]*projectLimiter{}, now: time.Now}
+}
+
+// setLimits installs the latest limits of a project, keeping its usage.
+func (l *rateLimiter) setLimits(projectID string, limits *authpb.ProjectLimits) {
+	if limits == nil {
+		limits = &authpb.ProjectLimits{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.projects[projectID]
+	if !ok {
+		p = &projectLimiter{}
+		l.projects[projectID] = p
+	}
+	p.limits = limits
+	p.lastUsed = l.now()
+	p.events.rate = float64(limits.EventsPerSecond)
+	p.bytes.rate = float64(limits.BytesPerSecond)
+}
+
+// allow admits events/bytes for a project or returns a *rateLimitError.
+// Nothing is consumed when the request is rejected. Projects without
+// limits always pass.
+func (l *rateLimiter) allow(projectID string, events, bytes int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.projects[projectID]
+	if !ok {
+		return nil
+	}
+	now := l.now()
+	p.lastUsed = now
+
+	if day := now.UTC().Format("2006-01-02"); day != p.day {
+		p.day, p.dayEvents, p.dayBytes = day, 0, 0
+	}
+	untilMidnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+	if q := p.limits.DailyEvents; q > 0 && p.dayEvents+events > q {
+		return l.reject(projectID, "daily_events", untilMidnight)
+	}
+	if q := p.limits.DailyBytes; q > 0 && p.dayBytes+bytes > q {
+		return l.reject(projectID, "daily_bytes", untilMidnight)
+	}
+
+	if p.events.rate > 0 {
+		p.events.refill(now)
+		if d := p.events.wait(float64(events)); d > 0 {
+			return l.reject(projectID, "events_per_second", d)
+		}
+	}
+	if p.bytes.rate > 0 {
+		p.bytes.refill(now)
+		if d := p.bytes.wait(float64(bytes)); d > 0 {
+			return l.reject(projectID, "bytes_per_second", d)
+		}
+	}
+
+	p.events.tokens -= float64(events)
+	p.bytes.tokens -= float64(bytes)
+	p.dayEvents += events
+	p.dayBytes += bytes
+	return nil
+}
+
+// evictIdle forgets projects not seen for idle, so that the map does not
+// grow with every project that ever sent. A project that used some of
+// today's quota is kept until the day is over; dropping it would reset
+// the quota.
+func (l *rateLimiter) evictIdle(idle time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	today := now.UTC().Format("2006-01-02")
+	for id, p := range l.projects {
+		if now.Sub(p.lastUsed) < idle {
+			continue
+		}
+		if p.day == today && (p.dayEvents > 0 || p.dayBytes > 0) {
+			continue
+		}
+		delete(l.projects, id)
+	}
+}
+
+// evictLoop runs evictIdle every idle/2; it never returns.
+func (l *rateLimiter) evictLoop(idle time.Duration) {
+	ticker := time.NewTicker(idle / 2)
+	defer ticker.Stop()
+	for range ticker.C {
+		l.evictIdle(idle)
+	}
+}
+
+func (l *rateLimiter) reject(projectID, reason string, retryAfter time.Duration) error {
+	rateLimitedCounter.WithLabelValues(projectID, reason).Inc()
+	return &rateLimitError{Reason: reason, RetryAfter: retryAfter}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+)
+
+// newTestLimiter is a rateLimiter on a clock the test moves.
+func newTestLimiter(now *time.Time) *rateLimiter {
+	l := newRateLimiter()
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestRateLimiterEvictIdle(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	l.setLimits("idle", &authpb.ProjectLimits{EventsPerSecond: 10})
+	l.setLimits("quota", &authpb.ProjectLimits{DailyEvents: 10})
+	l.setLimits("active", &authpb.ProjectLimits{EventsPerSecond: 10})
+	if err := l.allow("quota", 4, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(9 * time.Minute)
+	if err := l.allow("active", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	l.evictIdle(10 * time.Minute)
+	for id, want := range map[string]bool{"idle": false, "quota": true, "active": true} {
+		if _, ok := l.projects[id]; ok != want {
+			t.Errorf("%s kept = %v, want %v", id, ok, want)
+		}
+	}
+
+	// the quota used today still counts after the sweep ...
+	if err := l.allow("quota", 7, 0); err == nil {
+		t.Error("quota was reset by evictIdle")
+	}
+	// ... and the project goes once the day is over
+	now = time.Date(2026, 10, 18, 0, 30, 0, 0, time.UTC)
+	l.evictIdle(10 * time.Minute)
+	if len(l.projects) != 0 {
+		t.Errorf("projects left after midnight: %v", l.projects)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	b := tokenBucket{rate: 10}
+	b.refill(start)
+	if b.tokens != 10 {
+		t.Fatalf("a new bucket holds %v tokens, want a full second's 10", b.tokens)
+	}
+	if d := b.wait(10); d != 0 {
+		t.Errorf("wait(10) on a full bucket = %v", d)
+	}
+	// a request larger than the rate needs only a full bucket
+	if d := b.wait(25); d != 0 {
+		t.Errorf("wait(25) on a full bucket = %v", d)
+	}
+	b.tokens -= 25
+	b.refill(start.Add(time.Second))
+	if b.tokens != -5 {
+		t.Fatalf("tokens after 1s = %v, want -5", b.tokens)
+	}
+	if d := b.wait(1); d != 600*time.Millisecond {
+		t.Errorf("wait(1) in debt = %v, want 600ms", d)
+	}
+	b.refill(start.Add(time.Hour))
+	if b.tokens != 10 {
+		t.Errorf("tokens after an hour = %v, want capped at 10", b.tokens)
+	}
+}
+
+func TestRateLimiterRates(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	l.setLimits("p", &authpb.ProjectLimits{EventsPerSecond: 10, BytesPerSecond: 1000})
+
+	if err := l.allow("p", 10, 100); err != nil {
+		t.Fatalf("first second: %v", err)
+	}
+	err := l.allow("p", 1, 1)
+	rl, ok := err.(*rateLimitError)
+	if !ok || rl.Reason != "events_per_second" || rl.RetryAfter != 100*time.Millisecond {
+		t.Fatalf("11th event: %v", err)
+	}
+	if rl.retryAfterSeconds() != 1 {
+		t.Errorf("Retry-After = %d, want rounded up to 1", rl.retryAfterSeconds())
+	}
+	// a rejected request consumed nothing
+	now = now.Add(100 * time.Millisecond)
+	if err := l.allow("p", 1, 1); err != nil {
+		t.Errorf("after RetryAfter: %v", err)
+	}
+
+	now = now.Add(time.Second)
+	if err := l.allow("p", 1, 5000); err != nil {
+		t.Errorf("oversized batch on a full bucket: %v, want accepted", err)
+	}
+	// which leaves the bytes bucket 4s in debt
+	now = now.Add(3 * time.Second)
+	err = l.allow("p", 1, 0)
+	if rl, ok := err.(*rateLimitError); !ok || rl.Reason != "bytes_per_second" || rl.RetryAfter != time.Second {
+		t.Errorf("while in debt: %v", err)
+	}
+}
+
+func TestRateLimiterDailyQuota(t *testing.T) {
+	now := time.Date(2026, 10, 17, 23, 59, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	l.setLimits("p", &authpb.ProjectLimits{DailyEvents: 5, DailyBytes: 100})
+
+	if err := l.allow("p", 5, 50); err != nil {
+		t.Fatalf("exactly the quota: %v", err)
+	}
+	err := l.allow("p", 1, 1)
+	rl, ok := err.(*rateLimitError)
+	if !ok || rl.Reason != "daily_events" || rl.RetryAfter != time.Minute {
+		t.Fatalf("over the event quota: %v, want daily_events until midnight", err)
+	}
+	if err := l.allow("p", 0, 51); err == nil || err.(*rateLimitError).Reason != "daily_bytes" {
+		t.Errorf("over the byte quota: %v", err)
+	}
+
+	// the quota is per UTC day
+	now = time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	if err := l.allow("p", 5, 100); err != nil {
+		t.Errorf("new day: %v", err)
+	}
+
+	// setLimits keeps the usage, unknown and unlimited projects pass
+	l.setLimits("p", &authpb.ProjectLimits{DailyEvents: 6})
+	if err := l.allow("p", 2, 0); err == nil {
+		t.Error("new limits reset today's usage")
+	}
+	l.setLimits("free", nil)
+	for _, id := range []string{"free", "unknown"} {
+		if err := l.allow(id, 1e9, 1e12); err != nil {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+	"go.uber.org/zap"
+)
+
+// valueMatcher finds sensitive substrings of a data value; valid, when set,
+// filters out regexp matches value[lo:hi] that are not the real thing.
+type valueMatcher struct {
+	re    *regexp.Regexp
+	valid func(value string, lo, hi int) bool
+}
+
+// detectors are the built-in matchers a rule can name instead of a pattern.
+var detectors = map[authpb.Detector]valueMatcher{
+	authpb.Detector_DETECTOR_EMAIL: {
+		re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	},
+	authpb.Detector_DETECTOR_IPV4: {
+		re: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`),
+	},
+	authpb.Detector_DETECTOR_IPV6: {
+		// the first group is not empty unless the address starts with ::,
+		// so the separator in "addr:fe80::1" is not part of the match
+		re:    regexp.MustCompile(`(?i)(?:[0-9a-f]{1,4}|:):(?:[0-9a-f]{0,4}:){0,6}(?:\d{1,3}(?:\.\d{1,3}){3}|[0-9a-f]{0,4})`),
+		valid: ipv6Valid,
+	},
+	authpb.Detector_DETECTOR_CREDIT_CARD: {
+		re:    regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		valid: func(value string, lo, hi int) bool { return luhnValid(value[lo:hi]) },
+	},
+	authpb.Detector_DETECTOR_JWT: {
+		re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	},
+	authpb.Detector_DETECTOR_BEARER: {
+		re:    regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`),
+		valid: bearerValid,
+	},
+}
+
+// ipv6Valid accepts addresses that stand on their own, not the :: of
+// std::vector or Foo::Bar.
+func ipv6Valid(value string, lo, hi int) bool {
+	if lo > 0 && isWordByte(value[lo-1]) || hi < len(value) && isWordByte(value[hi]) {
+		return false
+	}
+	return net.ParseIP(value[lo:hi]) != nil
+}
+
+// bearerValid accepts tokens of at least 8 characters that are not all
+// letters, so prose like "the bearer of the news" is left alone.
+func bearerValid(value string, lo, hi int) bool {
+	fields := strings.Fields(value[lo:hi])
+	token := strings.TrimRight(fields[len(fields)-1], "=")
+	if len(token) < 8 {
+		return false
+	}
+	for i := 0; i < len(token); i++ {
+		if !('a' <= token[i] && token[i] <= 'z' || 'A' <= token[i] && token[i] <= 'Z') {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// luhnValid reports whether the digits of a card-number candidate pass the
+// Luhn checksum.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+type redactionRule struct {
+	name     string
+	action   authpb.RedactionAction
+	keys     map[string]bool // lower-cased key names
+	matchers []valueMatcher
+}
+
+// redactor applies a project's redaction rules to event data.
+type redactor struct {
+	salt  []byte
+	rules []redactionRule
+}
+
+// newRedactor compiles policy; it returns nil when there is nothing to
+// redact. Patterns were validated by AuthSvc, so a rule that still fails to
+// compile is skipped with a log entry.
+func newRedactor(policy *authpb.RedactionPolicy, logger *zap.Logger) *redactor {
+	if policy == nil || len(policy.Rules) == 0 {
+		return nil
+	}
+	r := &redactor{salt: []byte(policy.Salt)}
+	for i, pr := range policy.Rules {
+		rule := redactionRule{name: pr.Name, action: pr.Action, keys: map[string]bool{}}
+		if rule.name == "" {
+			if pr.Detector != authpb.Detector_DETECTOR_NONE {
+				rule.name = strings.ToLower(strings.TrimPrefix(pr.Detector.String(), "DETECTOR_"))
+			} else {
+				rule.name = "rule" + strconv.Itoa(i)
+			}
+		}
+		for _, k := range pr.Keys {
+			rule.keys[strings.ToLower(k)] = true
+		}
+		if m, ok := detectors[pr.Detector]; ok {
+			rule.matchers = append(rule.matchers, m)
+		}
+		if pr.Pattern != "" {
+			re, err := regexp.Compile(pr.Pattern)
+			if err != nil {
+				logger.Error("invalid redaction pattern", zap.String("rule", rule.name), zap.Error(err))
+				continue
+			}
+			rule.matchers = append(rule.matchers, valueMatcher{re: re})
+		}
+		r.rules = append(r.rules, rule)
+	}
+	return r
+}
+
+// apply redacts data in place, counting hits per rule.
+func (r *redactor) apply(projectID string, data map[string]string) {
+	for key, value := range data {
+		dropped := false
+		for _, rule := range r.rules {
+			hits := 0
+			if rule.keys[strings.ToLower(key)] {
+				hits = 1
+				value = r.replace(rule, value)
+			} else {
+				for _, m := range rule.matchers {
+					var n int
+					value, n = r.redactMatches(rule, m, value)
+					hits += n
+				}
+			}
+			if hits == 0 {
+				continue
+			}
+			redactionCounter.WithLabelValues(projectID, rule.name, strings.ToLower(strings.TrimPrefix(rule.action.String(), "REDACT_"))).Add(float64(hits))
+			if rule.action == authpb.RedactionAction_REDACT_DROP_KEY {
+				dropped = true
+				break
+			}
+		}
+		if dropped {
+			delete(data, key)
+		} else {
+			data[key] = value
+		}
+	}
+}
+
+// redactMatches replaces the valid matches of m in value, returning the new
+// value and the number of replacements.
+func (r *redactor) redactMatches(rule redactionRule, m valueMatcher, value string) (string, int) {
+	var (
+		b    strings.Builder
+		last int
+		hits int
+	)
+	for _, loc := range m.re.FindAllStringIndex(value, -1) {
+		if m.valid != nil && !m.valid(value, loc[0], loc[1]) {
+			continue
+		}
+		b.WriteString(value[last:loc[0]])
+		b.WriteString(r.replace(rule, value[loc[0]:loc[1]]))
+		last = loc[1]
+		hits++
+	}
+	if hits == 0 {
+		return value, 0
+	}
+	b.WriteString(value[last:])
+	return b.String(), hits
+}
+
+// replace is what a match of rule turns into; the result of a dropped key
+// is discarded.
+func (r *redactor) replace(rule redactionRule, s string) string {
+	switch rule.action {
+	case authpb.RedactionAction_REDACT_HASH:
+		mac := hmac.New(sha256.New, r.salt)
+		mac.Write([]byte(s))
+		return "hash:" + hex.EncodeToString(mac.Sum(nil)[:12])
+	case authpb.RedactionAction_REDACT_DROP_KEY:
+		return ""
+	default:
+		return "[REDACTED:" + rule.name + "]"
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// schemaErrorsKey tags a quarantined event with its schema violations.
+const schemaErrorsKey = "_schema_errors"
+
+// fieldError is one schema violation; Field is "name" or "data.<key>".
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// schemaError rejects an event that violates its project's schema.
+type schemaError struct {
+	Fields []fieldError
+}
+
+func (e *schemaError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "invalid payload: schema violation: " + strings.Join(parts, "; ")
+}
+
+func (e *schemaError) Is(target error) bool { return target == errInvalidPayload }
+
+// validate checks p against what the processor needs, applies the
+// project's timestamp policy, checks p against the project's schema and
+// redacts its data. It returns the topic p should be produced to: under a
+// quarantine schema an invalid event is tagged with its violations and
+// routed to the quarantine topic instead of being rejected.
+func (g *gatewayServer) validate(projectID string, p *ingestpb.LogPayload) (string, error) {
+	if err := validatePayload(p); err != nil {
+		return "", err
+	}
+	if err := g.stampTimestamps(projectID, p); err != nil {
+		return "", err
+	}
+	policy := g.policies.get(projectID)
+	topic := g.kafkaTopic
+	if policy.schema != nil {
+		if violations := checkSchema(policy.schema, p); len(violations) > 0 {
+			serr := &schemaError{Fields: violations}
+			if policy.schema.Strictness != authpb.SchemaStrictness_SCHEMA_QUARANTINE || g.quarantineTopic == "" {
+				schemaViolationCounter.WithLabelValues(projectID, "rejected").Inc()
+				return "", serr
+			}
+			schemaViolationCounter.WithLabelValues(projectID, "quarantined").Inc()
+			if p.Data == nil {
+				p.Data = map[string]string{}
+			}
+			p.Data[schemaErrorsKey] = strings.TrimPrefix(serr.Error(), "invalid payload: schema violation: ")
+			topic = g.quarantineTopic
+		}
+	}
+	// redaction runs last so that it also covers values quoted in the
+	// schema errors tag
+	if policy.redaction != nil {
+		policy.redaction.apply(projectID, p.Data)
+	}
+	return topic, nil
+}
+
+// checkSchema lists every violation of schema by p, ordered by field.
+func checkSchema(schema *authpb.ProjectSchema, p *ingestpb.LogPayload) []fieldError {
+	var errs []fieldError
+	if len(schema.EventNames) > 0 && !slices.Contains(schema.EventNames, p.Name) {
+		errs = append(errs, fieldError{"name", fmt.Sprintf("event name %q is not allowed", p.Name)})
+	}
+	if schema.MaxKeys > 0 && len(p.Data) > int(schema.MaxKeys) {
+		errs = append(errs, fieldError{"data", fmt.Sprintf("%d keys, at most %d allowed", len(p.Data), schema.MaxKeys)})
+	}
+	for _, key := range schema.RequiredKeys {
+		if _, ok := p.Data[key]; !ok {
+			errs = append(errs, fieldError{"data." + key, "required key is missing"})
+		}
+	}
+	for key, value := range p.Data {
+		field := "data." + key
+		if schema.MaxKeyBytes > 0 && len(key) > int(schema.MaxKeyBytes) {
+			errs = append(errs, fieldError{field, fmt.Sprintf("key is longer than %d bytes", schema.MaxKeyBytes)})
+		}
+		if schema.MaxValueBytes > 0 && len(value) > int(schema.MaxValueBytes) {
+			errs = append(errs, fieldError{field, fmt.Sprintf("value is longer than %d bytes", schema.MaxValueBytes)})
+		}
+		if t, ok := schema.KeyTypes[key]; ok {
+			if msg := checkValueType(t, value); msg != "" {
+				errs = append(errs, fieldError{field, msg})
+			}
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+// checkValueType describes why value does not have type t, or returns "".
+func checkValueType(t *authpb.KeyType, value string) string {
+	var err error
+	switch t.Type {
+	case authpb.ValueType_VALUE_INT:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case authpb.ValueType_VALUE_FLOAT:
+		_, err = strconv.ParseFloat(value, 64)
+	case authpb.ValueType_VALUE_BOOL:
+		_, err = strconv.ParseBool(value)
+	case authpb.ValueType_VALUE_TIMESTAMP:
+		if _, perr := strconv.ParseInt(value, 10, 64); perr != nil {
+			_, err = time.Parse(time.RFC3339Nano, value)
+		}
+	case authpb.ValueType_VALUE_ENUM:
+		if !slices.Contains(t.EnumValues, value) {
+			return fmt.Sprintf("%q is not one of %s", value, strings.Join(t.EnumValues, ", "))
+		}
+	}
+	if err != nil {
+		return fmt.Sprintf("%q is not a valid %s", value, strings.ToLower(strings.TrimPrefix(t.Type.String(), "VALUE_")))
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+var testSchema = &authpb.ProjectSchema{
+	RequiredKeys: []string{"user"},
+	EventNames:   []string{"login", "logout"},
+	KeyTypes: map[string]*authpb.KeyType{
+		"attempts": {Type: authpb.ValueType_VALUE_INT},
+		"ratio":    {Type: authpb.ValueType_VALUE_FLOAT},
+		"ok":       {Type: authpb.ValueType_VALUE_BOOL},
+		"at":       {Type: authpb.ValueType_VALUE_TIMESTAMP},
+		"method":   {Type: authpb.ValueType_VALUE_ENUM, EnumValues: []string{"password", "sso"}},
+	},
+	MaxKeys:       4,
+	MaxKeyBytes:   8,
+	MaxValueBytes: 24,
+}
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *ingestpb.LogPayload
+		want []fieldError
+	}{
+		{"valid", &ingestpb.LogPayload{Name: "login", Data: map[string]string{
+			"user": "bob", "attempts": "3", "at": "2026-10-17T12:00:00Z", "method": "sso",
+		}}, nil},
+		{"unix timestamp", &ingestpb.LogPayload{Name: "login", Data: map[string]string{
+			"user": "bob", "at": "1760702400", "ok": "true", "ratio": "0.5",
+		}}, nil},
+		{"name and missing key", &ingestpb.LogPayload{Name: "signup"}, []fieldError{
+			{"data.user", "required key is missing"},
+			{"name", `event name "signup" is not allowed`},
+		}},
+		{"types", &ingestpb.LogPayload{Name: "login", Data: map[string]string{
+			"user": "bob", "attempts": "3.5", "at": "yesterday", "method": "ldap",
+		}}, []fieldError{
+			{"data.at", `"yesterday" is not a valid timestamp`},
+			{"data.attempts", `"3.5" is not a valid int`},
+			{"data.method", `"ldap" is not one of password, sso`},
+		}},
+		{"sizes", &ingestpb.LogPayload{Name: "logout", Data: map[string]string{
+			"user": strings.Repeat("x", 25), "longerkey": "", "a": "", "b": "", "c": "",
+		}}, []fieldError{
+			{"data", "5 keys, at most 4 allowed"},
+			{"data.longerkey", "key is longer than 8 bytes"},
+			{"data.user", "value is longer than 24 bytes"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := checkSchema(testSchema, tt.p); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: checkSchema = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestValidateSchemaStrictness checks what happens to an invalid event
+// under each strictness: rejected, or tagged and routed to quarantine.
+func TestValidateSchemaStrictness(t *testing.T) {
+	invalid := func() *ingestpb.LogPayload {
+		return &ingestpb.LogPayload{Name: "signup", Timestamp: 1, Data: map[string]string{"user": "bob"}}
+	}
+	tests := []struct {
+		strictness      authpb.SchemaStrictness
+		quarantineTopic string
+		topic           string
+		rejected        bool
+	}{
+		{authpb.SchemaStrictness_SCHEMA_OFF, "logs_quarantine", "logs_raw", false},
+		{authpb.SchemaStrictness_SCHEMA_REJECT, "logs_quarantine", "", true},
+		{authpb.SchemaStrictness_SCHEMA_QUARANTINE, "logs_quarantine", "logs_quarantine", false},
+		// without a quarantine topic there is nowhere to route it
+		{authpb.SchemaStrictness_SCHEMA_QUARANTINE, "", "", true},
+	}
+	for _, tt := range tests {
+		g, _ := newTestGateway(&fakeProducer{}, nil)
+		g.quarantineTopic = tt.quarantineTopic
+		g.policies.set(testProject, &authpb.ApiKeyResponse{Schema: &authpb.ProjectSchema{
+			Strictness: tt.strictness,
+			EventNames: []string{"login"},
+		}})
+		p := invalid()
+		topic, err := g.validate(testProject, p)
+
+		var serr *schemaError
+		if tt.rejected {
+			if !errors.As(err, &serr) || !errors.Is(err, errInvalidPayload) {
+				t.Errorf("%s: err = %v, want a schema error", tt.strictness, err)
+			}
+			continue
+		}
+		if err != nil || topic != tt.topic {
+			t.Errorf("%s: validate = %q, %v, want %q", tt.strictness, topic, err, tt.topic)
+			continue
+		}
+		tag, tagged := p.Data[schemaErrorsKey]
+		if quarantined := topic == "logs_quarantine"; tagged != quarantined {
+			t.Errorf("%s: tagged = %v", tt.strictness, tagged)
+		} else if tagged && tag != `name: event name "signup" is not allowed` {
+			t.Errorf("%s: %s = %q", tt.strictness, schemaErrorsKey, tag)
+		}
+	}
+}
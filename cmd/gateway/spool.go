@@ -0,0 +1,541 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+var (
+	errSpoolFull    = errors.New("spool full")
+	errSpoolCorrupt = errors.New("spool: corrupt record")
+)
+
+const (
+	// spoolOverflowReject refuses new events while the spool is full.
+	spoolOverflowReject = "reject"
+	// spoolOverflowDropOldest deletes the oldest segments to make room.
+	spoolOverflowDropOldest = "drop_oldest"
+
+	spoolReplayBatch = 500
+	// record header: length and CRC-32 of the body
+	spoolRecordHeader = 8
+)
+
+// spoolSegment is one append-only file of records. events and bytes count
+// what has not been replayed yet.
+type spoolSegment struct {
+	id     uint64
+	path   string
+	size   int64
+	events int64
+}
+
+// spool is the gateway's on-disk write-ahead log for Kafka outages. Events
+// that cannot be produced are appended to it, and while it holds anything
+// all new events are appended too, so that replay to Kafka preserves
+// arrival order. Records are framed as
+//
+//	uint32 length | uint32 crc32 | int64 enqueued unix nanos |
+//	uint8 topic length | topic | uint16 key length | key | value
+//
+// and the replay position is persisted in a cursor file, so a restart
+// resumes where replay left off (re-sending at most one batch).
+type spool struct {
+	dir          string
+	maxBytes     int64
+	segmentBytes int64
+	overflow     string
+	logger       *zap.Logger
+
+	mu       sync.Mutex
+	segments []*spoolSegment // oldest first; the last one takes appends
+	active   *os.File
+	readOff  int64 // replay position in segments[0]
+	events   int64
+	bytes    int64
+	oldest   time.Time // enqueue time of the next record to replay
+	notify   chan struct{}
+}
+
+// openSpool recovers the spool in dir and starts a fresh active segment.
+func openSpool(dir string, maxBytes, segmentBytes int64, overflow string, logger *zap.Logger) (*spool, error) {
+	if overflow != spoolOverflowReject && overflow != spoolOverflowDropOldest {
+		return nil, fmt.Errorf("spool: unknown overflow policy %q", overflow)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &spool{
+		dir:          dir,
+		maxBytes:     maxBytes,
+		segmentBytes: segmentBytes,
+		overflow:     overflow,
+		logger:       logger,
+		notify:       make(chan struct{}, 1),
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	curSeg, curOff := s.readCursor()
+	var lastID uint64
+	for _, path := range paths {
+		id, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), ".seg"), 10, 64)
+		if err != nil {
+			continue
+		}
+		lastID = id
+		if id < curSeg {
+			// fully replayed before the last shutdown
+			os.Remove(path)
+			continue
+		}
+		seg := &spoolSegment{id: id, path: path}
+		start := int64(0)
+		if id == curSeg && len(s.segments) == 0 {
+			start = curOff
+		}
+		if err := s.scan(seg, start); err != nil {
+			return nil, err
+		}
+		if len(s.segments) == 0 {
+			s.readOff = start
+		}
+		s.segments = append(s.segments, seg)
+		s.events += seg.events
+		s.bytes += seg.size - start
+	}
+	if err := s.rotate(lastID + 1); err != nil {
+		return nil, err
+	}
+	s.oldest = s.peekTime()
+	s.updateMetrics()
+	return s, nil
+}
+
+// scan counts the intact records of seg from start and cuts off a torn
+// tail left by a crash mid-append.
+func (s *spool) scan(seg *spoolSegment, start int64) error {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	off := start
+	for {
+		_, n, err := readSpoolRecord(f, off, info.Size())
+		if err != nil {
+			break
+		}
+		off += n
+		seg.events++
+	}
+	seg.size = off
+	return os.Truncate(seg.path, off)
+}
+
+// rotate closes the active segment and starts segment id.
+func (s *spool) rotate(id uint64) error {
+	if s.active != nil {
+		s.active.Close()
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("%020d.seg", id))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	s.active = f
+	s.segments = append(s.segments, &spoolSegment{id: id, path: path})
+	return nil
+}
+
+// pending reports whether the spool holds events not yet replayed.
+func (s *spool) pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events > 0
+}
+
+// append durably stores msgs, all or nothing.
+func (s *spool) append(msgs []*sarama.ProducerMessage) error {
+	now := time.Now()
+	var buf []byte
+	for _, msg := range msgs {
+		var key, value []byte
+		if msg.Key != nil {
+			key, _ = msg.Key.Encode()
+		}
+		if msg.Value != nil {
+			value, _ = msg.Value.Encode()
+		}
+		buf = appendSpoolRecord(buf, now, msg.Topic, key, value)
+	}
+	size := int64(len(buf))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.bytes+size > s.maxBytes {
+		if s.overflow != spoolOverflowDropOldest || s.bytes == 0 {
+			return errSpoolFull
+		}
+		if err := s.dropOldest(); err != nil {
+			return err
+		}
+	}
+
+	active := s.segments[len(s.segments)-1]
+	if _, err := s.active.Write(buf); err != nil {
+		return err
+	}
+	if err := s.active.Sync(); err != nil {
+		return err
+	}
+	active.size += size
+	active.events += int64(len(msgs))
+	if s.events == 0 {
+		s.oldest = now
+	}
+	s.events += int64(len(msgs))
+	s.bytes += size
+	if active.size >= s.segmentBytes {
+		if err := s.rotate(active.id + 1); err != nil {
+			return err
+		}
+	}
+	s.updateMetrics()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// dropOldest discards the oldest segment's unreplayed events.
+func (s *spool) dropOldest() error {
+	if len(s.segments) == 1 {
+		if err := s.rotate(s.segments[0].id + 1); err != nil {
+			return err
+		}
+	}
+	seg := s.segments[0]
+	s.segments = s.segments[1:]
+	s.events -= seg.events
+	s.bytes -= seg.size - s.readOff
+	spoolDroppedCounter.Add(float64(seg.events))
+	s.logger.Warn("spool full, dropped oldest segment", zap.Int64("events", seg.events))
+	s.readOff = 0
+	s.oldest = s.peekTime()
+	if err := os.Remove(seg.path); err != nil {
+		return err
+	}
+	return s.writeCursor()
+}
+
+// read returns up to max records from the replay position without
+// consuming them, with the offset each one ends at; commit consumes them
+// once they are in Kafka. Corrupt records are skipped.
+func (s *spool) read(max int) (segID uint64, msgs []*sarama.ProducerMessage, ends []int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		seg := s.segments[0]
+		if s.readOff >= seg.size {
+			if len(s.segments) == 1 {
+				// caught up with the active segment
+				return seg.id, nil, nil, nil
+			}
+			// a finished segment is fully replayed
+			s.segments = s.segments[1:]
+			s.events -= seg.events
+			s.readOff = 0
+			os.Remove(seg.path)
+			if err := s.writeCursor(); err != nil {
+				return 0, nil, nil, err
+			}
+			continue
+		}
+
+		msgs, ends, err := s.readSegment(seg, max)
+		if errors.Is(err, errSpoolCorrupt) && len(msgs) == 0 {
+			continue
+		}
+		if err != nil && len(msgs) == 0 {
+			return 0, nil, nil, err
+		}
+		// records before a corrupt one are replayed first
+		return seg.id, msgs, ends, nil
+	}
+}
+
+// readSegment reads up to max records of seg from the replay position. A
+// corrupt record at the replay position is skipped and reported with
+// errSpoolCorrupt; one further on ends the batch before it. Callers hold
+// s.mu.
+func (s *spool) readSegment(seg *spoolSegment, max int) ([]*sarama.ProducerMessage, []int64, error) {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	var (
+		msgs []*sarama.ProducerMessage
+		ends []int64
+	)
+	end := s.readOff
+	for len(msgs) < max && end < seg.size {
+		rec, n, err := readSpoolRecord(f, end, seg.size)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// the file is shorter than the records counted in it
+			err = fmt.Errorf("%w at offset %d: %v", errSpoolCorrupt, end, err)
+		}
+		if err != nil {
+			if len(msgs) == 0 && errors.Is(err, errSpoolCorrupt) {
+				if serr := s.skipCorrupt(seg, f, err); serr != nil {
+					return nil, nil, serr
+				}
+			}
+			return msgs, ends, err
+		}
+		end += n
+		msgs = append(msgs, &sarama.ProducerMessage{
+			Topic: rec.topic,
+			Key:   sarama.ByteEncoder(rec.key),
+			Value: sarama.ByteEncoder(rec.value),
+		})
+		ends = append(ends, end)
+	}
+	return msgs, ends, nil
+}
+
+// skipCorrupt moves the replay position past the corrupt record at it, so
+// that one bad record does not stall replay for good. When the record's
+// length cannot be trusted either, the rest of seg is skipped. Callers hold
+// s.mu.
+func (s *spool) skipCorrupt(seg *spoolSegment, f io.ReaderAt, cause error) error {
+	end, skipped := seg.size, seg.events
+	var hdr [spoolRecordHeader]byte
+	if _, err := f.ReadAt(hdr[:], s.readOff); err == nil {
+		if next := s.readOff + spoolRecordHeader + int64(binary.BigEndian.Uint32(hdr[:4])); next <= seg.size {
+			end, skipped = next, min(1, seg.events)
+		}
+	}
+	s.logger.Error("spool: skipping corrupt data", zap.Error(cause),
+		zap.String("segment", seg.path), zap.Int64("offset", s.readOff), zap.Int64("bytes", end-s.readOff))
+	spoolCorruptCounter.Add(float64(skipped))
+	s.bytes -= end - s.readOff
+	s.readOff = end
+	seg.events -= skipped
+	s.events -= skipped
+	s.oldest = s.peekTime()
+	s.updateMetrics()
+	return s.writeCursor()
+}
+
+// commit consumes n records of segment segID up to end. It is a no-op when
+// the segment was dropped by the overflow policy in the meantime.
+func (s *spool) commit(segID uint64, n int, end int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seg := s.segments[0]
+	if seg.id != segID {
+		return nil
+	}
+	s.bytes -= end - s.readOff
+	s.readOff = end
+	seg.events -= int64(n)
+	s.events -= int64(n)
+	s.oldest = s.peekTime()
+	s.updateMetrics()
+	return s.writeCursor()
+}
+
+// replay produces spooled events to Kafka in order, backing off while the
+// brokers are still unavailable.
+func (s *spool) replay(producer sarama.SyncProducer) {
+	backoff := time.Second
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		segID, msgs, ends, err := s.read(spoolReplayBatch)
+		if err != nil {
+			s.logger.Error("spool read failed", zap.Error(err))
+		}
+		if len(msgs) == 0 {
+			select {
+			case <-s.notify:
+			case <-ticker.C:
+			}
+			s.refreshMetrics()
+			continue
+		}
+		if err := producer.SendMessages(msgs); err != nil {
+			// what precedes the first failure is in Kafka; the rest is
+			// resent in order on the next attempt
+			if first, resent := resendTail(msgs, sendFailures(err)); first > 0 {
+				spoolReplayedCounter.Add(float64(first))
+				spoolResentCounter.Add(float64(resent))
+				if err := s.commit(segID, first, ends[first-1]); err != nil {
+					s.logger.Error("spool commit failed", zap.Error(err))
+				}
+			}
+			s.logger.Warn("spool replay failed, backing off", zap.Error(err), zap.Duration("backoff", backoff))
+			time.Sleep(backoff)
+			backoff = min(2*backoff, 30*time.Second)
+			s.refreshMetrics()
+			continue
+		}
+		backoff = time.Second
+		spoolReplayedCounter.Add(float64(len(msgs)))
+		if err := s.commit(segID, len(msgs), ends[len(ends)-1]); err != nil {
+			s.logger.Error("spool commit failed", zap.Error(err))
+		}
+	}
+}
+
+// peekTime is the enqueue time of the next record to replay; zero when
+// the spool is empty. Callers hold s.mu.
+func (s *spool) peekTime() time.Time {
+	for _, seg := range s.segments {
+		if seg.events == 0 {
+			continue
+		}
+		off := int64(0)
+		if seg == s.segments[0] {
+			off = s.readOff
+		}
+		f, err := os.Open(seg.path)
+		if err != nil {
+			return time.Time{}
+		}
+		rec, _, err := readSpoolRecord(f, off, seg.size)
+		f.Close()
+		if err != nil {
+			return time.Time{}
+		}
+		return rec.enqueued
+	}
+	return time.Time{}
+}
+
+func (s *spool) refreshMetrics() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateMetrics()
+}
+
+// updateMetrics publishes the spool depth and age. Callers hold s.mu.
+func (s *spool) updateMetrics() {
+	if s.events == 0 {
+		spoolEventsGauge.Set(0)
+		spoolBytesGauge.Set(0)
+		spoolAgeGauge.Set(0)
+		return
+	}
+	spoolEventsGauge.Set(float64(s.events))
+	spoolBytesGauge.Set(float64(s.bytes))
+	if !s.oldest.IsZero() {
+		spoolAgeGauge.Set(time.Since(s.oldest).Seconds())
+	}
+}
+
+func (s *spool) cursorPath() string { return filepath.Join(s.dir, "cursor") }
+
+func (s *spool) readCursor() (uint64, int64) {
+	b, err := os.ReadFile(s.cursorPath())
+	if err != nil {
+		return 0, 0
+	}
+	var seg uint64
+	var off int64
+	if _, err := fmt.Sscanf(string(b), "%d %d", &seg, &off); err != nil {
+		return 0, 0
+	}
+	return seg, off
+}
+
+// writeCursor persists the replay position atomically. Callers hold s.mu.
+func (s *spool) writeCursor() error {
+	tmp := s.cursorPath() + ".tmp"
+	data := fmt.Sprintf("%d %d\n", s.segments[0].id, s.readOff)
+	if err := os.WriteFile(tmp, []byte(data), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.cursorPath())
+}
+
+type spoolRecord struct {
+	enqueued time.Time
+	topic    string
+	key      []byte
+	value    []byte
+}
+
+func appendSpoolRecord(buf []byte, enqueued time.Time, topic string, key, value []byte) []byte {
+	body := make([]byte, 0, 11+len(topic)+len(key)+len(value))
+	body = binary.BigEndian.AppendUint64(body, uint64(enqueued.UnixNano()))
+	body = append(body, byte(len(topic)))
+	body = append(body, topic...)
+	body = binary.BigEndian.AppendUint16(body, uint16(len(key)))
+	body = append(body, key...)
+	body = append(body, value...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(body)))
+	buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(body))
+	return append(buf, body...)
+}
+
+// readSpoolRecord reads the record at off, which must end by limit, and
+// returns its total size.
+func readSpoolRecord(r io.ReaderAt, off, limit int64) (spoolRecord, int64, error) {
+	var hdr [spoolRecordHeader]byte
+	if _, err := r.ReadAt(hdr[:], off); err != nil {
+		return spoolRecord{}, 0, err
+	}
+	size := binary.BigEndian.Uint32(hdr[:4])
+	corrupt := fmt.Errorf("%w at offset %d", errSpoolCorrupt, off)
+	if off+spoolRecordHeader+int64(size) > limit {
+		// a torn tail or a damaged length
+		return spoolRecord{}, 0, corrupt
+	}
+	body := make([]byte, size)
+	if _, err := r.ReadAt(body, off+spoolRecordHeader); err != nil {
+		return spoolRecord{}, 0, err
+	}
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(hdr[4:]) || size < 11 {
+		return spoolRecord{}, 0, corrupt
+	}
+	rec := spoolRecord{enqueued: time.Unix(0, int64(binary.BigEndian.Uint64(body[:8])))}
+	rest := body[8:]
+	topicLen := int(rest[0])
+	if 1+topicLen+2 > len(rest) {
+		return spoolRecord{}, 0, corrupt
+	}
+	rec.topic = string(rest[1 : 1+topicLen])
+	rest = rest[1+topicLen:]
+	keyLen := int(binary.BigEndian.Uint16(rest[:2]))
+	if 2+keyLen > len(rest) {
+		return spoolRecord{}, 0, corrupt
+	}
+	rec.key = rest[2 : 2+keyLen]
+	rec.value = rest[2+keyLen:]
+	return rec, spoolRecordHeader + int64(size), nil
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+func counterValue(c prometheus.Counter) float64 {
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		panic(err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func openTestSpool(t *testing.T, dir string) *spool {
+	t.Helper()
+	s, err := openSpool(dir, 1<<20, 1024, spoolOverflowReject, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// testMessages returns n messages whose values are prefix-0, prefix-1, ...
+func testMessages(prefix string, n int) []*sarama.ProducerMessage {
+	msgs := make([]*sarama.ProducerMessage, n)
+	for i := range msgs {
+		msgs[i] = &sarama.ProducerMessage{
+			Topic:    "logs_raw",
+			Key:      sarama.StringEncoder("project"),
+			Value:    sarama.StringEncoder(fmt.Sprintf("%s-%d", prefix, i)),
+			Metadata: i,
+		}
+	}
+	return msgs
+}
+
+func messageValues(t *testing.T, msgs []*sarama.ProducerMessage) []string {
+	t.Helper()
+	vals := make([]string, len(msgs))
+	for i, msg := range msgs {
+		b, err := msg.Value.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		vals[i] = string(b)
+	}
+	return vals
+}
+
+// drain reads and commits everything the spool holds, in replay order.
+func drain(t *testing.T, s *spool) []string {
+	t.Helper()
+	var got []string
+	for {
+		segID, msgs, ends, err := s.read(7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(msgs) == 0 {
+			return got
+		}
+		got = append(got, messageValues(t, msgs)...)
+		if err := s.commit(segID, len(msgs), ends[len(ends)-1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func seq(prefix string, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprintf("%s-%d", prefix, i)
+	}
+	return out
+}
+
+func TestSpoolAppendAndRead(t *testing.T) {
+	s := openTestSpool(t, t.TempDir())
+	if s.pending() {
+		t.Fatal("new spool is pending")
+	}
+	// enough records to span several segments
+	for _, prefix := range []string{"a", "b", "c"} {
+		if err := s.append(testMessages(prefix, 40)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !s.pending() {
+		t.Fatal("spool with events is not pending")
+	}
+	if len(s.segments) < 3 {
+		t.Fatalf("expected rotation into several segments, got %d", len(s.segments))
+	}
+	want := append(append(seq("a", 40), seq("b", 40)...), seq("c", 40)...)
+	if got := drain(t, s); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replay order:\n got %v\nwant %v", got, want)
+	}
+	if s.pending() || s.events != 0 || s.bytes != 0 {
+		t.Fatalf("drained spool still holds %d events, %d bytes", s.events, s.bytes)
+	}
+}
+
+func TestSpoolFull(t *testing.T) {
+	s, err := openSpool(t.TempDir(), 200, 4096, spoolOverflowReject, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.append(testMessages("a", 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.append(testMessages("b", 10)); !errors.Is(err, errSpoolFull) {
+		t.Fatalf("err = %v, want errSpoolFull", err)
+	}
+	// a rejected append stores nothing
+	if got := drain(t, s); !reflect.DeepEqual(got, seq("a", 3)) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestSpoolRecovery(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestSpool(t, dir)
+	if err := s.append(testMessages("a", 50)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.append(testMessages("b", 50)); err != nil {
+		t.Fatal(err)
+	}
+	// replay part of it, then crash halfway through an append
+	segID, msgs, ends, err := s.read(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.commit(segID, len(msgs), ends[len(ends)-1]); err != nil {
+		t.Fatal(err)
+	}
+	last := s.segments[len(s.segments)-1]
+	torn := appendSpoolRecord(nil, time.Now(), "logs_raw", nil, []byte("torn"))
+	f, err := os.OpenFile(last.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(torn[:len(torn)-2])
+	f.Close()
+	s.active.Close()
+
+	r := openTestSpool(t, dir)
+	if r.events != 70 {
+		t.Fatalf("recovered %d events, want 70", r.events)
+	}
+	if r.oldest.IsZero() {
+		t.Fatal("recovered spool has no oldest time")
+	}
+	want := append(seq("a", 50)[30:], seq("b", 50)...)
+	if got := drain(t, r); !reflect.DeepEqual(got, want) {
+		t.Fatalf("recovered order:\n got %v\nwant %v", got, want)
+	}
+	// replayed segments are gone once the spool is reopened
+	r.active.Close()
+	r2 := openTestSpool(t, dir)
+	if r2.pending() {
+		t.Fatalf("reopened drained spool holds %d events", r2.events)
+	}
+	segs, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(segs) > 2 {
+		t.Fatalf("%d segments left after replay", len(segs))
+	}
+}
+
+// fakeProducer records what it is sent. Its first failures calls fail as a
+// whole, and the positions in failAt fail in the next call.
+type fakeProducer struct {
+	sarama.SyncProducer
+
+	mu       sync.Mutex
+	failures int
+	sent     []string
+	failAt   map[int]bool
+}
+
+func (p *fakeProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("kafka: brokers unavailable")
+	}
+	var perrs sarama.ProducerErrors
+	for i, msg := range msgs {
+		if p.failAt[i] {
+			perrs = append(perrs, &sarama.ProducerError{Msg: msg, Err: errors.New("leader not available")})
+			continue
+		}
+		b, _ := msg.Value.Encode()
+		p.sent = append(p.sent, string(b))
+	}
+	p.failAt = nil
+	if perrs != nil {
+		return perrs
+	}
+	return nil
+}
+
+func (p *fakeProducer) sentValues() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.sent...)
+}
+
+func TestSpoolReplayOrder(t *testing.T) {
+	s := openTestSpool(t, t.TempDir())
+	p := &fakeProducer{failures: 1}
+	if err := s.append(testMessages("a", spoolReplayBatch+20)); err != nil {
+		t.Fatal(err)
+	}
+	go s.replay(p)
+	if err := s.append(testMessages("b", 5)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := append(seq("a", spoolReplayBatch+20), seq("b", 5)...)
+	deadline := time.Now().Add(10 * time.Second)
+	for len(p.sentValues()) < len(want) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := p.sentValues(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %d events, want %d in order:\n got %v", len(got), len(want), got)
+	}
+}
+
+func TestProduceAllSpoolsFromFirstFailure(t *testing.T) {
+	s := openTestSpool(t, t.TempDir())
+	// message 2 fails while 3 and 4 go through
+	p := &fakeProducer{failAt: map[int]bool{2: true}}
+	g := &gatewayServer{logger: zap.NewNop(), kafkaProd: p, spool: s}
+	resent := counterValue(spoolResentCounter)
+
+	if failed := g.produceAll(testMessages("a", 5)); len(failed) != 0 {
+		t.Fatalf("failed = %v, want everything sent or spooled", failed)
+	}
+	if got, want := p.sentValues(), []string{"a-0", "a-1", "a-3", "a-4"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("sent %v, want %v", got, want)
+	}
+	// the spool replays from the failed message on, in order
+	if got, want := drain(t, s), []string{"a-2", "a-3", "a-4"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("spooled %v, want %v", got, want)
+	}
+	// a-3 and a-4 reach Kafka twice
+	if got := counterValue(spoolResentCounter) - resent; got != 2 {
+		t.Fatalf("resent counter grew by %v, want 2", got)
+	}
+}
+
+func TestProduceAllWithoutSpool(t *testing.T) {
+	p := &fakeProducer{failAt: map[int]bool{1: true}}
+	g := &gatewayServer{logger: zap.NewNop(), kafkaProd: p}
+	failed := g.produceAll(testMessages("a", 3))
+	if !reflect.DeepEqual(failed, map[int]bool{1: true}) {
+		t.Fatalf("failed = %v, want only position 1", failed)
+	}
+}
+
+func TestSpoolReplayResendsFromFirstFailure(t *testing.T) {
+	s := openTestSpool(t, t.TempDir())
+	// the broker rejects a-2 but accepts a-3 and a-4 of the first attempt
+	p := &fakeProducer{failAt: map[int]bool{2: true}}
+	if err := s.append(testMessages("a", 5)); err != nil {
+		t.Fatal(err)
+	}
+	resent := counterValue(spoolResentCounter)
+	go s.replay(p)
+
+	want := []string{"a-0", "a-1", "a-3", "a-4", "a-2", "a-3", "a-4"}
+	deadline := time.Now().Add(10 * time.Second)
+	for (len(p.sentValues()) < len(want) || s.pending()) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// a-0 and a-1 are committed after the first attempt and not sent again
+	if got := p.sentValues(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("sent %v, want %v", got, want)
+	}
+	if s.pending() {
+		t.Fatal("spool still pending after replay")
+	}
+	if got := counterValue(spoolResentCounter) - resent; got != 2 {
+		t.Fatalf("resent counter grew by %v, want 2", got)
+	}
+}
+
+// corruptRecord rewrites part of the i-th record of the spool's first
+// segment; all records of testMessages of one prefix have the same size.
+func corruptRecord(t *testing.T, s *spool, i int, at int64, b []byte) {
+	t.Helper()
+	size := int64(len(appendSpoolRecord(nil, time.Now(), "logs_raw", []byte("project"), []byte("a-0"))))
+	f, err := os.OpenFile(s.segments[0].path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteAt(b, int64(i)*size+at); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSpoolSkipsCorruptRecord(t *testing.T) {
+	s := openTestSpool(t, t.TempDir())
+	if err := s.append(testMessages("a", 5)); err != nil {
+		t.Fatal(err)
+	}
+	corrupted := counterValue(spoolCorruptCounter)
+	// flip a byte of a-2's value, so its checksum no longer matches
+	corruptRecord(t, s, 2, spoolRecordHeader+8+1+8+2+7, []byte{'X'})
+
+	if got, want := drain(t, s), []string{"a-0", "a-1", "a-3", "a-4"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+	if s.pending() || s.events != 0 || s.bytes != 0 {
+		t.Fatalf("spool still holds %d events, %d bytes", s.events, s.bytes)
+	}
+	if got := counterValue(spoolCorruptCounter) - corrupted; got != 1 {
+		t.Fatalf("corrupt counter grew by %v, want 1", got)
+	}
+}
+
+func TestSpoolSkipsSegmentWithDamagedLength(t *testing.T) {
+	// small segments, so that each append ends up in its own segment
+	s, err := openSpool(t.TempDir(), 1<<20, 100, spoolOverflowReject, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.append(testMessages("a", 5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.append(testMessages("b", 3)); err != nil {
+		t.Fatal(err)
+	}
+	corrupted := counterValue(spoolCorruptCounter)
+	// a-2 claims to be longer than the segment, so nothing after it can
+	// be framed
+	corruptRecord(t, s, 2, 0, []byte{0x7f, 0xff, 0xff, 0xff})
+
+	if got, want := drain(t, s), []string{"a-0", "a-1", "b-0", "b-1", "b-2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+	if s.pending() || s.events != 0 {
+		t.Fatalf("spool still holds %d events", s.events)
+	}
+	if got := counterValue(spoolCorruptCounter) - corrupted; got != 3 {
+		t.Fatalf("corrupt counter grew by %v, want 3", got)
+	}
+}
+
+func TestResendTail(t *testing.T) {
+	msgs := testMessages("a", 5)
+	tests := []struct {
+		name          string
+		unsent        map[*sarama.ProducerMessage]bool
+		first, resent int
+	}{
+		{"whole call failed", nil, 0, 0},
+		{"first message failed", map[*sarama.ProducerMessage]bool{msgs[0]: true}, 0, 4},
+		{"middle failed", map[*sarama.ProducerMessage]bool{msgs[2]: true, msgs[4]: true}, 2, 1},
+		{"last failed", map[*sarama.ProducerMessage]bool{msgs[4]: true}, 4, 0},
+	}
+	for _, tt := range tests {
+		first, resent := resendTail(msgs, tt.unsent)
+		if first != tt.first || resent != tt.resent {
+			t.Errorf("%s: resendTail = %d, %d, want %d, %d", tt.name, first, resent, tt.first, tt.resent)
+		}
+	}
+
+	if sendFailures(errors.New("kafka: brokers unavailable")) != nil {
+		t.Error("a failed call reports single failures")
+	}
+	perrs := sarama.ProducerErrors{{Msg: msgs[1], Err: errors.New("leader not available")}}
+	if got := sendFailures(fmt.Errorf("send: %w", perrs)); !reflect.DeepEqual(got, map[*sarama.ProducerMessage]bool{msgs[1]: true}) {
+		t.Errorf("sendFailures = %v", got)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// streamEntry is one received event waiting for the next flush; msg is nil
+// when the event was rejected and only its sequence needs acking.
+type streamEntry struct {
+	seq uint64
+	msg *sarama.ProducerMessage
+	err error
+}
+
+// logStream is the per-stream state of StreamLogs.
+type logStream struct {
+	g         *gatewayServer
+	stream    ingestpb.IngestService_StreamLogsServer
+	projectID string
+	apiKey    string
+	pending   []streamEntry
+	started   bool
+	lastSeq   uint64 // highest sequence received
+	acked     uint64 // highest sequence acked as durable
+}
+
+// StreamLogs implements ingestpb.IngestServiceServer. The project is
+// authenticated once from the first message; events are batched into Kafka
+// every stream.batch_size events or stream.flush_interval, whichever comes
+// first, and each flush is acked with the last durable sequence number.
+func (g *gatewayServer) StreamLogs(stream ingestpb.IngestService_StreamLogsServer) error {
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	projectID, err := g.authorize(stream.Context(), first.ProjectId, first.ApiKey)
+	if err != nil {
+		return grpcIngestError(err)
+	}
+
+	s := &logStream{
+		g:         g,
+		stream:    stream,
+		projectID: projectID,
+		apiKey:    first.ApiKey,
+	}
+	s.add(first)
+
+	// Recv blocks, so read on a separate goroutine and hand messages over.
+	in := make(chan *ingestpb.StreamLogRequest, g.streamBatch)
+	recvErr := make(chan error, 1)
+	go func() {
+		defer close(in)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					recvErr <- err
+				}
+				return
+			}
+			select {
+			case in <- req:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(g.streamFlush)
+	defer ticker.Stop()
+	for {
+		select {
+		case req, ok := <-in:
+			if !ok {
+				if err := s.flush(); err != nil {
+					return err
+				}
+				select {
+				case err := <-recvErr:
+					return err
+				default:
+					return nil
+				}
+			}
+			s.add(req)
+			if len(s.pending) >= g.streamBatch {
+				if err := s.flush(); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if err := s.flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// add queues one received event, rejecting it up front when it cannot be
+// produced.
+func (s *logStream) add(req *ingestpb.StreamLogRequest) {
+	if s.started && req.Sequence <= s.lastSeq {
+		s.pending = append(s.pending, streamEntry{
+			seq: req.Sequence,
+			err: fmt.Errorf("sequence %d is not greater than %d", req.Sequence, s.lastSeq),
+		})
+		return
+	}
+	s.started = true
+	s.lastSeq = req.Sequence
+	topic, err := s.g.validate(s.projectID, req.Payload)
+	if err != nil {
+		s.pending = append(s.pending, streamEntry{seq: req.Sequence, err: err})
+		return
+	}
+	msg, err := s.g.newMessage(topic, s.projectID, s.apiKey, req.Payload)
+	if err != nil {
+		s.pending = append(s.pending, streamEntry{seq: req.Sequence, err: err})
+		return
+	}
+	s.pending = append(s.pending, streamEntry{seq: req.Sequence, msg: msg})
+}
+
+// admit applies the project's rate limits to a flush. Streams are
+// throttled rather than rejected when over the per-second rates: holding
+// the flush back stops reading from the stream, which pushes back on the
+// agent through flow control. An exhausted daily quota ends the stream.
+func (s *logStream) admit(msgs []*sarama.ProducerMessage) error {
+	bytes := messagesBytes(msgs)
+	for {
+		err := s.g.limiter.allow(s.projectID, int64(len(msgs)), bytes)
+		var rl *rateLimitError
+		if !errors.As(err, &rl) {
+			return err
+		}
+		if rl.Reason == "daily_events" || rl.Reason == "daily_bytes" {
+			return grpcIngestError(err)
+		}
+		select {
+		case <-time.After(rl.RetryAfter):
+		case <-s.stream.Context().Done():
+			return s.stream.Context().Err()
+		}
+	}
+}
+
+// flush produces the pending events and acks the longest durable prefix.
+// When Kafka fails part-way the stream is aborted with Unavailable so the
+// agent reconnects and resumes after the last acked sequence.
+func (s *logStream) flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	var msgs []*sarama.ProducerMessage
+	for i, e := range s.pending {
+		if e.msg != nil {
+			e.msg.Metadata = i
+			msgs = append(msgs, e.msg)
+		}
+	}
+	if err := s.admit(msgs); err != nil {
+		return err
+	}
+	failed := s.g.produceAll(msgs)
+
+	ack := &ingestpb.StreamLogAck{LastSequence: s.acked}
+	broken := false
+	for i, e := range s.pending {
+		if failed[i] {
+			broken = true
+			break
+		}
+		if e.err != nil {
+			ack.Rejected = append(ack.Rejected, &ingestpb.RejectedLog{Sequence: e.seq, Error: e.err.Error()})
+		}
+		if e.seq > ack.LastSequence {
+			ack.LastSequence = e.seq
+		}
+	}
+	s.pending = s.pending[:0]
+
+	if ack.LastSequence != s.acked || len(ack.Rejected) > 0 {
+		if err := s.stream.Send(ack); err != nil {
+			return err
+		}
+		s.acked = ack.LastSequence
+	}
+	if broken {
+		s.g.logger.Warn("aborting log stream after kafka failure",
+			zap.String("project_id", s.projectID), zap.Uint64("acked", s.acked))
+		return status.Errorf(codes.Unavailable, "kafka unavailable; resume after sequence %d", s.acked)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// openTestStream starts StreamLogs on g over gRPC.
+func openTestStream(t *testing.T, g *gatewayServer) ingestpb.IngestService_StreamLogsClient {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	stream, err := dialTestGRPC(t, g).StreamLogs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stream
+}
+
+// sendEvents sends seqs, each an event named events[seq]; sequences not in
+// events are sent unnamed, which is invalid.
+func sendEvents(t *testing.T, stream ingestpb.IngestService_StreamLogsClient, events map[uint64]string, seqs ...uint64) {
+	t.Helper()
+	for _, seq := range seqs {
+		if err := stream.Send(&ingestpb.StreamLogRequest{
+			ProjectId: testProject,
+			ApiKey:    testKey,
+			Sequence:  seq,
+			Payload:   &ingestpb.LogPayload{Name: events[seq]},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// ackSummary renders an ack as its last sequence and rejected sequences.
+func ackSummary(ack *ingestpb.StreamLogAck) []uint64 {
+	out := []uint64{ack.LastSequence}
+	for _, r := range ack.Rejected {
+		out = append(out, r.Sequence)
+	}
+	return out
+}
+
+func TestStreamLogsAcks(t *testing.T) {
+	p := &fakeProducer{}
+	g, _ := newTestGateway(p, nil)
+	g.streamBatch, g.streamFlush = 3, time.Hour
+	stream := openTestStream(t, g)
+	events := map[uint64]string{1: "a", 3: "c", 5: "e", 6: "f"}
+
+	// a flush acks the highest sequence and lists the rejected ones
+	sendEvents(t, stream, events, 1, 2, 3)
+	ack, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ackSummary(ack); !reflect.DeepEqual(got, []uint64{3, 2}) {
+		t.Errorf("first ack = %v, want last 3, rejected 2", got)
+	}
+
+	// sequences must increase; a gap is fine
+	sendEvents(t, stream, events, 3, 5, 6)
+	if ack, err = stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ackSummary(ack); !reflect.DeepEqual(got, []uint64{6, 3}) {
+		t.Errorf("second ack = %v, want last 6, rejected 3", got)
+	}
+	if ack.Rejected[0].Error != "sequence 3 is not greater than 3" {
+		t.Errorf("duplicate rejected with %q", ack.Rejected[0].Error)
+	}
+
+	stream.CloseSend()
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("after close: err = %v, want EOF", err)
+	}
+	if n := len(p.sentValues()); n != 4 {
+		t.Errorf("%d events produced, want 4", n)
+	}
+}
+
+func TestStreamLogsFlushInterval(t *testing.T) {
+	g, _ := newTestGateway(&fakeProducer{}, nil)
+	g.streamBatch, g.streamFlush = 100, 10*time.Millisecond
+	stream := openTestStream(t, g)
+	sendEvents(t, stream, map[uint64]string{7: "a"}, 7)
+	ack, err := stream.Recv()
+	if err != nil || ack.LastSequence != 7 {
+		t.Errorf("ack = %v, %v, want last 7 without closing the stream", ack, err)
+	}
+}
+
+// TestStreamLogsKafkaFailure checks that a failed flush acks only the
+// durable prefix and ends the stream, so the agent resumes from there.
+func TestStreamLogsKafkaFailure(t *testing.T) {
+	p := &fakeProducer{failAt: map[int]bool{1: true}}
+	g, _ := newTestGateway(p, nil)
+	g.streamBatch, g.streamFlush = 3, time.Hour
+	stream := openTestStream(t, g)
+	sendEvents(t, stream, map[uint64]string{1: "a", 2: "b", 3: "c"}, 1, 2, 3)
+
+	ack, err := stream.Recv()
+	if err != nil || ack.LastSequence != 1 || len(ack.Rejected) != 0 {
+		t.Fatalf("ack = %v, %v, want last 1", ack, err)
+	}
+	_, err = stream.Recv()
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("err = %v, want Unavailable", err)
+	}
+}
+
+func TestStreamLogsUnauthorized(t *testing.T) {
+	g, _ := newTestGateway(&fakeProducer{}, nil)
+	stream := openTestStream(t, g)
+	if err := stream.Send(&ingestpb.StreamLogRequest{ApiKey: "wrong", Sequence: 1, Payload: &ingestpb.LogPayload{Name: "a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("err = %v, want Unauthenticated", err)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// maxSyslogMessage bounds a single syslog message on every transport.
+const maxSyslogMessage = 64 * 1024
+
+// syslogListenerConfig binds one syslog socket to a project.
+type syslogListenerConfig struct {
+	// Protocol is udp, tcp (newline framed) or tcp-octet (RFC 6587 octet
+	// counting).
+	Protocol  string
+	Address   string
+	ProjectID string `mapstructure:"project_id"`
+	ApiKey    string `mapstructure:"api_key"`
+}
+
+// syslogListener receives messages on one socket and feeds them to Kafka in
+// batches through the regular batch ingest path.
+type syslogListener struct {
+	g       *gatewayServer
+	cfg     syslogListenerConfig
+	logger  *zap.Logger
+	events  chan *ingestpb.LogPayload
+	batch   int
+	flushIn time.Duration
+}
+
+// startSyslogListeners opens every configured listener; a listener that
+// cannot bind is fatal, as with the HTTP and gRPC ports.
+func (g *gatewayServer) startSyslogListeners(cfgs []syslogListenerConfig, batch int, flushIn time.Duration) error {
+	for _, cfg := range cfgs {
+		l := &syslogListener{
+			g:       g,
+			cfg:     cfg,
+			logger:  g.logger.With(zap.String("listener", cfg.Address), zap.String("protocol", cfg.Protocol)),
+			events:  make(chan *ingestpb.LogPayload, batch),
+			batch:   batch,
+			flushIn: flushIn,
+		}
+		switch cfg.Protocol {
+		case "udp":
+			conn, err := net.ListenPacket("udp", cfg.Address)
+			if err != nil {
+				return fmt.Errorf("syslog listen %s: %w", cfg.Address, err)
+			}
+			go l.serveUDP(conn)
+		case "tcp", "tcp-octet":
+			lis, err := net.Listen("tcp", cfg.Address)
+			if err != nil {
+				return fmt.Errorf("syslog listen %s: %w", cfg.Address, err)
+			}
+			go l.serveTCP(lis)
+		default:
+			return fmt.Errorf("syslog listener %s: unknown protocol %q", cfg.Address, cfg.Protocol)
+		}
+		go l.run()
+		l.logger.Info("syslog listening")
+	}
+	return nil
+}
+
+func (l *syslogListener) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, maxSyslogMessage)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			l.logger.Error("syslog udp read failed", zap.Error(err))
+			return
+		}
+		l.handle(buf[:n], addr)
+	}
+}
+
+func (l *syslogListener) serveTCP(lis net.Listener) {
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			l.logger.Error("syslog accept failed", zap.Error(err))
+			return
+		}
+		go l.serveConn(conn)
+	}
+}
+
+func (l *syslogListener) serveConn(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReaderSize(conn, maxSyslogMessage)
+	for {
+		var (
+			msg []byte
+			err error
+		)
+		if l.cfg.Protocol == "tcp-octet" {
+			msg, err = readOctetCounted(r)
+		} else {
+			msg, err = r.ReadSlice('\n')
+			if errors.Is(err, io.EOF) && len(msg) > 0 {
+				err = nil
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				l.logger.Warn("syslog connection closed", zap.Error(err))
+			}
+			return
+		}
+		l.handle(msg, conn.RemoteAddr())
+	}
+}
+
+// readOctetCounted reads one "MSG-LEN SP SYSLOG-MSG" frame (RFC 6587 3.4.1).
+func readOctetCounted(r *bufio.Reader) ([]byte, error) {
+	head, err := r.ReadSlice(' ')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(string(head[:len(head)-1]))
+	if err != nil || n <= 0 || n > maxSyslogMessage {
+		return nil, fmt.Errorf("invalid octet count %q", head)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// handle parses one message and queues it. A message that cannot be parsed
+// is counted and still forwarded verbatim. UDP messages are dropped and
+// counted when the queue is full, since blocking would stop the read loop
+// and the kernel would drop datagrams unseen; TCP senders are slowed down
+// instead.
+func (l *syslogListener) handle(raw []byte, from net.Addr) {
+	syslogMessagesCounter.WithLabelValues(l.cfg.Address).Inc()
+	now := time.Now()
+	m, err := parseSyslog(raw, now)
+	var p *ingestpb.LogPayload
+	if err != nil {
+		syslogParseErrorCounter.WithLabelValues(l.cfg.Address).Inc()
+		p = &ingestpb.LogPayload{
+			Name:      "syslog",
+			Timestamp: now.UnixNano(),
+			Data: map[string]string{
+				"message":     string(raw),
+				"parse_error": err.Error(),
+			},
+		}
+	} else {
+		p = syslogToPayload(m)
+	}
+	if from != nil {
+		p.Data["source_addr"] = from.String()
+	}
+	if l.cfg.Protocol != "udp" {
+		l.events <- p
+		return
+	}
+	select {
+	case l.events <- p:
+	default:
+		syslogDroppedCounter.WithLabelValues(l.cfg.Address).Inc()
+	}
+}
+
+// run batches queued events and flushes them every batch events or
+// flushIn, whichever comes first. A batch larger than ingest.max_batch_size
+// is sent in chunks.
+func (l *syslogListener) run() {
+	ticker := time.NewTicker(l.flushIn)
+	defer ticker.Stop()
+	pending := make([]*ingestpb.LogPayload, 0, l.batch)
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		resp, err := l.g.sendAll(context.Background(), l.cfg.ProjectID, l.cfg.ApiKey, pending)
+		if err != nil {
+			l.logger.Error("syslog batch failed", zap.Error(err), zap.Int("events", len(pending)))
+		} else if resp.Rejected > 0 {
+			l.logger.Warn("syslog events rejected", zap.Int32("rejected", resp.Rejected))
+		}
+		pending = make([]*ingestpb.LogPayload, 0, l.batch)
+	}
+	for {
+		select {
+		case p := <-l.events:
+			pending = append(pending, p)
+			if len(pending) >= l.batch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// syslogToPayload names the event after the app-name and stores the header
+// fields and structured data (as sd.<SD-ID>.<PARAM>) in Data.
+func syslogToPayload(m *syslogMessage) *ingestpb.LogPayload {
+	data := map[string]string{
+		"facility": strconv.Itoa(m.Facility),
+		"severity": strconv.Itoa(m.Severity),
+		"message":  m.Message,
+	}
+	set := func(k, v string) {
+		if v != "" {
+			data[k] = v
+		}
+	}
+	set("hostname", m.Hostname)
+	set("app_name", m.AppName)
+	set("procid", m.ProcID)
+	set("msgid", m.MsgID)
+	if m.Version > 0 {
+		data["version"] = strconv.Itoa(m.Version)
+	}
+	for id, params := range m.StructuredData {
+		for k, v := range params {
+			data["sd."+id+"."+k] = v
+		}
+	}
+	name := m.AppName
+	if name == "" {
+		name = "syslog"
+	}
+	return &ingestpb.LogPayload{
+		Name:      name,
+		Timestamp: m.Timestamp.UnixNano(),
+		Data:      data,
+	}
+}
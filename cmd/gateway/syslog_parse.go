@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslogMessage is a parsed RFC 5424 or RFC 3164 message. Fields that are
+// absent (or the RFC 5424 NILVALUE "-") are left empty.
+type syslogMessage struct {
+	Facility  int
+	Severity  int
+	Version   int // 1 for RFC 5424, 0 for RFC 3164
+	Timestamp time.Time
+	Hostname  string
+	AppName   string
+	ProcID    string
+	MsgID     string
+	// StructuredData maps SD-ID to its params.
+	StructuredData map[string]map[string]string
+	Message        string
+}
+
+var errSyslogPRI = errors.New("syslog: missing or invalid PRI")
+
+// parseSyslog detects the message format from the header: RFC 5424 has a
+// version digit right after PRI, anything else is treated as RFC 3164. now
+// fills in the year RFC 3164 timestamps lack and the timestamp of messages
+// that have none.
+func parseSyslog(b []byte, now time.Time) (*syslogMessage, error) {
+	b = bytes.TrimRight(b, "\r\n\x00")
+	m := &syslogMessage{}
+	rest, err := m.parsePRI(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) >= 2 && rest[0] >= '1' && rest[0] <= '9' && rest[1] == ' ' {
+		err = m.parse5424(string(rest))
+	} else {
+		m.parse3164(string(rest), now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.Timestamp.IsZero() {
+		m.Timestamp = now
+	}
+	return m, nil
+}
+
+func (m *syslogMessage) parsePRI(b []byte) ([]byte, error) {
+	if len(b) < 3 || b[0] != '<' {
+		return nil, errSyslogPRI
+	}
+	end := bytes.IndexByte(b[:min(len(b), 5)], '>')
+	if end < 2 {
+		return nil, errSyslogPRI
+	}
+	pri := 0
+	for _, c := range b[1:end] {
+		if c < '0' || c > '9' {
+			return nil, errSyslogPRI
+		}
+		pri = pri*10 + int(c-'0')
+	}
+	if pri > 191 {
+		return nil, errSyslogPRI
+	}
+	m.Facility, m.Severity = pri/8, pri%8
+	return b[end+1:], nil
+}
+
+// parse5424 parses everything after PRI:
+// VERSION SP TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP SD [SP MSG]
+func (m *syslogMessage) parse5424(s string) error {
+	fields := strings.SplitN(s, " ", 7)
+	if len(fields) < 7 {
+		return fmt.Errorf("syslog: truncated RFC 5424 header")
+	}
+	m.Version, _ = strconv.Atoi(fields[0])
+	if fields[1] != "-" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[1])
+		if err != nil {
+			return fmt.Errorf("syslog: invalid timestamp %q", fields[1])
+		}
+		m.Timestamp = ts
+	}
+	m.Hostname = nilValue(fields[2])
+	m.AppName = nilValue(fields[3])
+	m.ProcID = nilValue(fields[4])
+	m.MsgID = nilValue(fields[5])
+
+	rest := fields[6]
+	if strings.HasPrefix(rest, "-") {
+		rest = rest[1:]
+	} else {
+		sd, n, err := parseStructuredData(rest)
+		if err != nil {
+			return err
+		}
+		m.StructuredData = sd
+		rest = rest[n:]
+	}
+	if rest != "" && rest[0] != ' ' {
+		return fmt.Errorf("syslog: expected space after structured data")
+	}
+	m.Message = strings.TrimPrefix(strings.TrimPrefix(rest, " "), "\ufeff")
+	return nil
+}
+
+// parseStructuredData parses one or more [SD-ID PARAM="VALUE" ...] elements
+// and returns how many bytes of s it consumed.
+func parseStructuredData(s string) (map[string]map[string]string, int, error) {
+	sd := map[string]map[string]string{}
+	i := 0
+	for i < len(s) && s[i] == '[' {
+		i++
+		start := i
+		for i < len(s) && s[i] != ' ' && s[i] != ']' {
+			i++
+		}
+		if i >= len(s) || i == start {
+			return nil, 0, fmt.Errorf("syslog: invalid SD-ID")
+		}
+		params := map[string]string{}
+		sd[s[start:i]] = params
+		for i < len(s) && s[i] == ' ' {
+			i++
+			start = i
+			for i < len(s) && s[i] != '=' {
+				i++
+			}
+			if i+1 >= len(s) || s[i+1] != '"' {
+				return nil, 0, fmt.Errorf("syslog: invalid SD-PARAM")
+			}
+			name := s[start:i]
+			i += 2
+			var val strings.Builder
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+					i++
+				}
+				val.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, 0, fmt.Errorf("syslog: unterminated SD-PARAM value")
+			}
+			params[name] = val.String()
+			i++
+		}
+		if i >= len(s) || s[i] != ']' {
+			return nil, 0, fmt.Errorf("syslog: unterminated SD-ELEMENT")
+		}
+		i++
+	}
+	return sd, i, nil
+}
+
+// parse3164 parses everything after PRI: TIMESTAMP SP HOSTNAME SP TAG MSG.
+// RFC 3164 only describes what relays should expect, so it never fails:
+// when the header does not fit, the remainder is kept as the message.
+func (m *syslogMessage) parse3164(s string, now time.Time) {
+	const stamp = "Jan _2 15:04:05"
+	if len(s) >= len(stamp) {
+		if ts, err := time.ParseInLocation(stamp, s[:len(stamp)], now.Location()); err == nil {
+			ts = ts.AddDate(now.Year(), 0, 0)
+			// a December message received in January belongs to last year
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			m.Timestamp = ts
+			s = strings.TrimPrefix(s[len(stamp):], " ")
+			if host, rest, ok := strings.Cut(s, " "); ok {
+				m.Hostname = host
+				s = rest
+			}
+		}
+	}
+
+	// TAG is up to 32 alphanumeric characters, optionally followed by [pid]
+	i := 0
+	for i < len(s) && i < 32 && isTagChar(s[i]) {
+		i++
+	}
+	if i > 0 && i < len(s) && (s[i] == ':' || s[i] == '[') {
+		m.AppName = s[:i]
+		s = s[i:]
+		if s[0] == '[' {
+			if end := strings.IndexByte(s, ']'); end > 0 {
+				m.ProcID = s[1:end]
+				s = s[end+1:]
+			}
+		}
+		s = strings.TrimPrefix(s, ":")
+	}
+	m.Message = strings.TrimPrefix(s, " ")
+}
+
+func isTagChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '_' || c == '.' || c == '/'
+}
+
+func nilValue(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
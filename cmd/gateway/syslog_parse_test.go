@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSyslog(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		in   string
+		now  time.Time
+		want syslogMessage
+	}{
+		{
+			name: "rfc 5424 without structured data",
+			in:   "<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - 'su root' failed for lonvick on /dev/pts/8",
+			want: syslogMessage{
+				Facility: 4, Severity: 2, Version: 1,
+				Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3e6, time.UTC),
+				Hostname:  "mymachine.example.com", AppName: "su", MsgID: "ID47",
+				Message: "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			name: "rfc 5424 with structured data and BOM",
+			in:   "<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut=\"3\" eventSource=\"Application\"][origin ip=\"192.0.2.1\"] \ufeffAn application event",
+			want: syslogMessage{
+				Facility: 20, Severity: 5, Version: 1,
+				Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3e6, time.UTC),
+				Hostname:  "mymachine.example.com", AppName: "evntslog", ProcID: "1234", MsgID: "ID47",
+				StructuredData: map[string]map[string]string{
+					"exampleSDID@32473": {"iut": "3", "eventSource": "Application"},
+					"origin":            {"ip": "192.0.2.1"},
+				},
+				Message: "An application event",
+			},
+		},
+		{
+			name: "rfc 5424 structured data without message",
+			in:   "<165>1 2003-10-11T22:14:15Z host app - - [meta seq=\"1\"]",
+			want: syslogMessage{
+				Facility: 20, Severity: 5, Version: 1,
+				Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 0, time.UTC),
+				Hostname:  "host", AppName: "app",
+				StructuredData: map[string]map[string]string{"meta": {"seq": "1"}},
+			},
+		},
+		{
+			name: "rfc 5424 escaped structured data params",
+			in:   `<14>1 2003-10-11T22:14:15Z host app - - [x q="say \"hi\"" p="a\\b" b="[1\]" n="\n"] msg`,
+			want: syslogMessage{
+				Facility: 1, Severity: 6, Version: 1,
+				Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 0, time.UTC),
+				Hostname:  "host", AppName: "app",
+				StructuredData: map[string]map[string]string{
+					// only ", \ and ] are escapes; other backslashes are kept
+					"x": {"q": `say "hi"`, "p": `a\b`, "b": "[1]", "n": `\n`},
+				},
+				Message: "msg",
+			},
+		},
+		{
+			name: "rfc 5424 nil timestamp takes now",
+			in:   "<13>1 - - - - - - hello\r\n",
+			want: syslogMessage{Facility: 1, Severity: 5, Version: 1, Timestamp: now, Message: "hello"},
+		},
+		{
+			name: "rfc 3164 without year",
+			in:   "<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed",
+			want: syslogMessage{
+				Facility: 4, Severity: 2,
+				Timestamp: time.Date(2026, 10, 11, 22, 14, 15, 0, time.UTC),
+				Hostname:  "mymachine", AppName: "su", ProcID: "230",
+				Message: "'su root' failed",
+			},
+		},
+		{
+			name: "rfc 3164 padded day",
+			in:   "<13>Feb  5 01:02:03 host cron: job done",
+			want: syslogMessage{
+				Facility: 1, Severity: 5,
+				Timestamp: time.Date(2026, 2, 5, 1, 2, 3, 0, time.UTC),
+				Hostname:  "host", AppName: "cron", Message: "job done",
+			},
+		},
+		{
+			name: "rfc 3164 december message received in january",
+			in:   "<13>Dec 31 23:59:58 host app: late",
+			now:  time.Date(2027, 1, 1, 0, 0, 5, 0, time.UTC),
+			want: syslogMessage{
+				Facility: 1, Severity: 5,
+				Timestamp: time.Date(2026, 12, 31, 23, 59, 58, 0, time.UTC),
+				Hostname:  "host", AppName: "app", Message: "late",
+			},
+		},
+		{
+			name: "rfc 3164 without header keeps the message",
+			in:   "<5>just some text",
+			want: syslogMessage{Facility: 0, Severity: 5, Timestamp: now, Message: "just some text"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := now
+			if !tt.now.IsZero() {
+				at = tt.now
+			}
+			got, err := parseSyslog([]byte(tt.in), at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Timestamp.Equal(tt.want.Timestamp) {
+				t.Errorf("timestamp = %v, want %v", got.Timestamp, tt.want.Timestamp)
+			}
+			got.Timestamp, tt.want.Timestamp = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSyslogErrors(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	badPRI := []string{
+		"",
+		"no pri at all",
+		"<>1 - - - - - -",
+		"<34",
+		"<abc>1 - - - - - -",
+		"<-1>hello",
+		"<+1>hello",
+		"<192>hello",
+		"<1234>hello",
+	}
+	for _, in := range badPRI {
+		if _, err := parseSyslog([]byte(in), now); !errors.Is(err, errSyslogPRI) {
+			t.Errorf("parseSyslog(%q) = %v, want errSyslogPRI", in, err)
+		}
+	}
+	bad5424 := []string{
+		"<34>1 2003-10-11T22:14:15Z host app",
+		"<34>1 yesterday host app - - - msg",
+		"<34>1 - host app - - [x a=\"1\" msg",
+		"<34>1 - host app - - [x a=1] msg",
+		"<34>1 - host app - - [x a=\"1\"]msg",
+		"<34>1 - host app - - [] msg",
+	}
+	for _, in := range bad5424 {
+		if _, err := parseSyslog([]byte(in), now); err == nil {
+			t.Errorf("parseSyslog(%q) succeeded", in)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// stampTimestamps records when the gateway received p, defaults a missing
+// timestamp to that time and applies the project's skew policy to the
+// timestamp the client sent.
+func (g *gatewayServer) stampTimestamps(projectID string, p *ingestpb.LogPayload) error {
+	now := g.now()
+	p.ReceivedAt = now.UnixNano()
+	if p.Timestamp == 0 {
+		p.Timestamp = p.ReceivedAt
+		timestampAdjustedCounter.WithLabelValues(projectID, "defaulted").Inc()
+		return nil
+	}
+	policy := g.policies.get(projectID).timestamps
+	if policy == nil {
+		return nil
+	}
+
+	ts := time.Unix(0, p.Timestamp)
+	past := time.Duration(policy.MaxPastSeconds) * time.Second
+	future := time.Duration(policy.MaxFutureSeconds) * time.Second
+	var bound time.Time
+	var problem string
+	switch {
+	case past > 0 && ts.Before(now.Add(-past)):
+		bound, problem = now.Add(-past), fmt.Sprintf("more than %s in the past", past)
+	case future > 0 && ts.After(now.Add(future)):
+		bound, problem = now.Add(future), fmt.Sprintf("more than %s in the future", future)
+	default:
+		return nil
+	}
+	if policy.Mode == authpb.TimestampMode_TIMESTAMP_REJECT {
+		timestampAdjustedCounter.WithLabelValues(projectID, "rejected").Inc()
+		return fmt.Errorf("%w: timestamp %s is %s", errInvalidPayload, ts.UTC().Format(time.RFC3339), problem)
+	}
+	timestampAdjustedCounter.WithLabelValues(projectID, "clamped").Inc()
+	p.Timestamp = bound.UnixNano()
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	authpb "github.com/parishadmk/log-system-analysis/internal/api/auth"
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+// TestStampTimestamps checks the skew window edges: a timestamp exactly
+// on an edge is kept, one a nanosecond past it is clamped to the edge or
+// rejected.
+func TestStampTimestamps(t *testing.T) {
+	const hour = int64(time.Hour)
+	g, _ := newTestGateway(&fakeProducer{}, nil)
+	now := g.now().UnixNano()
+	tests := []struct {
+		name string
+		mode authpb.TimestampMode
+		ts   int64
+		want int64 // 0 when rejected
+	}{
+		{"missing defaults to now", authpb.TimestampMode_TIMESTAMP_REJECT, 0, now},
+		{"past edge", authpb.TimestampMode_TIMESTAMP_REJECT, now - 24*hour, now - 24*hour},
+		{"future edge", authpb.TimestampMode_TIMESTAMP_REJECT, now + hour, now + hour},
+		{"past edge reject", authpb.TimestampMode_TIMESTAMP_REJECT, now - 24*hour - 1, 0},
+		{"future edge reject", authpb.TimestampMode_TIMESTAMP_REJECT, now + hour + 1, 0},
+		{"past edge clamp", authpb.TimestampMode_TIMESTAMP_CLAMP, now - 24*hour - 1, now - 24*hour},
+		{"future edge clamp", authpb.TimestampMode_TIMESTAMP_CLAMP, now + hour + 1, now + hour},
+		{"far past clamp", authpb.TimestampMode_TIMESTAMP_CLAMP, 1, now - 24*hour},
+		{"keep", authpb.TimestampMode_TIMESTAMP_KEEP, 1, 1},
+	}
+	for _, tt := range tests {
+		g.policies.set(testProject, &authpb.ApiKeyResponse{Timestamps: &authpb.TimestampPolicy{
+			Mode:             tt.mode,
+			MaxPastSeconds:   24 * 3600,
+			MaxFutureSeconds: 3600,
+		}})
+		p := &ingestpb.LogPayload{Name: "x", Timestamp: tt.ts}
+		err := g.stampTimestamps(testProject, p)
+		if p.ReceivedAt != now {
+			t.Errorf("%s: received_at = %d, want %d", tt.name, p.ReceivedAt, now)
+		}
+		if tt.want == 0 {
+			if !errors.Is(err, errInvalidPayload) {
+				t.Errorf("%s: err = %v, want errInvalidPayload", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || p.Timestamp != tt.want {
+			t.Errorf("%s: timestamp = %d, %v, want %d", tt.name, p.Timestamp, err, tt.want)
+		}
+	}
+}
+
+// TestStampTimestampsOneSided checks that a zero bound leaves that side of
+// the window open.
+func TestStampTimestampsOneSided(t *testing.T) {
+	g, _ := newTestGateway(&fakeProducer{}, nil)
+	now := g.now()
+	g.policies.set(testProject, &authpb.ApiKeyResponse{Timestamps: &authpb.TimestampPolicy{
+		Mode:             authpb.TimestampMode_TIMESTAMP_REJECT,
+		MaxFutureSeconds: 60,
+	}})
+	old := now.AddDate(-10, 0, 0).UnixNano()
+	p := &ingestpb.LogPayload{Name: "x", Timestamp: old}
+	if err := g.stampTimestamps(testProject, p); err != nil || p.Timestamp != old {
+		t.Errorf("ten years old: %d, %v", p.Timestamp, err)
+	}
+	p = &ingestpb.LogPayload{Name: "x", Timestamp: now.Add(61 * time.Second).UnixNano()}
+	if err := g.stampTimestamps(testProject, p); !errors.Is(err, errInvalidPayload) {
+		t.Errorf("61s ahead: err = %v", err)
+	}
+}
@@ -1,217 +1,328 @@
 package main
 
 import (
-    "context"
-    "database/sql"
-    "fmt"
-    "net/http"
-    "os"
-    "os/signal"
-    "syscall"
-    "time"
-
-    "github.com/IBM/sarama"
-    "github.com/gocql/gocql"
-    "github.com/parishadmk/log-system-analysis/internal/lib"
-    ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
-    "github.com/prometheus/client_golang/prometheus"
-    "github.com/prometheus/client_golang/prometheus/promhttp"
-    "github.com/spf13/viper"
-    "go.uber.org/zap"
-    "google.golang.org/protobuf/proto"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/gocql/gocql"
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+	"github.com/parishadmk/log-system-analysis/internal/lib"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 )
 
 type processorConfig struct {
-    Kafka struct {
-        Brokers []string
-        Topic   string
-        Group   string
-    }
-    Cassandra struct {
-        Hosts []string
-    }
-    ClickHouse struct {
-        Dsn string
-    }
-    Processor struct {
-        TtlSeconds int
-    }
-    Metrics struct {
-        Port string
-    }
+	Kafka struct {
+		Brokers []string
+		Topic   string
+		Group   string
+	}
+	Cassandra struct {
+		Hosts []string
+	}
+	ClickHouse struct {
+		Dsn string
+	}
+	Processor struct {
+		TtlSeconds int
+		// how long a client-supplied event_id is remembered for de-duplication
+		DedupWindowSeconds int `mapstructure:"dedup_window_seconds"`
+	}
+	Metrics struct {
+		Port string
+	}
 }
 
 var (
-    processedCounter = prometheus.NewCounter(prometheus.CounterOpts{
-        Name: "processor_messages_processed_total",
-        Help: "Total number of messages successfully processed",
-    })
-    errorCounter = prometheus.NewCounter(prometheus.CounterOpts{
-        Name: "processor_messages_failed_total",
-        Help: "Total number of messages that failed processing",
-    })
-    writeLatencyHist = prometheus.NewHistogram(prometheus.HistogramOpts{
-        Name: "processor_write_latency_seconds",
-        Help: "Latency (s) for dual-write to Cassandra + ClickHouse",
-    })
+	processedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "processor_messages_processed_total",
+		Help: "Total number of messages successfully processed",
+	})
+	errorCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "processor_messages_failed_total",
+		Help: "Total number of messages that failed processing",
+	})
+	writeLatencyHist = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "processor_write_latency_seconds",
+		Help: "Latency (s) for dual-write to Cassandra + ClickHouse",
+	})
+	duplicateCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "processor_duplicate_events_total",
+		Help: "Total number of events skipped because their event_id was already stored",
+	})
 )
 
 func initMetrics() {
-    prometheus.MustRegister(processedCounter, errorCounter, writeLatencyHist)
-    http.Handle("/metrics", promhttp.Handler())
+	prometheus.MustRegister(processedCounter, errorCounter, writeLatencyHist, duplicateCounter)
+	http.Handle("/metrics", promhttp.Handler())
 }
 
 // handler for the Sarama consumer group
 type consumerGroupHandler struct {
-    logger   *zap.Logger
-    cassSess *gocql.Session
-    chDB     *sql.DB
-    ttl      int
+	logger   *zap.Logger
+	cassSess *gocql.Session
+	chDB     *sql.DB
+	ttl      int
+	dedupTTL int
 }
 
 func (h *consumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
 func (h *consumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
 
+// errMalformed marks messages that can never be processed.
+var errMalformed = errors.New("malformed message")
+
+// maxRetryBackoff caps the wait between attempts to store a message.
+const maxRetryBackoff = 30 * time.Second
+
 func (h *consumerGroupHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-    for msg := range claim.Messages() {
-        start := time.Now()
-        if err := h.processMessage(msg); err != nil {
-            h.logger.Error("processing failed", zap.Error(err))
-            errorCounter.Inc()
-            // still mark so we don’t block on a poison message
-            sess.MarkMessage(msg, "")
-            continue
-        }
-        writeLatencyHist.Observe(time.Since(start).Seconds())
-        processedCounter.Inc()
-        sess.MarkMessage(msg, "")
-    }
-    return nil
+	for msg := range claim.Messages() {
+		start := time.Now()
+		err := h.processMessage(msg)
+		// A failed write is retried rather than marked: the event ID may
+		// already be claimed by this message, and only this message can
+		// complete the write.
+		for backoff := time.Second; err != nil && !errors.Is(err, errMalformed); backoff = min(2*backoff, maxRetryBackoff) {
+			h.logger.Error("storage write failed, retrying", zap.Error(err), zap.Duration("backoff", backoff))
+			errorCounter.Inc()
+			select {
+			case <-sess.Context().Done():
+				// left unmarked, so the partition's next owner redelivers it
+				return nil
+			case <-time.After(backoff):
+			}
+			err = h.processMessage(msg)
+		}
+		if err != nil {
+			h.logger.Error("processing failed", zap.Error(err))
+			errorCounter.Inc()
+			// still mark so we don’t block on a poison message
+			sess.MarkMessage(msg, "")
+			continue
+		}
+		writeLatencyHist.Observe(time.Since(start).Seconds())
+		processedCounter.Inc()
+		sess.MarkMessage(msg, "")
+	}
+	return nil
 }
 
 func (h *consumerGroupHandler) processMessage(msg *sarama.ConsumerMessage) error {
-    // 1) Unmarshal protobuf
-    var req ingestpb.LogRequest
-    if err := proto.Unmarshal(msg.Value, &req); err != nil {
-        return fmt.Errorf("proto unmarshal: %w", err)
-    }
-
-    // 2) Parse project UUID
-    pid, err := gocql.ParseUUID(req.ProjectId)
-    if err != nil {
-        return fmt.Errorf("parse project_id: %w", err)
-    }
-
-    // 3) Convert timestamp
-    ts := time.Unix(0, req.Payload.Timestamp)
-
-    // 4) Write to Cassandra with TTL
-    cql := `
+	// 1) Unmarshal protobuf
+	var req ingestpb.LogRequest
+	if err := proto.Unmarshal(msg.Value, &req); err != nil {
+		return fmt.Errorf("%w: proto unmarshal: %v", errMalformed, err)
+	}
+
+	// 2) Parse project UUID
+	pid, err := gocql.ParseUUID(req.ProjectId)
+	if err != nil {
+		return fmt.Errorf("%w: parse project_id: %v", errMalformed, err)
+	}
+
+	// 3) Convert timestamps; messages from gateways that did not stamp
+	//    received_at fall back to the Kafka append time
+	ts := time.Unix(0, req.Payload.Timestamp)
+	receivedAt := msg.Timestamp
+	if req.Payload.ReceivedAt != 0 {
+		receivedAt = time.Unix(0, req.Payload.ReceivedAt)
+	}
+
+	// 4) De-duplicate client retries by event ID: only the first message
+	//    carrying it is stored, so a retry whose timestamp drifted never
+	//    reaches a second partition. Events without an ID are keyed by their
+	//    Kafka position so redeliveries stay idempotent
+	eventID := req.Payload.EventId
+	if eventID != "" {
+		dup, err := h.claimEventID(pid, eventID, msg)
+		if err != nil {
+			return err
+		}
+		if dup {
+			duplicateCounter.Inc()
+			return nil
+		}
+	} else {
+		eventID = fmt.Sprintf("kafka:%d:%d", msg.Partition, msg.Offset)
+	}
+
+	// 5) Write to Cassandra with TTL
+	cql := `
       INSERT INTO logs.events
-        (project_id, kafka_partition, kafka_offset, event_time, event_name, data)
-      VALUES (?, ?, ?, ?, ?, ?)
+        (project_id, kafka_partition, kafka_offset, event_id, event_time, received_at, event_name, data)
+      VALUES (?, ?, ?, ?, ?, ?, ?, ?)
       USING TTL ?`
-    if err := h.cassSess.Query(cql,
-        pid,
-        msg.Partition,
-        msg.Offset,
-        ts,
-        req.Payload.Name,
-        req.Payload.Data,
-        h.ttl,
-    ).Exec(); err != nil {
-        return fmt.Errorf("cassandra insert: %w", err)
-    }
-
-    // 5) Write to ClickHouse
-    chSQL := `
+	if err := h.cassSess.Query(cql,
+		pid,
+		msg.Partition,
+		msg.Offset,
+		eventID,
+		ts,
+		receivedAt,
+		req.Payload.Name,
+		req.Payload.Data,
+		h.ttl,
+	).Exec(); err != nil {
+		return fmt.Errorf("cassandra insert: %w", err)
+	}
+
+	// 6) Write to the query table read by QuerySvc
+	if err := insertEventByTime(h.cassSess, storedEvent{
+		projectID:  pid,
+		partition:  msg.Partition,
+		offset:     msg.Offset,
+		eventID:    eventID,
+		eventTime:  ts,
+		receivedAt: receivedAt,
+		name:       req.Payload.Name,
+		data:       req.Payload.Data,
+	}, h.ttl, h.ttl); err != nil {
+		return err
+	}
+
+	// 7) Write to ClickHouse; rows with the same event_id collapse on merge
+	chSQL := `
       INSERT INTO logs
-        (project_id, timestamp, event_name, data, kafka_partition, kafka_offset)
-      VALUES (?, ?, ?, ?, ?, ?)`
-    if _, err := h.chDB.Exec(chSQL,
-        req.ProjectId,
-        ts,
-        req.Payload.Name,
-        req.Payload.Data,
-        msg.Partition,
-        msg.Offset,
-    ); err != nil {
-        return fmt.Errorf("clickhouse insert: %w", err)
-    }
-
-    return nil
+        (project_id, timestamp, received_at, event_name, data, kafka_partition, kafka_offset, event_id)
+      VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := h.chDB.Exec(chSQL,
+		req.ProjectId,
+		ts,
+		receivedAt,
+		req.Payload.Name,
+		req.Payload.Data,
+		msg.Partition,
+		msg.Offset,
+		eventID,
+	); err != nil {
+		return fmt.Errorf("clickhouse insert: %w", err)
+	}
+
+	return nil
+}
+
+// claimEventID records the first Kafka message carrying eventID and reports
+// whether msg is a duplicate of it. A redelivery of that same message is
+// not a duplicate, so a write interrupted by a failure or a crash is
+// completed.
+func (h *consumerGroupHandler) claimEventID(pid gocql.UUID, eventID string, msg *sarama.ConsumerMessage) (bool, error) {
+	existing := map[string]interface{}{}
+	applied, err := h.cassSess.Query(`
+      INSERT INTO logs.event_ids
+        (project_id, event_id, kafka_partition, kafka_offset)
+      VALUES (?, ?, ?, ?)
+      IF NOT EXISTS
+      USING TTL ?`,
+		pid,
+		eventID,
+		msg.Partition,
+		msg.Offset,
+		h.dedupTTL,
+	).MapScanCAS(existing)
+	if err != nil {
+		return false, fmt.Errorf("cassandra event_id claim: %w", err)
+	}
+	return !applied && !claimedBy(existing, msg), nil
+}
+
+// claimedBy reports whether the existing claim row names msg's position.
+func claimedBy(existing map[string]interface{}, msg *sarama.ConsumerMessage) bool {
+	partition, _ := existing["kafka_partition"].(int)
+	offset, _ := existing["kafka_offset"].(int64)
+	return int32(partition) == msg.Partition && offset == msg.Offset
 }
 
 func main() {
-    // Logger
-    if err := lib.InitLogger(); err != nil {
-        fmt.Fprintf(os.Stderr, "logger init: %v\n", err)
-        os.Exit(1)
-    }
-    logger := lib.Log
-    defer logger.Sync()
-
-    // Config
-    viper.SetConfigFile("/etc/processor/config.yml")
-    if err := lib.LoadConfig(viper.ConfigFileUsed()); err != nil {
-        logger.Fatal("config load failed", zap.Error(err))
-    }
-    var cfg processorConfig
-    if err := viper.Unmarshal(&cfg); err != nil {
-        logger.Fatal("config unmarshal failed", zap.Error(err))
-    }
-
-    // Metrics HTTP endpoint
-    initMetrics()
-    go func() {
-        logger.Info("metrics listening", zap.String("port", cfg.Metrics.Port))
-        if err := http.ListenAndServe(":"+cfg.Metrics.Port, nil); err != nil {
-            logger.Fatal("metrics server failed", zap.Error(err))
-        }
-    }()
-
-    // Cassandra session
-    cassSess, err := lib.NewCassandraSession(cfg.Cassandra.Hosts)
-    if err != nil {
-        logger.Fatal("cassandra connect failed", zap.Error(err))
-    }
-    defer cassSess.Close()
-
-    // ClickHouse connection
-    chDB, err := lib.NewClickHouseConn(cfg.ClickHouse.Dsn)
-    if err != nil {
-        logger.Fatal("clickhouse connect failed", zap.Error(err))
-    }
-    defer chDB.Close()
-
-    // Kafka consumer group
-    consumerGroup, err := lib.NewKafkaConsumer(cfg.Kafka.Brokers, cfg.Kafka.Group, []string{cfg.Kafka.Topic})
-    if err != nil {
-        logger.Fatal("kafka consumer init failed", zap.Error(err))
-    }
-    ctx, cancel := context.WithCancel(context.Background())
-    handler := &consumerGroupHandler{
-        logger:   logger,
-        cassSess: cassSess,
-        chDB:     chDB,
-        ttl:      cfg.Processor.TtlSeconds,
-    }
-    go func() {
-        for {
-            if err := consumerGroup.Consume(ctx, []string{cfg.Kafka.Topic}, handler); err != nil {
-                logger.Error("consumer error", zap.Error(err))
-                time.Sleep(time.Second)
-            }
-            if ctx.Err() != nil {
-                return
-            }
-        }
-    }()
-
-    // Graceful shutdown on SIGINT/SIGTERM
-    sigs := make(chan os.Signal, 1)
-    signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-    <-sigs
-    logger.Info("shutting down processor")
-    cancel()
-}
\ No newline at end of file
+	// Logger
+	if err := lib.InitLogger(); err != nil {
+		fmt.Fprintf(os.Stderr, "logger init: %v\n", err)
+		os.Exit(1)
+	}
+	logger := lib.Log
+	defer logger.Sync()
+
+	// Config
+	viper.SetConfigFile("/etc/processor/config.yml")
+	if err := lib.LoadConfig(viper.ConfigFileUsed()); err != nil {
+		logger.Fatal("config load failed", zap.Error(err))
+	}
+	viper.SetDefault("processor.dedup_window_seconds", 86400)
+	var cfg processorConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		logger.Fatal("config unmarshal failed", zap.Error(err))
+	}
+
+	// Metrics HTTP endpoint
+	initMetrics()
+	go func() {
+		logger.Info("metrics listening", zap.String("port", cfg.Metrics.Port))
+		if err := http.ListenAndServe(":"+cfg.Metrics.Port, nil); err != nil {
+			logger.Fatal("metrics server failed", zap.Error(err))
+		}
+	}()
+
+	// Cassandra session
+	cassSess, err := lib.NewCassandraSession(cfg.Cassandra.Hosts)
+	if err != nil {
+		logger.Fatal("cassandra connect failed", zap.Error(err))
+	}
+	defer cassSess.Close()
+
+	// `processor backfill` fills the query table from logs.events and exits
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		if err := runBackfill(cassSess, logger, 1000, cfg.Processor.TtlSeconds); err != nil {
+			logger.Fatal("backfill failed", zap.Error(err))
+		}
+		return
+	}
+
+	// ClickHouse connection
+	chDB, err := lib.NewClickHouseConn(cfg.ClickHouse.Dsn)
+	if err != nil {
+		logger.Fatal("clickhouse connect failed", zap.Error(err))
+	}
+	defer chDB.Close()
+
+	// Kafka consumer group
+	consumerGroup, err := lib.NewKafkaConsumer(cfg.Kafka.Brokers, cfg.Kafka.Group, []string{cfg.Kafka.Topic})
+	if err != nil {
+		logger.Fatal("kafka consumer init failed", zap.Error(err))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := &consumerGroupHandler{
+		logger:   logger,
+		cassSess: cassSess,
+		chDB:     chDB,
+		ttl:      cfg.Processor.TtlSeconds,
+		dedupTTL: cfg.Processor.DedupWindowSeconds,
+	}
+	go func() {
+		for {
+			if err := consumerGroup.Consume(ctx, []string{cfg.Kafka.Topic}, handler); err != nil {
+				logger.Error("consumer error", zap.Error(err))
+				time.Sleep(time.Second)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+
+	// Graceful shutdown on SIGINT/SIGTERM
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	<-sigs
+	logger.Info("shutting down processor")
+	cancel()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"google.golang.org/protobuf/proto"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+)
+
+func TestClaimedBy(t *testing.T) {
+	msg := &sarama.ConsumerMessage{Partition: 3, Offset: 42}
+	tests := []struct {
+		name     string
+		existing map[string]interface{}
+		want     bool
+	}{
+		{"same message redelivered", map[string]interface{}{"kafka_partition": 3, "kafka_offset": int64(42)}, true},
+		{"retry at another offset", map[string]interface{}{"kafka_partition": 3, "kafka_offset": int64(7)}, false},
+		{"retry in another partition", map[string]interface{}{"kafka_partition": 1, "kafka_offset": int64(42)}, false},
+		{"no existing row", map[string]interface{}{}, false},
+	}
+	for _, tt := range tests {
+		if got := claimedBy(tt.existing, msg); got != tt.want {
+			t.Errorf("%s: claimedBy = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestProcessMessageMalformed checks that undecodable messages are reported
+// as malformed, so they are skipped rather than retried forever.
+func TestProcessMessageMalformed(t *testing.T) {
+	badProject, err := proto.Marshal(&ingestpb.LogRequest{
+		ProjectId: "not-a-uuid",
+		Payload:   &ingestpb.LogPayload{Name: "x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &consumerGroupHandler{}
+	for name, value := range map[string][]byte{
+		"garbage":            {0xff, 0xff, 0xff},
+		"invalid project id": badProject,
+	} {
+		err := h.processMessage(&sarama.ConsumerMessage{Value: value})
+		if !errors.Is(err, errMalformed) {
+			t.Errorf("%s: err = %v, want errMalformed", name, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gocql/gocql"
+	"go.uber.org/zap"
+)
+
+// storedEvent is one row of logs.events.
+type storedEvent struct {
+	projectID  gocql.UUID
+	partition  int32
+	offset     int64
+	eventID    string
+	eventTime  time.Time
+	receivedAt time.Time
+	name       string
+	data       map[string]string
+}
+
+// eventDay is the events_by_time partition of an event time: its UTC day.
+func eventDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// insertEventByTime writes ev to the logs.events_by_time query table with
+// ttl and records its day in logs.event_days with dayTTL. Rewriting a day
+// resets its TTL, so dayTTL must be the longest TTL any event is stored with,
+// or a day could expire while it still has rows. A TTL of 0 writes without
+// expiry.
+func insertEventByTime(sess *gocql.Session, ev storedEvent, ttl, dayTTL int) error {
+	day := eventDay(ev.eventTime)
+	if err := sess.Query(`
+      INSERT INTO logs.events_by_time
+        (project_id, event_name, day, event_time, kafka_partition, kafka_offset, event_id, received_at, data)
+      VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+      USING TTL ?`,
+		ev.projectID, ev.name, day, ev.eventTime, ev.partition, ev.offset,
+		ev.eventID, ev.receivedAt, ev.data, ttl,
+	).Exec(); err != nil {
+		return fmt.Errorf("cassandra events_by_time insert: %w", err)
+	}
+	if err := sess.Query(`
+      INSERT INTO logs.event_days (project_id, event_name, day)
+      VALUES (?, ?, ?)
+      USING TTL ?`,
+		ev.projectID, ev.name, day, dayTTL,
+	).Exec(); err != nil {
+		return fmt.Errorf("cassandra event_days insert: %w", err)
+	}
+	return nil
+}
+
+// runBackfill copies the rows of logs.events into the query table, keeping
+// the time each row has left to live. Days are recorded with at least the
+// processor's retention ttl, so a day outlives every row copied to it. Rows
+// are upserts, so it can be rerun.
+func runBackfill(sess *gocql.Session, logger *zap.Logger, pageSize, ttl int) error {
+	iter := sess.Query(`
+      SELECT project_id, kafka_partition, kafka_offset, event_id, event_time,
+             received_at, event_name, data, TTL(event_name)
+        FROM logs.events`).PageSize(pageSize).Iter()
+	var (
+		ev        storedEvent
+		eventID   *string
+		remaining *int
+		copied    int
+	)
+	for iter.Scan(&ev.projectID, &ev.partition, &ev.offset, &eventID, &ev.eventTime,
+		&ev.receivedAt, &ev.name, &ev.data, &remaining) {
+		rowTTL, dayTTL := prepareBackfill(&ev, eventID, remaining, ttl)
+		if err := insertEventByTime(sess, ev, rowTTL, dayTTL); err != nil {
+			return err
+		}
+		copied++
+		if copied%10000 == 0 {
+			logger.Info("backfill progress", zap.Int("rows", copied))
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("cassandra events scan: %w", err)
+	}
+	logger.Info("backfill done", zap.Int("rows", copied))
+	return nil
+}
+
+// prepareBackfill fills in what rows written before event IDs and receive
+// times were stored lack, and returns the TTLs to copy ev with: the row keeps
+// the time it has left, and its day lives at least the retention ttl. A
+// nil remaining is a row that does not expire.
+func prepareBackfill(ev *storedEvent, eventID *string, remaining *int, ttl int) (rowTTL, dayTTL int) {
+	ev.eventID = fmt.Sprintf("kafka:%d:%d", ev.partition, ev.offset)
+	if eventID != nil && *eventID != "" {
+		ev.eventID = *eventID
+	}
+	if ev.receivedAt.IsZero() {
+		ev.receivedAt = ev.eventTime
+	}
+	if remaining == nil {
+		return 0, 0
+	}
+	return *remaining, max(*remaining, ttl)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventDay(t *testing.T) {
+	tehran := time.FixedZone("IRST", 3*3600+1800)
+	tests := []struct {
+		in, want time.Time
+	}{
+		{time.Date(2026, 10, 17, 23, 59, 59, 0, time.UTC), time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		// 01:00 in Tehran is still the day before in UTC
+		{time.Date(2026, 10, 17, 1, 0, 0, 0, tehran), time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := eventDay(tt.in); !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("eventDay(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPrepareBackfill(t *testing.T) {
+	eventTime := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	receivedAt := eventTime.Add(time.Second)
+	id := "evt-1"
+	empty := ""
+	ttl := func(n int) *int { return &n }
+	tests := []struct {
+		name           string
+		eventID        *string
+		receivedAt     time.Time
+		remaining      *int
+		wantID         string
+		wantReceivedAt time.Time
+		rowTTL, dayTTL int
+	}{
+		{"current row", &id, receivedAt, ttl(3600), "evt-1", receivedAt, 3600, 86400},
+		{"no event id", nil, receivedAt, ttl(3600), "kafka:2:40", receivedAt, 3600, 86400},
+		{"empty event id", &empty, receivedAt, ttl(3600), "kafka:2:40", receivedAt, 3600, 86400},
+		{"no receive time", &id, time.Time{}, ttl(3600), "evt-1", eventTime, 3600, 86400},
+		// a row stored with a longer TTL than today's retention keeps it
+		{"longer than retention", &id, receivedAt, ttl(200000), "evt-1", receivedAt, 200000, 200000},
+		{"no expiry", &id, receivedAt, nil, "evt-1", receivedAt, 0, 0},
+	}
+	for _, tt := range tests {
+		ev := storedEvent{partition: 2, offset: 40, eventTime: eventTime, receivedAt: tt.receivedAt}
+		rowTTL, dayTTL := prepareBackfill(&ev, tt.eventID, tt.remaining, 86400)
+		if ev.eventID != tt.wantID || !ev.receivedAt.Equal(tt.wantReceivedAt) {
+			t.Errorf("%s: event id %q, received at %v, want %q, %v", tt.name, ev.eventID, ev.receivedAt, tt.wantID, tt.wantReceivedAt)
+		}
+		if rowTTL != tt.rowTTL || dayTTL != tt.dayTTL {
+			t.Errorf("%s: TTLs = %d, %d, want %d, %d", tt.name, rowTTL, dayTTL, tt.rowTTL, tt.dayTTL)
+		}
+	}
+}
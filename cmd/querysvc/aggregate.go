@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+const (
+	maxMetrics            = 20
+	maxGroupBy            = 5
+	maxQuantiles          = 10
+	defaultHistogramBins  = 10
+	maxHistogramBins      = 100
+	defaultAggregateLimit = 100
+	maxAggregateLimit     = 1000
+	invalidSampleCount    = 5
+)
+
+var defaultQuantiles = []float64{0.5, 0.95, 0.99}
+
+// numericValue casts a data value to Float64; values that are not numbers
+// become NULL.
+const numericValue = "toFloat64OrNull(data[?])"
+
+// Aggregate computes numeric statistics of data keys
+func (s *queryServer) Aggregate(ctx context.Context, req *querypb.AggregateRequest) (*querypb.AggregateResponse, error) {
+	ctx, err := s.authorize(ctx, req.Token, req.ProjectId)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	resp, err := s.aggregate(ctx, req)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	return resp, nil
+}
+
+// selectList collects the columns of a query together with their bound
+// arguments and scan destinations.
+type selectList struct {
+	exprs []string
+	args  []interface{}
+	dests []interface{}
+}
+
+func (l *selectList) add(expr string, dest interface{}, args ...interface{}) {
+	l.exprs = append(l.exprs, expr)
+	l.args = append(l.args, args...)
+	l.dests = append(l.dests, dest)
+}
+
+func (s *queryServer) aggregate(ctx context.Context, req *querypb.AggregateRequest) (*querypb.AggregateResponse, error) {
+	if req.ProjectId == "" {
+		return nil, fmt.Errorf("%w: project_id is required", errBadRequest)
+	}
+	if len(req.Metrics) == 0 || len(req.Metrics) > maxMetrics {
+		return nil, fmt.Errorf("%w: metrics needs 1 to %d metrics", errBadRequest, maxMetrics)
+	}
+	if len(req.GroupBy) > maxGroupBy {
+		return nil, fmt.Errorf("%w: at most %d group_by columns", errBadRequest, maxGroupBy)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultAggregateLimit
+	}
+	if limit > maxAggregateLimit {
+		return nil, fmt.Errorf("%w: limit must be at most %d", errBadRequest, maxAggregateLimit)
+	}
+	now := time.Now()
+	window, err := parseTimeRange(req.From, req.To, now)
+	if err != nil {
+		return nil, err
+	}
+	var step time.Duration
+	switch req.Interval {
+	case "":
+	case "auto":
+		if window, err = window.bounded(now, defaultHistogramWindow); err != nil {
+			return nil, err
+		}
+		step, _ = histogramInterval("", window)
+	default:
+		if step, err = histogramInterval(req.Interval, window); err != nil {
+			return nil, err
+		}
+	}
+	where, whereArgs, err := s.eventPredicate(ctx, req.ProjectId, window, req.EventNames, req.Filters, req.Conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		cols    selectList
+		groupBy []string
+		keys    = make([]string, len(req.GroupBy))
+		bucket  int64
+		events  int64
+	)
+	for i, g := range req.GroupBy {
+		expr, args, err := s.splitColumn(ctx, req.ProjectId, g)
+		if err != nil {
+			return nil, err
+		}
+		if expr == "" {
+			return nil, fmt.Errorf("%w: empty group_by column", errBadRequest)
+		}
+		alias := "g" + strconv.Itoa(i)
+		cols.add(expr+" AS "+alias, &keys[i], args...)
+		groupBy = append(groupBy, alias)
+	}
+	orderBy := "events DESC"
+	if step > 0 {
+		cols.add("intDiv(toUnixTimestamp64Nano(timestamp), ?) * ? AS bucket", &bucket, int64(step), int64(step))
+		groupBy = append(groupBy, "bucket")
+		orderBy = "bucket, events DESC"
+	}
+	cols.add("count() AS events", &events)
+	if len(req.GroupBy) > 0 {
+		orderBy += ", " + strings.Join(groupBy[:len(req.GroupBy)], ", ")
+	}
+
+	results := make([]metricColumns, len(req.Metrics))
+	for i, m := range req.Metrics {
+		if err := s.addMetric(ctx, req.ProjectId, &cols, m, &results[i]); err != nil {
+			return nil, fmt.Errorf("metric %d: %w", i, err)
+		}
+	}
+
+	sqlStr := "SELECT " + strings.Join(cols.exprs, ",\n       ") + `
+               FROM logs FINAL
+               WHERE project_id = ?` + where
+	args := append(cols.args, req.ProjectId)
+	args = append(args, whereArgs...)
+	if len(groupBy) > 0 {
+		sqlStr += " GROUP BY " + strings.Join(groupBy, ", ")
+	}
+	sqlStr += " ORDER BY " + orderBy + " LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.chDB.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		zapLog.Error("clickhouse aggregate", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &querypb.AggregateResponse{Groups: []*querypb.AggregateGroup{}, Interval: int64(step)}
+	for rows.Next() {
+		if err := rows.Scan(cols.dests...); err != nil {
+			zapLog.Error("row scan", zap.Error(err))
+			return nil, err
+		}
+		if events == 0 {
+			// an aggregate without GROUP BY returns one row even when
+			// nothing matches
+			continue
+		}
+		g := &querypb.AggregateGroup{
+			Keys:   slices.Clone(keys),
+			Bucket: bucket,
+			Events: events,
+		}
+		for i := range results {
+			g.Metrics = append(g.Metrics, results[i].result())
+		}
+		resp.Groups = append(resp.Groups, g)
+	}
+	return resp, rows.Err()
+}
+
+// metricColumns receives the columns of one metric.
+type metricColumns struct {
+	value            float64
+	quantiles        []float64
+	lower, upper, nb []float64
+	count, invalid   int64
+	samples          []string
+}
+
+func (c *metricColumns) result() *querypb.MetricResult {
+	r := &querypb.MetricResult{
+		Value:          finite(c.value),
+		Count:          c.count,
+		Invalid:        c.invalid,
+		InvalidSamples: slices.Clone(c.samples),
+	}
+	for _, q := range c.quantiles {
+		r.Quantiles = append(r.Quantiles, finite(q))
+	}
+	for i := range c.nb {
+		if i < len(c.lower) && i < len(c.upper) {
+			r.Bins = append(r.Bins, &querypb.HistogramBin{Lower: c.lower[i], Upper: c.upper[i], Count: c.nb[i]})
+		}
+	}
+	return r
+}
+
+// finite maps the NaN ClickHouse returns for an empty average or quantile
+// to 0; count tells the two apart.
+func finite(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
+
+// addMetric appends the columns of m. Every statistic skips values that are
+// not numbers, and those are counted and sampled alongside it.
+func (s *queryServer) addMetric(ctx context.Context, projectID string, cols *selectList, m *querypb.Metric, dst *metricColumns) error {
+	// identical expressions of different metrics get distinct names
+	add := func(expr string, dest interface{}, args ...interface{}) {
+		cols.add(expr+" AS c"+strconv.Itoa(len(cols.exprs)), dest, args...)
+	}
+	op := strings.ToLower(m.Op)
+	if m.Key == "" {
+		if op != "count" {
+			return fmt.Errorf("%w: %s needs a key", errBadRequest, m.Op)
+		}
+		add("toFloat64(count())", &dst.value)
+		add("count()", &dst.count)
+		add("toUInt64(0)", &dst.invalid)
+		add("emptyArrayString()", &dst.samples)
+		return nil
+	}
+	searchable, err := s.projects.searchableKeys(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(searchable, m.Key) {
+		return fmt.Errorf("%w: %q is not a searchable key of this project", errBadRequest, m.Key)
+	}
+	k := m.Key
+	num := "ifNull(" + numericValue + ", 0), isNotNull(" + numericValue + ")"
+
+	switch op {
+	case "count":
+		add("toFloat64(countIf(isNotNull("+numericValue+")))", &dst.value, k)
+	case "sum", "avg", "min", "max":
+		add("toFloat64("+op+"If("+num+"))", &dst.value, k, k)
+	case "quantiles":
+		levels := m.Quantiles
+		if len(levels) == 0 {
+			levels = defaultQuantiles
+		}
+		if len(levels) > maxQuantiles {
+			return fmt.Errorf("%w: at most %d quantiles", errBadRequest, maxQuantiles)
+		}
+		args := make([]interface{}, 0, len(levels)+2)
+		for _, q := range levels {
+			if !(q >= 0 && q <= 1) {
+				return fmt.Errorf("%w: quantile %v is not between 0 and 1", errBadRequest, q)
+			}
+			args = append(args, q)
+		}
+		args = append(args, k, k)
+		add("quantilesIf("+strings.TrimSuffix(strings.Repeat("?, ", len(levels)), ", ")+")("+num+")", &dst.quantiles, args...)
+	case "histogram":
+		bins := m.Bins
+		if bins <= 0 {
+			bins = defaultHistogramBins
+		}
+		if bins > maxHistogramBins {
+			return fmt.Errorf("%w: bins must be at most %d", errBadRequest, maxHistogramBins)
+		}
+		alias := "h" + strconv.Itoa(len(cols.exprs))
+		add("arrayMap(b -> tupleElement(b, 1), histogramIf(?)("+num+") AS "+alias+")", &dst.lower, bins, k, k)
+		add("arrayMap(b -> tupleElement(b, 2), "+alias+")", &dst.upper)
+		add("arrayMap(b -> tupleElement(b, 3), "+alias+")", &dst.nb)
+	default:
+		return fmt.Errorf("%w: unknown op %q", errBadRequest, m.Op)
+	}
+	add("countIf(isNotNull("+numericValue+"))", &dst.count, k)
+	add("countIf(mapContains(data, ?) AND isNull("+numericValue+"))", &dst.invalid, k, k)
+	add("groupUniqArrayIf(?)(data[?], mapContains(data, ?) AND isNull("+numericValue+"))",
+		&dst.samples, invalidSampleCount, k, k, k)
+	return nil
+}
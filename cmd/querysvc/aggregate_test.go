@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+func TestAggregate(t *testing.T) {
+	ch := &fakeClickHouse{results: [][][]driver.Value{{{
+		"login", int64(4),
+		float64(12.5), int64(4), int64(1), []string{"n/a"},
+		[]float64{10, 20}, int64(4), int64(1), []string{"n/a"},
+		float64(5), int64(5), int64(0), []string{},
+	}}}}
+	s := newTestServer(ch, "latency")
+	resp, err := s.Aggregate(context.Background(), &querypb.AggregateRequest{
+		Token:     testToken(t, testUser),
+		ProjectId: testProject,
+		GroupBy:   []string{"event_name"},
+		Metrics: []*querypb.Metric{
+			{Op: "avg", Key: "latency"},
+			{Op: "quantiles", Key: "latency", Quantiles: []float64{0.5, 0.9}},
+			{Op: "count"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &querypb.AggregateResponse{Groups: []*querypb.AggregateGroup{{
+		Keys:   []string{"login"},
+		Events: 4,
+		Metrics: []*querypb.MetricResult{
+			{Value: 12.5, Count: 4, Invalid: 1, InvalidSamples: []string{"n/a"}},
+			{Quantiles: []float64{10, 20}, Count: 4, Invalid: 1, InvalidSamples: []string{"n/a"}},
+			{Value: 5, Count: 5},
+		},
+	}}}
+	if !proto.Equal(resp, want) {
+		t.Errorf("response = %v\nwant %v", resp, want)
+	}
+
+	q := ch.query(t, 0)
+	for _, part := range []string{
+		"SELECT event_name AS g0,\n       count() AS events,",
+		"toFloat64(avgIf(ifNull(toFloat64OrNull(data[?]), 0), isNotNull(toFloat64OrNull(data[?])))) AS c2",
+		"quantilesIf(?, ?)(ifNull(toFloat64OrNull(data[?]), 0), isNotNull(toFloat64OrNull(data[?]))) AS c6",
+		"toFloat64(count()) AS c10",
+		" GROUP BY g0 ORDER BY events DESC, g0 LIMIT ?",
+	} {
+		if !strings.Contains(q.sql, part) {
+			t.Errorf("sql has no %q:\n%s", part, q.sql)
+		}
+	}
+	k := "latency"
+	wantArgs := []interface{}{
+		k, k, k, k, k, invalidSampleCount, k, k, k,
+		0.5, 0.9, k, k, k, k, k, invalidSampleCount, k, k, k,
+		testProject, int32(defaultAggregateLimit),
+	}
+	if !reflect.DeepEqual(q.args, wantArgs) {
+		t.Errorf("args = %#v\nwant %#v", q.args, wantArgs)
+	}
+}
+
+// TestAggregateByTime checks time buckets, histogram bins and that an empty
+// average is reported as 0.
+func TestAggregateByTime(t *testing.T) {
+	bucket := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC).UnixNano()
+	ch := &fakeClickHouse{results: [][][]driver.Value{{{
+		bucket, int64(3),
+		[]float64{0, 50}, []float64{50, 100}, []float64{2, 1}, int64(3), int64(0), []string{},
+		math.NaN(), int64(0), int64(0), []string{},
+	}}}}
+	s := newTestServer(ch, "latency", "size")
+	resp, err := s.Aggregate(context.Background(), &querypb.AggregateRequest{
+		Token:     testToken(t, testUser),
+		ProjectId: testProject,
+		From:      "2026-10-17T00:00:00Z",
+		To:        "2026-10-17T02:00:00Z",
+		Interval:  "1h",
+		Metrics: []*querypb.Metric{
+			{Op: "histogram", Key: "latency", Bins: 2},
+			{Op: "AVG", Key: "size"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &querypb.AggregateResponse{Interval: int64(time.Hour), Groups: []*querypb.AggregateGroup{{
+		Bucket: bucket,
+		Events: 3,
+		Metrics: []*querypb.MetricResult{
+			{Bins: []*querypb.HistogramBin{{Lower: 0, Upper: 50, Count: 2}, {Lower: 50, Upper: 100, Count: 1}}, Count: 3},
+			{},
+		},
+	}}}
+	if !proto.Equal(resp, want) {
+		t.Errorf("response = %v\nwant %v", resp, want)
+	}
+
+	q := ch.query(t, 0)
+	if !strings.Contains(q.sql, "histogramIf(?)(") || !strings.Contains(q.sql, " GROUP BY bucket ORDER BY bucket, events DESC LIMIT ?") {
+		t.Errorf("sql = %s", q.sql)
+	}
+	step := int64(time.Hour)
+	if got := q.args[:5]; !reflect.DeepEqual(got, []interface{}{step, step, int32(2), "latency", "latency"}) {
+		t.Errorf("leading args = %#v", got)
+	}
+}
+
+// TestAggregateNoMatches checks that the single row of an ungrouped
+// aggregate over no events is not returned as a group.
+func TestAggregateNoMatches(t *testing.T) {
+	ch := &fakeClickHouse{results: [][][]driver.Value{{{
+		int64(0), float64(0), int64(0), int64(0), []string{},
+	}}}}
+	s := newTestServer(ch)
+	resp, err := s.Aggregate(context.Background(), &querypb.AggregateRequest{
+		Token:     testToken(t, testUser),
+		ProjectId: testProject,
+		Metrics:   []*querypb.Metric{{Op: "count"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Groups == nil || len(resp.Groups) != 0 {
+		t.Errorf("groups = %v, want none", resp.Groups)
+	}
+}
+
+func TestAggregateErrors(t *testing.T) {
+	s := newTestServer(&fakeClickHouse{}, "latency")
+	metric := []*querypb.Metric{{Op: "count"}}
+	tests := []struct {
+		name string
+		req  *querypb.AggregateRequest
+	}{
+		{"no metrics", &querypb.AggregateRequest{}},
+		{"too many metrics", &querypb.AggregateRequest{Metrics: make([]*querypb.Metric, maxMetrics+1)}},
+		{"too many group_by", &querypb.AggregateRequest{Metrics: metric, GroupBy: make([]string, maxGroupBy+1)}},
+		{"empty group_by", &querypb.AggregateRequest{Metrics: metric, GroupBy: []string{""}}},
+		{"unsearchable group_by", &querypb.AggregateRequest{Metrics: metric, GroupBy: []string{"data.user"}}},
+		{"limit", &querypb.AggregateRequest{Metrics: metric, Limit: maxAggregateLimit + 1}},
+		{"interval", &querypb.AggregateRequest{Metrics: metric, Interval: "1500ms"}},
+		{"sum without key", &querypb.AggregateRequest{Metrics: []*querypb.Metric{{Op: "sum"}}}},
+		{"unsearchable key", &querypb.AggregateRequest{Metrics: []*querypb.Metric{{Op: "sum", Key: "user"}}}},
+		{"unknown op", &querypb.AggregateRequest{Metrics: []*querypb.Metric{{Op: "median", Key: "latency"}}}},
+		{"quantile above 1", &querypb.AggregateRequest{Metrics: []*querypb.Metric{{Op: "quantiles", Key: "latency", Quantiles: []float64{1.5}}}}},
+		{"quantile NaN", &querypb.AggregateRequest{Metrics: []*querypb.Metric{{Op: "quantiles", Key: "latency", Quantiles: []float64{math.NaN()}}}}},
+		{"too many quantiles", &querypb.AggregateRequest{Metrics: []*querypb.Metric{{Op: "quantiles", Key: "latency", Quantiles: make([]float64, maxQuantiles+1)}}}},
+		{"too many bins", &querypb.AggregateRequest{Metrics: []*querypb.Metric{{Op: "histogram", Key: "latency", Bins: maxHistogramBins + 1}}}},
+	}
+	tok := testToken(t, testUser)
+	for _, tt := range tests {
+		tt.req.Token, tt.req.ProjectId = tok, testProject
+		if _, err := s.Aggregate(context.Background(), tt.req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: err = %v, want InvalidArgument", tt.name, err)
+		}
+	}
+	// the failing metric is named
+	_, err := s.Aggregate(context.Background(), &querypb.AggregateRequest{
+		Token: tok, ProjectId: testProject,
+		Metrics: []*querypb.Metric{{Op: "count"}, {Op: "median", Key: "latency"}},
+	})
+	if msg := status.Convert(err).Message(); !strings.HasPrefix(msg, "metric 1: ") {
+		t.Errorf("message = %q", msg)
+	}
+}
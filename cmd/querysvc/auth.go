@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc/metadata"
+)
+
+type userIDKey struct{}
+
+// withUserID returns ctx carrying the authenticated user's ID.
+func withUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// userIDFromContext returns the user ID set by authorize.
+func userIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(userIDKey{}).(string)
+	return id
+}
+
+// authenticate verifies the JWT passed in the request's token field, or
+// else in "authorization: Bearer" metadata.
+func (s *queryServer) authenticate(ctx context.Context, token string) (*claims, error) {
+	if token == "" {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			for _, v := range md.Get("authorization") {
+				if strings.HasPrefix(v, "Bearer ") {
+					token = strings.TrimPrefix(v, "Bearer ")
+				}
+			}
+		}
+	}
+	if token == "" {
+		return nil, errUnauthenticated
+	}
+	c := &claims{}
+	parsed, err := jwt.ParseWithClaims(token, c, func(t *jwt.Token) (interface{}, error) {
+		return s.jwtKey, nil
+	})
+	if err != nil || !parsed.Valid || c.UserID == "" {
+		return nil, errUnauthenticated
+	}
+	if _, err := gocql.ParseUUID(c.UserID); err != nil {
+		return nil, errUnauthenticated
+	}
+	return c, nil
+}
+
+// checkProjectID rejects a project ID that is not a UUID, before it reaches
+// a query comparing it with a UUID column.
+func checkProjectID(projectID string) error {
+	if _, err := gocql.ParseUUID(projectID); err != nil {
+		return fmt.Errorf("%w: invalid project_id", errBadRequest)
+	}
+	return nil
+}
+
+// authorize authenticates the caller and checks that they are a member of
+// projectID and that it is not archived. The returned context carries the
+// caller's user ID.
+func (s *queryServer) authorize(ctx context.Context, token, projectID string) (context.Context, error) {
+	c, err := s.authenticate(ctx, token)
+	if err != nil {
+		return ctx, err
+	}
+	ctx = withUserID(ctx, c.UserID)
+	if projectID == "" {
+		// the request itself is rejected as incomplete
+		return ctx, nil
+	}
+	if err := checkProjectID(projectID); err != nil {
+		return ctx, err
+	}
+	member, archived, err := s.projects.membership(ctx, c.UserID, projectID)
+	if err != nil {
+		return ctx, err
+	}
+	if !member {
+		return ctx, errForbidden
+	}
+	if archived {
+		return ctx, fmt.Errorf("%w: project is archived", errNotFound)
+	}
+	return ctx, nil
+}
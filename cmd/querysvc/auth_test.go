@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthorize(t *testing.T) {
+	const (
+		archivedProject = "1c9e5e2a-3f4b-4d6c-8e7f-90a1b2c3d4e5"
+		otherUser       = "5a4b3c2d-1e0f-4a9b-8c7d-6e5f4a3b2c1d"
+	)
+	s := newTestServer(&fakeClickHouse{})
+	expires := time.Now().Add(time.Hour)
+	s.projects.members[membershipKey{testUser, archivedProject}] = membershipEntry{member: true, archived: true, expires: expires}
+	s.projects.members[membershipKey{otherUser, testProject}] = membershipEntry{member: false, expires: expires}
+
+	tests := []struct {
+		name      string
+		token     string
+		projectID string
+		want      error
+	}{
+		{"member", testToken(t, testUser), testProject, nil},
+		{"not a member", testToken(t, otherUser), testProject, errForbidden},
+		{"archived project", testToken(t, testUser), archivedProject, errNotFound},
+		{"project id not a UUID", testToken(t, testUser), "proj-1", errBadRequest},
+		{"user id not a UUID", testToken(t, "alice"), testProject, errUnauthenticated},
+		{"no user id", testToken(t, ""), testProject, errUnauthenticated},
+		// the handler rejects the request itself
+		{"no project", testToken(t, testUser), "", nil},
+	}
+	for _, tt := range tests {
+		ctx, err := s.authorize(context.Background(), tt.token, tt.projectID)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+			continue
+		}
+		if tt.want != errUnauthenticated && userIDFromContext(ctx) == "" {
+			t.Errorf("%s: no user ID in the context", tt.name)
+		}
+	}
+}
+
+// TestMembershipHTTPStatus checks the statuses the UI sees for a project it
+// may not read.
+func TestMembershipHTTPStatus(t *testing.T) {
+	const otherProject = "2d8f6a1b-5c4e-4f3a-9b2c-1a0e9d8c7b6a"
+	s := newTestServer(&fakeClickHouse{})
+	s.projects.members[membershipKey{testUser, otherProject}] = membershipEntry{member: false, expires: time.Now().Add(time.Hour)}
+	tok := testToken(t, testUser)
+
+	post := func(projectID string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/detail",
+			strings.NewReader(`{"project_id":"`+projectID+`","event_name":"login"}`))
+		req.Header.Set("Authorization", "Bearer "+tok)
+		rec := httptest.NewRecorder()
+		s.handleDetail(rec, req)
+		return rec.Code
+	}
+	if code := post(otherProject); code != http.StatusForbidden {
+		t.Errorf("not a member: status = %d, want 403", code)
+	}
+	if code := post("not-a-uuid"); code != http.StatusBadRequest {
+		t.Errorf("bad project id: status = %d, want 400", code)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+const cursorVersion = 2
+
+// detailCursor is a position in the occurrences of an event, ordered by
+// event time and then Kafka (partition, offset), and the direction to page
+// from it. Clients treat the encoded form as opaque.
+type detailCursor struct {
+	older     bool // page towards older occurrences, else newer
+	eventTime time.Time
+	partition int32
+	offset    int64
+}
+
+func (c detailCursor) encode() string {
+	b := make([]byte, 0, 22)
+	b = append(b, cursorVersion)
+	if c.older {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = binary.BigEndian.AppendUint64(b, uint64(c.eventTime.UnixMilli()))
+	b = binary.BigEndian.AppendUint32(b, uint32(c.partition))
+	b = binary.BigEndian.AppendUint64(b, uint64(c.offset))
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (detailCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) != 22 || b[0] != cursorVersion || b[1] > 1 {
+		return detailCursor{}, fmt.Errorf("%w: invalid cursor", errBadRequest)
+	}
+	return detailCursor{
+		older:     b[1] == 1,
+		eventTime: time.UnixMilli(int64(binary.BigEndian.Uint64(b[2:10]))),
+		partition: int32(binary.BigEndian.Uint32(b[10:14])),
+		offset:    int64(binary.BigEndian.Uint64(b[14:22])),
+	}, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/base64"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursors := []detailCursor{
+		{older: true, eventTime: time.UnixMilli(1700000000123), partition: 3, offset: 42},
+		{older: false, eventTime: time.UnixMilli(0), partition: 0, offset: 0},
+		{older: true, eventTime: time.UnixMilli(-86400000), partition: math.MaxInt32, offset: math.MaxInt64},
+	}
+	for _, c := range cursors {
+		got, err := decodeCursor(c.encode())
+		if err != nil {
+			t.Fatalf("%+v: %v", c, err)
+		}
+		if got.older != c.older || !got.eventTime.Equal(c.eventTime) || got.partition != c.partition || got.offset != c.offset {
+			t.Errorf("round trip of %+v gave %+v", c, got)
+		}
+	}
+}
+
+func TestDecodeBadCursor(t *testing.T) {
+	valid := detailCursor{older: true, eventTime: time.UnixMilli(1700000000123), partition: 3, offset: 42}.encode()
+	raw, _ := base64.RawURLEncoding.DecodeString(valid)
+	tampered := func(f func(b []byte) []byte) string {
+		b := append([]byte(nil), raw...)
+		return base64.RawURLEncoding.EncodeToString(f(b))
+	}
+	tests := map[string]string{
+		"garbage":           "not a cursor!",
+		"padded base64":     base64.URLEncoding.EncodeToString(raw[:20]),
+		"std base64":        base64.StdEncoding.EncodeToString([]byte{0xfb, 0xff}),
+		"empty payload":     tampered(func(b []byte) []byte { return b[:0] }),
+		"truncated":         tampered(func(b []byte) []byte { return b[:21] }),
+		"extended":          tampered(func(b []byte) []byte { return append(b, 0) }),
+		"old version":       tampered(func(b []byte) []byte { b[0] = 1; return b }),
+		"future version":    tampered(func(b []byte) []byte { b[0] = cursorVersion + 1; return b }),
+		"unknown direction": tampered(func(b []byte) []byte { b[1] = 2; return b }),
+	}
+	for name, s := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeCursor(s)
+			if err == nil {
+				t.Fatalf("decodeCursor(%q) succeeded", s)
+			}
+			gerr := grpcQueryError(err)
+			if code := status.Code(gerr); code != codes.InvalidArgument {
+				t.Errorf("code = %v, want InvalidArgument", code)
+			}
+			if got := httpStatus(gerr); got != http.StatusBadRequest {
+				t.Errorf("http status = %d, want 400", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+const (
+	maxFacetKeys      = 20
+	defaultFacetLimit = 10
+	maxFacetLimit     = 100
+)
+
+// GetFacets breaks matching events down by the values of data keys
+func (s *queryServer) GetFacets(ctx context.Context, req *querypb.FacetsRequest) (*querypb.FacetsResponse, error) {
+	ctx, err := s.authorize(ctx, req.Token, req.ProjectId)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	resp, err := s.getFacets(ctx, req)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	return resp, nil
+}
+
+func (s *queryServer) getFacets(ctx context.Context, req *querypb.FacetsRequest) (*querypb.FacetsResponse, error) {
+	if req.ProjectId == "" {
+		return nil, fmt.Errorf("%w: project_id is required", errBadRequest)
+	}
+	if len(req.Keys) == 0 || len(req.Keys) > maxFacetKeys {
+		return nil, fmt.Errorf("%w: keys needs 1 to %d keys", errBadRequest, maxFacetKeys)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultFacetLimit
+	}
+	if limit > maxFacetLimit {
+		return nil, fmt.Errorf("%w: limit must be at most %d", errBadRequest, maxFacetLimit)
+	}
+	searchable, err := s.projects.searchableKeys(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	resp := &querypb.FacetsResponse{}
+	facets := map[string]*querypb.Facet{}
+	for _, key := range req.Keys {
+		if !slices.Contains(searchable, key) {
+			return nil, fmt.Errorf("%w: %q is not a searchable key of this project", errBadRequest, key)
+		}
+		if facets[key] != nil {
+			return nil, fmt.Errorf("%w: duplicate key %q", errBadRequest, key)
+		}
+		facets[key] = &querypb.Facet{Key: key, Values: []*querypb.FacetValue{}}
+		resp.Facets = append(resp.Facets, facets[key])
+	}
+	window, err := parseTimeRange(req.From, req.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	where, whereArgs, err := s.eventPredicate(ctx, req.ProjectId, window, req.EventNames, req.Filters, req.Conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	// every matching row is joined with each requested key, so one pass
+	// answers all keys
+	keyList := "[" + strings.TrimSuffix(strings.Repeat("?, ", len(req.Keys)), ", ") + "]"
+	keyArgs := make([]interface{}, 0, len(req.Keys)+1+len(whereArgs))
+	for _, key := range req.Keys {
+		keyArgs = append(keyArgs, key)
+	}
+	keyArgs = append(keyArgs, req.ProjectId)
+	keyArgs = append(keyArgs, whereArgs...)
+
+	statsSQL := `SELECT facet_key,
+                        count() AS total,
+                        countIf(mapContains(data, facet_key)) AS present,
+                        uniqIf(data[facet_key], mapContains(data, facet_key)) AS distinct_values
+                 FROM logs FINAL
+                 ARRAY JOIN ` + keyList + ` AS facet_key
+                 WHERE project_id = ?` + where + `
+                 GROUP BY facet_key`
+	rows, err := s.chDB.QueryContext(ctx, statsSQL, keyArgs...)
+	if err != nil {
+		zapLog.Error("clickhouse facets", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			key                      string
+			total, present, distinct int64
+		)
+		if err := rows.Scan(&key, &total, &present, &distinct); err != nil {
+			zapLog.Error("row scan", zap.Error(err))
+			return nil, err
+		}
+		if f := facets[key]; f != nil {
+			resp.Total = total
+			f.Distinct = distinct
+			f.Missing = total - present
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if resp.Total == 0 {
+		return resp, nil
+	}
+
+	topSQL := `SELECT facet_key, data[facet_key] AS value, count() AS cnt
+               FROM logs FINAL
+               ARRAY JOIN ` + keyList + ` AS facet_key
+               WHERE project_id = ?` + where + ` AND mapContains(data, facet_key)
+               GROUP BY facet_key, value
+               ORDER BY facet_key, cnt DESC, value
+               LIMIT ? BY facet_key`
+	rows, err = s.chDB.QueryContext(ctx, topSQL, append(keyArgs, limit)...)
+	if err != nil {
+		zapLog.Error("clickhouse facets", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			key string
+			v   querypb.FacetValue
+		)
+		if err := rows.Scan(&key, &v.Value, &v.Count); err != nil {
+			zapLog.Error("row scan", zap.Error(err))
+			return nil, err
+		}
+		if f := facets[key]; f != nil {
+			f.Values = append(f.Values, &v)
+		}
+	}
+	return resp, rows.Err()
+}
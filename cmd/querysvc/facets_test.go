@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+func TestGetFacets(t *testing.T) {
+	ch := &fakeClickHouse{results: [][][]driver.Value{
+		{
+			{"status", int64(10), int64(10), int64(2)},
+			{"user", int64(10), int64(8), int64(3)},
+		},
+		{
+			{"status", "200", int64(7)},
+			{"status", "500", int64(3)},
+			{"user", "bob", int64(5)},
+		},
+	}}
+	s := newTestServer(ch, "user", "status")
+	resp, err := s.GetFacets(context.Background(), &querypb.FacetsRequest{
+		Token:      testToken(t, testUser),
+		ProjectId:  testProject,
+		Keys:       []string{"user", "status"},
+		EventNames: []string{"login"},
+		Limit:      2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// facets keep the order of the request
+	want := &querypb.FacetsResponse{Total: 10, Facets: []*querypb.Facet{
+		{Key: "user", Distinct: 3, Missing: 2, Values: []*querypb.FacetValue{{Value: "bob", Count: 5}}},
+		{Key: "status", Distinct: 2, Values: []*querypb.FacetValue{{Value: "200", Count: 7}, {Value: "500", Count: 3}}},
+	}}
+	if !proto.Equal(resp, want) {
+		t.Errorf("response = %v\nwant %v", resp, want)
+	}
+
+	// both queries join every row with the keys once
+	stats, top := ch.query(t, 0), ch.query(t, 1)
+	for _, q := range []fakeQuery{stats, top} {
+		if !strings.Contains(q.sql, "ARRAY JOIN [?, ?] AS facet_key") || !strings.Contains(q.sql, "AND event_name IN (?)") {
+			t.Errorf("sql = %s", q.sql)
+		}
+	}
+	if !strings.HasSuffix(top.sql, "LIMIT ? BY facet_key") {
+		t.Errorf("top values sql = %s", top.sql)
+	}
+	wantArgs := []interface{}{"user", "status", testProject, "login"}
+	if !reflect.DeepEqual(stats.args, wantArgs) {
+		t.Errorf("stats args = %#v, want %#v", stats.args, wantArgs)
+	}
+	if wantArgs = append(wantArgs, int32(2)); !reflect.DeepEqual(top.args, wantArgs) {
+		t.Errorf("top values args = %#v, want %#v", top.args, wantArgs)
+	}
+}
+
+// TestGetFacetsNoMatches checks that without matching events the top values
+// are not queried and every facet is still listed.
+func TestGetFacetsNoMatches(t *testing.T) {
+	ch := &fakeClickHouse{}
+	s := newTestServer(ch, "user")
+	resp, err := s.GetFacets(context.Background(), &querypb.FacetsRequest{
+		Token:     testToken(t, testUser),
+		ProjectId: testProject,
+		Keys:      []string{"user"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 0 || len(resp.Facets) != 1 || resp.Facets[0].Key != "user" || resp.Facets[0].Values == nil {
+		t.Errorf("response = %v", resp)
+	}
+	if n := len(ch.queries); n != 1 {
+		t.Errorf("%d queries sent, want 1", n)
+	}
+}
+
+func TestGetFacetsErrors(t *testing.T) {
+	s := newTestServer(&fakeClickHouse{}, "user", "status")
+	tests := []struct {
+		name string
+		req  *querypb.FacetsRequest
+	}{
+		{"no keys", &querypb.FacetsRequest{}},
+		{"too many keys", &querypb.FacetsRequest{Keys: make([]string, maxFacetKeys+1)}},
+		{"unsearchable key", &querypb.FacetsRequest{Keys: []string{"user", "email"}}},
+		{"duplicate key", &querypb.FacetsRequest{Keys: []string{"user", "user"}}},
+		{"limit", &querypb.FacetsRequest{Keys: []string{"user"}, Limit: maxFacetLimit + 1}},
+	}
+	tok := testToken(t, testUser)
+	for _, tt := range tests {
+		tt.req.Token, tt.req.ProjectId = tok, testProject
+		if _, err := s.GetFacets(context.Background(), tt.req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: err = %v, want InvalidArgument", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+const (
+	maxFilterConditions = 50
+	maxFilterValues     = 1000
+)
+
+// compileFilters turns the legacy equality map and the conditions of a
+// search into a SQL predicate over logs.data. Keys must be searchable and
+// every key and value is bound as a parameter; nothing from the request is
+// spliced into the SQL text. An empty predicate means no filtering.
+func compileFilters(searchable []string, eq map[string]string, conds []*querypb.Filter) (string, []interface{}, error) {
+	all := make([]*querypb.Filter, 0, len(eq)+len(conds))
+	keys := make([]string, 0, len(eq))
+	for k := range eq {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		all = append(all, &querypb.Filter{Key: k, Op: "=", Value: eq[k]})
+	}
+	all = append(all, conds...)
+	if len(all) > maxFilterConditions {
+		return "", nil, fmt.Errorf("%w: at most %d filter conditions", errBadRequest, maxFilterConditions)
+	}
+
+	var (
+		preds []string
+		args  []interface{}
+	)
+	for _, f := range all {
+		if !slices.Contains(searchable, f.Key) {
+			return "", nil, fmt.Errorf("%w: %q is not a searchable key of this project", errBadRequest, f.Key)
+		}
+		pred, condArgs, err := compileFilter(f)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: filter on %q: %v", errBadRequest, f.Key, err)
+		}
+		preds = append(preds, pred)
+		args = append(args, condArgs...)
+	}
+	return strings.Join(preds, " AND "), args, nil
+}
+
+func compileFilter(f *querypb.Filter) (string, []interface{}, error) {
+	switch op := strings.ToLower(f.Op); op {
+	case "=", "", "!=":
+		if op == "" {
+			op = "="
+		}
+		return "data[?] " + op + " ?", []interface{}{f.Key, f.Value}, nil
+	case "in", "not_in":
+		if len(f.Values) == 0 || len(f.Values) > maxFilterValues {
+			return "", nil, fmt.Errorf("%s needs 1 to %d values", op, maxFilterValues)
+		}
+		args := []interface{}{f.Key}
+		for _, v := range f.Values {
+			args = append(args, v)
+		}
+		in := "IN"
+		if op == "not_in" {
+			in = "NOT IN"
+		}
+		return "data[?] " + in + " (" + strings.TrimSuffix(strings.Repeat("?, ", len(f.Values)), ", ") + ")", args, nil
+	case "prefix":
+		return "startsWith(data[?], ?)", []interface{}{f.Key, f.Value}, nil
+	case "contains":
+		return "position(data[?], ?) > 0", []interface{}{f.Key, f.Value}, nil
+	case "regex":
+		// ClickHouse and Go both use RE2 syntax
+		if _, err := regexp.Compile(f.Value); err != nil {
+			return "", nil, err
+		}
+		return "match(data[?], ?)", []interface{}{f.Key, f.Value}, nil
+	case ">", ">=", "<", "<=":
+		n, err := strconv.ParseFloat(f.Value, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s needs a number, got %q", op, f.Value)
+		}
+		return "toFloat64OrNull(data[?]) " + op + " ?", []interface{}{f.Key, n}, nil
+	case "exists":
+		return "mapContains(data, ?)", []interface{}{f.Key}, nil
+	case "not_exists":
+		return "NOT mapContains(data, ?)", []interface{}{f.Key}, nil
+	default:
+		return "", nil, fmt.Errorf("unknown operator %q", f.Op)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+func TestCompileFilters(t *testing.T) {
+	searchable := []string{"status", "region", "path"}
+	tests := []struct {
+		name  string
+		eq    map[string]string
+		conds []*querypb.Filter
+		sql   string
+		args  []interface{}
+	}{
+		{"none", nil, nil, "", nil},
+		{
+			"legacy equality, sorted by key",
+			map[string]string{"status": "500", "region": "eu"}, nil,
+			"data[?] = ? AND data[?] = ?",
+			[]interface{}{"region", "eu", "status", "500"},
+		},
+		{
+			"empty op is equality",
+			nil, []*querypb.Filter{{Key: "status", Value: "500"}},
+			"data[?] = ?", []interface{}{"status", "500"},
+		},
+		{
+			"equal",
+			nil, []*querypb.Filter{{Key: "status", Op: "=", Value: "500"}},
+			"data[?] = ?", []interface{}{"status", "500"},
+		},
+		{
+			"not equal",
+			nil, []*querypb.Filter{{Key: "status", Op: "!=", Value: "500"}},
+			"data[?] != ?", []interface{}{"status", "500"},
+		},
+		{
+			"in",
+			nil, []*querypb.Filter{{Key: "region", Op: "in", Values: []string{"eu", "us"}}},
+			"data[?] IN (?, ?)", []interface{}{"region", "eu", "us"},
+		},
+		{
+			"not_in, case-insensitive op",
+			nil, []*querypb.Filter{{Key: "region", Op: "NOT_IN", Values: []string{"eu"}}},
+			"data[?] NOT IN (?)", []interface{}{"region", "eu"},
+		},
+		{
+			"prefix",
+			nil, []*querypb.Filter{{Key: "path", Op: "prefix", Value: "/api"}},
+			"startsWith(data[?], ?)", []interface{}{"path", "/api"},
+		},
+		{
+			"contains",
+			nil, []*querypb.Filter{{Key: "path", Op: "contains", Value: "users"}},
+			"position(data[?], ?) > 0", []interface{}{"path", "users"},
+		},
+		{
+			"regex",
+			nil, []*querypb.Filter{{Key: "path", Op: "regex", Value: `^/api/v\d+`}},
+			"match(data[?], ?)", []interface{}{"path", `^/api/v\d+`},
+		},
+		{
+			"greater",
+			nil, []*querypb.Filter{{Key: "status", Op: ">", Value: "499"}},
+			"toFloat64OrNull(data[?]) > ?", []interface{}{"status", 499.0},
+		},
+		{
+			"greater or equal",
+			nil, []*querypb.Filter{{Key: "status", Op: ">=", Value: "500"}},
+			"toFloat64OrNull(data[?]) >= ?", []interface{}{"status", 500.0},
+		},
+		{
+			"less",
+			nil, []*querypb.Filter{{Key: "status", Op: "<", Value: "1.5"}},
+			"toFloat64OrNull(data[?]) < ?", []interface{}{"status", 1.5},
+		},
+		{
+			"less or equal",
+			nil, []*querypb.Filter{{Key: "status", Op: "<=", Value: "-2"}},
+			"toFloat64OrNull(data[?]) <= ?", []interface{}{"status", -2.0},
+		},
+		{
+			"exists",
+			nil, []*querypb.Filter{{Key: "region", Op: "exists"}},
+			"mapContains(data, ?)", []interface{}{"region"},
+		},
+		{
+			"not_exists",
+			nil, []*querypb.Filter{{Key: "region", Op: "not_exists"}},
+			"NOT mapContains(data, ?)", []interface{}{"region"},
+		},
+		{
+			"legacy map before conditions",
+			map[string]string{"status": "500"},
+			[]*querypb.Filter{{Key: "region", Op: "exists"}},
+			"data[?] = ? AND mapContains(data, ?)", []interface{}{"status", "500", "region"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := compileFilters(searchable, tt.eq, tt.conds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.sql {
+				t.Errorf("sql = %q, want %q", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+			if n := strings.Count(sql, "?"); n != len(args) {
+				t.Errorf("%d placeholders but %d args", n, len(args))
+			}
+		})
+	}
+}
+
+func TestCompileFiltersErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		eq    map[string]string
+		conds []*querypb.Filter
+	}{
+		{"legacy key not searchable", map[string]string{"secret": "x"}, nil},
+		{"condition key not searchable", nil, []*querypb.Filter{{Key: "secret", Op: "exists"}}},
+		{"unknown operator", nil, []*querypb.Filter{{Key: "status", Op: "like", Value: "5%"}}},
+		{"operator spliced into sql", nil, []*querypb.Filter{{Key: "status", Op: "= 1 OR 1 =", Value: "1"}}},
+		{"non-numeric comparison", nil, []*querypb.Filter{{Key: "status", Op: ">", Value: "abc"}}},
+		{"invalid regex", nil, []*querypb.Filter{{Key: "status", Op: "regex", Value: "("}}},
+		{"empty in", nil, []*querypb.Filter{{Key: "status", Op: "in"}}},
+		{"too many in values", nil, []*querypb.Filter{{Key: "status", Op: "in", Values: make([]string, maxFilterValues+1)}}},
+		{"too many conditions", nil, func() []*querypb.Filter {
+			conds := make([]*querypb.Filter, maxFilterConditions+1)
+			for i := range conds {
+				conds[i] = &querypb.Filter{Key: "status", Op: "exists"}
+			}
+			return conds
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := compileFilters([]string{"status"}, tt.eq, tt.conds)
+			if !errors.Is(err, errBadRequest) {
+				t.Fatalf("err = %v, want errBadRequest", err)
+			}
+		})
+	}
+}
+
+// TestCompileFiltersHostileInput checks that keys and values from the request
+// only ever reach the args, never the SQL text.
+func TestCompileFiltersHostileInput(t *testing.T) {
+	hostile := []string{
+		"x') OR 1=1 --",
+		"'; DROP TABLE logs; --",
+		"a` ] ) UNION SELECT * FROM system.users --",
+		"?",
+		`\' OR ''='`,
+	}
+	ops := []string{"=", "!=", "in", "not_in", "prefix", "contains", "exists", "not_exists"}
+	for _, key := range hostile {
+		for _, value := range hostile {
+			for _, op := range ops {
+				f := &querypb.Filter{Key: key, Op: op, Value: value, Values: []string{value, value}}
+				sql, args, err := compileFilters(hostile, map[string]string{key: value}, []*querypb.Filter{f})
+				if err != nil {
+					t.Fatalf("key %q op %s: %v", key, op, err)
+				}
+				for _, h := range []string{key, value} {
+					if h != "?" && strings.Contains(sql, h) {
+						t.Errorf("key %q op %s: %q reached the sql: %s", key, op, h, sql)
+					}
+				}
+				if !hasArg(args, key) || (op != "exists" && op != "not_exists" && !hasArg(args, value)) {
+					t.Errorf("key %q op %s: args %#v miss the input", key, op, args)
+				}
+				if n := strings.Count(sql, "?"); n != len(args) {
+					t.Errorf("key %q op %s: %d placeholders but %d args", key, op, n, len(args))
+				}
+			}
+		}
+	}
+}
+
+func hasArg(args []interface{}, s string) bool {
+	for _, a := range args {
+		if a == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+const (
+	defaultHistogramWindow = 24 * time.Hour
+	targetHistogramBuckets = 60
+	maxHistogramBuckets    = 1000
+	defaultSplitLimit      = 10
+	maxSplitLimit          = 100
+)
+
+// histogramSteps are the bucket widths picked when no interval is given.
+var histogramSteps = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 10 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 7 * 24 * time.Hour,
+}
+
+// GetHistogram counts events per time bucket
+func (s *queryServer) GetHistogram(ctx context.Context, req *querypb.HistogramRequest) (*querypb.HistogramResponse, error) {
+	ctx, err := s.authorize(ctx, req.Token, req.ProjectId)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	resp, err := s.getHistogram(ctx, req)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	return resp, nil
+}
+
+func (s *queryServer) getHistogram(ctx context.Context, req *querypb.HistogramRequest) (*querypb.HistogramResponse, error) {
+	if req.ProjectId == "" {
+		return nil, fmt.Errorf("%w: project_id is required", errBadRequest)
+	}
+	now := time.Now()
+	window, err := parseTimeRange(req.From, req.To, now)
+	if err != nil {
+		return nil, err
+	}
+	if window, err = window.bounded(now, defaultHistogramWindow); err != nil {
+		return nil, err
+	}
+	step, err := histogramInterval(req.Interval, window)
+	if err != nil {
+		return nil, err
+	}
+	// buckets are aligned to multiples of the interval since the epoch
+	origin := window.from.UnixNano() - window.from.UnixNano()%int64(step)
+	n := (window.to.UnixNano() - origin + int64(step) - 1) / int64(step)
+	if n > maxHistogramBuckets {
+		return nil, fmt.Errorf("%w: interval %s gives more than %d buckets", errBadRequest, step, maxHistogramBuckets)
+	}
+	splitLimit := req.SplitLimit
+	if splitLimit <= 0 {
+		splitLimit = defaultSplitLimit
+	}
+	if splitLimit > maxSplitLimit {
+		return nil, fmt.Errorf("%w: split_limit must be at most %d", errBadRequest, maxSplitLimit)
+	}
+
+	where, whereArgs, err := s.eventPredicate(ctx, req.ProjectId, window, req.EventNames, req.Filters, req.Conditions)
+	if err != nil {
+		return nil, err
+	}
+	splitExpr, splitArgs, err := s.splitColumn(ctx, req.ProjectId, req.SplitBy)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlStr := `SELECT intDiv(toUnixTimestamp64Nano(timestamp) - ?, ?) AS bucket, `
+	args := []interface{}{origin, int64(step)}
+	if splitExpr == "" {
+		sqlStr += `1 AS in_top, '' AS series`
+	} else {
+		// values beyond the top split_limit are folded into one series
+		sqlStr += splitExpr + ` AS split_value,
+                  split_value IN (SELECT ` + splitExpr + ` AS v FROM logs FINAL
+                                   WHERE project_id = ?` + where + `
+                                   GROUP BY v ORDER BY count() DESC, v LIMIT ?) AS in_top,
+                  if(in_top, split_value, '') AS series`
+		args = append(args, splitArgs...)
+		args = append(args, splitArgs...)
+		args = append(args, req.ProjectId)
+		args = append(args, whereArgs...)
+		args = append(args, splitLimit)
+	}
+	sqlStr += `, count() AS cnt
+               FROM logs FINAL
+               WHERE project_id = ?` + where + `
+               GROUP BY bucket, in_top, series`
+	args = append(args, req.ProjectId)
+	args = append(args, whereArgs...)
+
+	rows, err := s.chDB.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		zapLog.Error("clickhouse histogram", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	type seriesKey struct {
+		value string
+		other bool
+	}
+	series := map[seriesKey]*querypb.HistogramSeries{}
+	for rows.Next() {
+		var (
+			bucket, cnt int64
+			inTop       uint8
+			value       string
+		)
+		if err := rows.Scan(&bucket, &inTop, &value, &cnt); err != nil {
+			zapLog.Error("row scan", zap.Error(err))
+			return nil, err
+		}
+		if bucket < 0 || bucket >= n {
+			continue
+		}
+		key := seriesKey{value: value, other: inTop == 0}
+		hs, ok := series[key]
+		if !ok {
+			hs = &querypb.HistogramSeries{Value: key.value, Other: key.other, Counts: make([]int64, n)}
+			series[key] = hs
+		}
+		hs.Counts[bucket] += cnt
+		hs.Total += cnt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	resp := &querypb.HistogramResponse{Interval: int64(step), Buckets: make([]int64, n)}
+	for i := range resp.Buckets {
+		resp.Buckets[i] = origin + int64(i)*int64(step)
+	}
+	for _, hs := range series {
+		resp.Series = append(resp.Series, hs)
+	}
+	sort.Slice(resp.Series, func(i, j int) bool {
+		a, b := resp.Series[i], resp.Series[j]
+		if a.Other != b.Other {
+			return b.Other
+		}
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Value < b.Value
+	})
+	return resp, nil
+}
+
+// histogramInterval parses the requested bucket width, or picks one giving
+// about targetHistogramBuckets over the window.
+func histogramInterval(interval string, window timeRange) (time.Duration, error) {
+	if interval != "" {
+		d, err := parseDuration(interval)
+		if err != nil {
+			return 0, fmt.Errorf("%w: interval: %v", errBadRequest, err)
+		}
+		if d < time.Second || d%time.Second != 0 {
+			return 0, fmt.Errorf("%w: interval must be a whole number of seconds", errBadRequest)
+		}
+		return d, nil
+	}
+	span := window.to.Sub(window.from)
+	for _, step := range histogramSteps {
+		if span/step <= targetHistogramBuckets {
+			return step, nil
+		}
+	}
+	return (span / targetHistogramBuckets).Truncate(24*time.Hour) + 24*time.Hour, nil
+}
+
+// splitColumn maps the split_by option onto a logs column expression; an
+// empty expression means no split.
+func (s *queryServer) splitColumn(ctx context.Context, projectID, splitBy string) (string, []interface{}, error) {
+	switch {
+	case splitBy == "":
+		return "", nil, nil
+	case splitBy == "event_name":
+		return "event_name", nil, nil
+	case strings.HasPrefix(splitBy, "data."):
+		key := strings.TrimPrefix(splitBy, "data.")
+		searchable, err := s.projects.searchableKeys(ctx, projectID)
+		if err != nil {
+			return "", nil, err
+		}
+		if !slices.Contains(searchable, key) {
+			return "", nil, fmt.Errorf("%w: %q is not a searchable key of this project", errBadRequest, key)
+		}
+		return "data[?]", []interface{}{key}, nil
+	}
+	return "", nil, fmt.Errorf("%w: split_by must be event_name or data.<key>", errBadRequest)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+// histogramFrom and histogramTo are the window of the histogram tests, 00:05
+// to 01:00, which 10m buckets cover from 00:00.
+var (
+	histogramFrom = time.Date(2026, 10, 17, 0, 5, 0, 0, time.UTC)
+	histogramTo   = time.Date(2026, 10, 17, 1, 0, 0, 0, time.UTC)
+)
+
+func TestHistogramInterval(t *testing.T) {
+	day := 24 * time.Hour
+	window := func(span time.Duration) timeRange {
+		return timeRange{from: histogramTo.Add(-span), to: histogramTo}
+	}
+	tests := []struct {
+		interval string
+		span     time.Duration
+		want     time.Duration
+	}{
+		{"5m", time.Hour, 5 * time.Minute},
+		{"2d", time.Hour, 2 * day},
+		// picked for about 60 buckets
+		{"", time.Minute, time.Second},
+		{"", time.Hour, time.Minute},
+		{"", day, 30 * time.Minute},
+		{"", 365 * day, 7 * day},
+		{"", 3650 * day, 61 * day},
+	}
+	for _, tt := range tests {
+		got, err := histogramInterval(tt.interval, window(tt.span))
+		if err != nil || got != tt.want {
+			t.Errorf("histogramInterval(%q, %s) = %s, %v, want %s", tt.interval, tt.span, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"1500ms", "500ms", "0s", "soon"} {
+		if _, err := histogramInterval(bad, window(time.Hour)); err == nil {
+			t.Errorf("histogramInterval(%q) accepted", bad)
+		}
+	}
+}
+
+func TestGetHistogram(t *testing.T) {
+	ch := &fakeClickHouse{results: [][][]driver.Value{{
+		{int64(0), int64(1), "", int64(3)},
+		{int64(5), int64(1), "", int64(2)},
+		// rows outside the buckets are dropped
+		{int64(6), int64(1), "", int64(9)},
+		{int64(-1), int64(1), "", int64(9)},
+	}}}
+	s := newTestServer(ch)
+	resp, err := s.GetHistogram(context.Background(), &querypb.HistogramRequest{
+		Token:     testToken(t, testUser),
+		ProjectId: testProject,
+		From:      histogramFrom.Format(time.RFC3339),
+		To:        histogramTo.Format(time.RFC3339),
+		Interval:  "10m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC).UnixNano()
+	step := int64(10 * time.Minute)
+	want := &querypb.HistogramResponse{
+		Interval: step,
+		Buckets:  []int64{origin, origin + step, origin + 2*step, origin + 3*step, origin + 4*step, origin + 5*step},
+		Series:   []*querypb.HistogramSeries{{Counts: []int64{3, 0, 0, 0, 0, 2}, Total: 5}},
+	}
+	if !proto.Equal(resp, want) {
+		t.Errorf("response = %v\nwant %v", resp, want)
+	}
+
+	q := ch.query(t, 0)
+	if !strings.HasPrefix(q.sql, "SELECT intDiv(toUnixTimestamp64Nano(timestamp) - ?, ?) AS bucket, 1 AS in_top, '' AS series") ||
+		!strings.HasSuffix(q.sql, "GROUP BY bucket, in_top, series") {
+		t.Errorf("sql = %s", q.sql)
+	}
+	wantArgs := []interface{}{origin, step, testProject, 20261017, histogramFrom.UnixNano(), 20261017, histogramTo.UnixNano()}
+	if !reflect.DeepEqual(q.args, wantArgs) {
+		t.Errorf("args = %#v\nwant %#v", q.args, wantArgs)
+	}
+}
+
+// TestGetHistogramSplit checks that the top values get a series each and the
+// rest one "other" series, ordered by total.
+func TestGetHistogramSplit(t *testing.T) {
+	ch := &fakeClickHouse{results: [][][]driver.Value{{
+		{int64(0), int64(1), "500", int64(1)},
+		{int64(0), int64(1), "200", int64(4)},
+		{int64(1), int64(0), "", int64(7)},
+		{int64(1), int64(1), "500", int64(3)},
+	}}}
+	s := newTestServer(ch, "status")
+	resp, err := s.GetHistogram(context.Background(), &querypb.HistogramRequest{
+		Token:      testToken(t, testUser),
+		ProjectId:  testProject,
+		From:       histogramFrom.Format(time.RFC3339),
+		To:         histogramTo.Format(time.RFC3339),
+		Interval:   "30m",
+		EventNames: []string{"request"},
+		SplitBy:    "data.status",
+		SplitLimit: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*querypb.HistogramSeries{
+		{Value: "200", Counts: []int64{4, 0}, Total: 4},
+		{Value: "500", Counts: []int64{1, 3}, Total: 4},
+		{Other: true, Counts: []int64{0, 7}, Total: 7},
+	}
+	if len(resp.Series) != len(want) {
+		t.Fatalf("series = %v", resp.Series)
+	}
+	for i := range want {
+		if !proto.Equal(resp.Series[i], want[i]) {
+			t.Errorf("series %d = %v, want %v", i, resp.Series[i], want[i])
+		}
+	}
+
+	// the split value is bound twice, and the top values are ranked over
+	// the same events as the counts
+	q := ch.query(t, 0)
+	if !strings.Contains(q.sql, "data[?] AS split_value") || !strings.Contains(q.sql, "GROUP BY v ORDER BY count() DESC, v LIMIT ?") {
+		t.Errorf("sql = %s", q.sql)
+	}
+	origin := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC).UnixNano()
+	where := []interface{}{20261017, histogramFrom.UnixNano(), 20261017, histogramTo.UnixNano(), "request"}
+	wantArgs := []interface{}{origin, int64(30 * time.Minute), "status", "status", testProject}
+	wantArgs = append(wantArgs, where...)
+	wantArgs = append(wantArgs, int32(2), testProject)
+	wantArgs = append(wantArgs, where...)
+	if !reflect.DeepEqual(q.args, wantArgs) {
+		t.Errorf("args = %#v\nwant %#v", q.args, wantArgs)
+	}
+}
+
+func TestGetHistogramErrors(t *testing.T) {
+	s := newTestServer(&fakeClickHouse{}, "status")
+	tok := testToken(t, testUser)
+	from, to := histogramFrom.Format(time.RFC3339), histogramTo.Format(time.RFC3339)
+	tests := []struct {
+		name string
+		req  *querypb.HistogramRequest
+	}{
+		{"too many buckets", &querypb.HistogramRequest{From: from, To: to, Interval: "1s"}},
+		{"empty window", &querypb.HistogramRequest{From: to, To: from}},
+		{"split limit", &querypb.HistogramRequest{From: from, To: to, SplitBy: "event_name", SplitLimit: maxSplitLimit + 1}},
+		{"unsearchable split", &querypb.HistogramRequest{From: from, To: to, SplitBy: "data.user"}},
+		{"unknown split", &querypb.HistogramRequest{From: from, To: to, SplitBy: "host"}},
+	}
+	for _, tt := range tests {
+		tt.req.Token, tt.req.ProjectId = tok, testProject
+		if _, err := s.GetHistogram(context.Background(), tt.req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: err = %v, want InvalidArgument", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/status"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+// The HTTP API is a JSON front-end to queryServer: request bodies decode into
+// the QueryService messages and responses are the messages it returns.
+
+// bearerToken reads the JWT from Authorization: Bearer <token>.
+func bearerToken(r *http.Request) string {
+	hdr := r.Header.Get("Authorization")
+	if !strings.HasPrefix(hdr, "Bearer ") {
+		return ""
+	}
+	return strings.TrimPrefix(hdr, "Bearer ")
+}
+
+func (s *queryServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+	var req querypb.SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		zapLog.Warn("search decode", zap.Error(err))
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if tok := bearerToken(r); tok != "" {
+		req.Token = tok
+	}
+	resp, err := s.SearchEvents(r.Context(), &req)
+	writeResponse(w, resp, err)
+}
+
+func (s *queryServer) handleDetail(w http.ResponseWriter, r *http.Request) {
+	var req querypb.EventDetailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		zapLog.Warn("detail decode", zap.Error(err))
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if tok := bearerToken(r); tok != "" {
+		req.Token = tok
+	}
+	resp, err := s.GetEventDetail(r.Context(), &req)
+	writeResponse(w, resp, err)
+}
+
+func (s *queryServer) handleHistogram(w http.ResponseWriter, r *http.Request) {
+	var req querypb.HistogramRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		zapLog.Warn("histogram decode", zap.Error(err))
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if tok := bearerToken(r); tok != "" {
+		req.Token = tok
+	}
+	resp, err := s.GetHistogram(r.Context(), &req)
+	writeResponse(w, resp, err)
+}
+
+func (s *queryServer) handleFacets(w http.ResponseWriter, r *http.Request) {
+	var req querypb.FacetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		zapLog.Warn("facets decode", zap.Error(err))
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if tok := bearerToken(r); tok != "" {
+		req.Token = tok
+	}
+	resp, err := s.GetFacets(r.Context(), &req)
+	writeResponse(w, resp, err)
+}
+
+func (s *queryServer) handleAggregate(w http.ResponseWriter, r *http.Request) {
+	var req querypb.AggregateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		zapLog.Warn("aggregate decode", zap.Error(err))
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if tok := bearerToken(r); tok != "" {
+		req.Token = tok
+	}
+	resp, err := s.Aggregate(r.Context(), &req)
+	writeResponse(w, resp, err)
+}
+
+func (s *queryServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var req querypb.QueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		zapLog.Warn("query decode", zap.Error(err))
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if tok := bearerToken(r); tok != "" {
+		req.Token = tok
+	}
+	resp, err := s.RunQuery(r.Context(), &req)
+	writeResponse(w, resp, err)
+}
+
+func (s *queryServer) handleListProjects(w http.ResponseWriter, r *http.Request) {
+	req := querypb.ListProjectsRequest{
+		Token:           bearerToken(r),
+		IncludeArchived: r.URL.Query().Get("include_archived") == "true",
+	}
+	resp, err := s.ListProjects(r.Context(), &req)
+	writeResponse(w, resp, err)
+}
+
+func (s *queryServer) handleCreateProject(w http.ResponseWriter, r *http.Request) {
+	var req querypb.CreateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		zapLog.Warn("create project decode", zap.Error(err))
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	req.Token = bearerToken(r)
+	resp, err := s.CreateProject(r.Context(), &req)
+	writeResponse(w, resp, err)
+}
+
+func (s *queryServer) handleUpdateProject(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeUpdateProject(r)
+	if err != nil {
+		zapLog.Warn("update project decode", zap.Error(err))
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	resp, err := s.UpdateProject(r.Context(), req)
+	writeResponse(w, resp, err)
+}
+
+// decodeUpdateProject reads a PATCH of a project: fields left out keep their
+// value, and a searchable_keys field replaces the keys, even with [].
+func decodeUpdateProject(r *http.Request) (*querypb.UpdateProjectRequest, error) {
+	var (
+		req    querypb.UpdateProjectRequest
+		fields map[string]json.RawMessage
+	)
+	body, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err == nil {
+		err = json.Unmarshal(body, &fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := fields["searchable_keys"]; ok {
+		req.UpdateSearchableKeys = true
+	}
+	req.Token = bearerToken(r)
+	req.ProjectId = r.PathValue("id")
+	return &req, nil
+}
+
+func (s *queryServer) handleArchiveProject(w http.ResponseWriter, r *http.Request) {
+	req := querypb.ArchiveProjectRequest{
+		Token:     bearerToken(r),
+		ProjectId: r.PathValue("id"),
+	}
+	resp, err := s.ArchiveProject(r.Context(), &req)
+	writeResponse(w, resp, err)
+}
+
+// writeResponse writes a QueryService result as JSON, or its error status.
+func writeResponse(w http.ResponseWriter, resp interface{}, err error) {
+	if err != nil {
+		http.Error(w, status.Convert(err).Message(), httpStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
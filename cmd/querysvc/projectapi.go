@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+const (
+	maxProjectNameLen = 200
+	maxProjectTTLDays = 3650
+	maxSearchableKeys = 100
+	maxKeyLen         = 128
+)
+
+// projectColumns are scanned by scanProject, for a query joining projects p
+// with the caller's project_members row m.
+const projectColumns = `p.id::STRING, p.name, p.ttl_days, p.searchable_keys, m.role,
+       p.archived_at IS NOT NULL, p.created_at, p.api_key`
+
+func scanProject(row pgx.Row) (*querypb.Project, error) {
+	var (
+		p       querypb.Project
+		created time.Time
+	)
+	err := row.Scan(&p.Id, &p.Name, &p.TtlDays, &p.SearchableKeys, &p.Role, &p.Archived, &created, &p.ApiKey)
+	if err != nil {
+		return nil, err
+	}
+	p.CreatedAt = created.UnixNano()
+	if p.Role != "owner" {
+		p.ApiKey = ""
+	}
+	return &p, nil
+}
+
+// ListProjects returns the projects the caller is a member of.
+func (s *queryServer) ListProjects(ctx context.Context, req *querypb.ListProjectsRequest) (*querypb.ListProjectsResponse, error) {
+	c, err := s.authenticate(ctx, req.Token)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	rows, err := s.projects.db.Query(ctx,
+		`SELECT `+projectColumns+`
+		   FROM project_members m JOIN projects p ON p.id = m.project_id
+		  WHERE m.user_id = $1::UUID AND ($2 OR p.archived_at IS NULL)
+		  ORDER BY p.name, p.id`,
+		c.UserID, req.IncludeArchived,
+	)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	defer rows.Close()
+	resp := &querypb.ListProjectsResponse{Projects: []*querypb.Project{}}
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, grpcQueryError(err)
+		}
+		resp.Projects = append(resp.Projects, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, grpcQueryError(err)
+	}
+	return resp, nil
+}
+
+// CreateProject creates a project with a fresh API key, owned by the caller.
+func (s *queryServer) CreateProject(ctx context.Context, req *querypb.CreateProjectRequest) (*querypb.Project, error) {
+	c, err := s.authenticate(ctx, req.Token)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	if err := validateProject(&req.Name, &req.TtlDays, req.SearchableKeys); err != nil {
+		return nil, grpcQueryError(err)
+	}
+	apiKey, err := newAPIKey()
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	keys := req.SearchableKeys
+	if keys == nil {
+		keys = []string{}
+	}
+
+	var projectID string
+	err = pgx.BeginFunc(ctx, s.projects.db, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx,
+			`INSERT INTO projects (name, searchable_keys, api_key, ttl_days)
+			 VALUES ($1, $2, $3, $4) RETURNING id::STRING`,
+			req.Name, keys, apiKey, req.TtlDays,
+		).Scan(&projectID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx,
+			`INSERT INTO project_members (user_id, project_id, role) VALUES ($1::UUID, $2::UUID, 'owner')`,
+			c.UserID, projectID,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	p, err := s.loadProject(ctx, c.UserID, projectID)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	return p, nil
+}
+
+// UpdateProject changes the name, TTL or searchable keys of a project.
+func (s *queryServer) UpdateProject(ctx context.Context, req *querypb.UpdateProjectRequest) (*querypb.Project, error) {
+	userID, err := s.authorizeOwner(ctx, req.Token, req.ProjectId)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	if err := validateProject(req.Name, req.TtlDays, req.SearchableKeys); err != nil {
+		return nil, grpcQueryError(err)
+	}
+	var keys []string // NULL keeps the current keys
+	if req.UpdateSearchableKeys || len(req.SearchableKeys) > 0 {
+		keys = req.SearchableKeys
+		if keys == nil {
+			keys = []string{}
+		}
+	}
+	_, err = s.projects.db.Exec(ctx,
+		`UPDATE projects
+		    SET name = COALESCE($2, name),
+		        ttl_days = COALESCE($3, ttl_days),
+		        searchable_keys = COALESCE($4, searchable_keys)
+		  WHERE id = $1::UUID`,
+		req.ProjectId, req.Name, req.TtlDays, keys,
+	)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	s.projects.invalidate(req.ProjectId)
+	p, err := s.loadProject(ctx, userID, req.ProjectId)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	return p, nil
+}
+
+// ArchiveProject marks a project archived; archiving twice is a no-op.
+func (s *queryServer) ArchiveProject(ctx context.Context, req *querypb.ArchiveProjectRequest) (*querypb.Project, error) {
+	userID, err := s.authorizeOwner(ctx, req.Token, req.ProjectId)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	_, err = s.projects.db.Exec(ctx,
+		`UPDATE projects SET archived_at = COALESCE(archived_at, now()) WHERE id = $1::UUID`,
+		req.ProjectId,
+	)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	s.projects.invalidate(req.ProjectId)
+	p, err := s.loadProject(ctx, userID, req.ProjectId)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	return p, nil
+}
+
+// authorizeOwner authenticates the caller and checks that they own
+// projectID. Unlike membership for reads, ownership is not cached.
+func (s *queryServer) authorizeOwner(ctx context.Context, token, projectID string) (string, error) {
+	c, err := s.authenticate(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if projectID == "" {
+		return "", fmt.Errorf("%w: project_id is required", errBadRequest)
+	}
+	if err := checkProjectID(projectID); err != nil {
+		return "", err
+	}
+	var role string
+	err = s.projects.db.QueryRow(ctx,
+		`SELECT role FROM project_members WHERE user_id = $1::UUID AND project_id = $2::UUID`,
+		c.UserID, projectID,
+	).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", errForbidden
+	}
+	if err != nil {
+		return "", err
+	}
+	if role != "owner" {
+		return "", fmt.Errorf("%w: only project owners may change it", errForbidden)
+	}
+	return c.UserID, nil
+}
+
+func (s *queryServer) loadProject(ctx context.Context, userID, projectID string) (*querypb.Project, error) {
+	p, err := scanProject(s.projects.db.QueryRow(ctx,
+		`SELECT `+projectColumns+`
+		   FROM project_members m JOIN projects p ON p.id = m.project_id
+		  WHERE m.user_id = $1::UUID AND p.id = $2::UUID`,
+		userID, projectID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errNotFound
+	}
+	return p, err
+}
+
+// validateProject checks the project fields that are set.
+func validateProject(name *string, ttlDays *int32, keys []string) error {
+	if name != nil && (*name == "" || len(*name) > maxProjectNameLen) {
+		return fmt.Errorf("%w: name must be 1 to %d bytes", errBadRequest, maxProjectNameLen)
+	}
+	if ttlDays != nil && (*ttlDays < 1 || *ttlDays > maxProjectTTLDays) {
+		return fmt.Errorf("%w: ttl_days must be between 1 and %d", errBadRequest, maxProjectTTLDays)
+	}
+	if len(keys) > maxSearchableKeys {
+		return fmt.Errorf("%w: at most %d searchable keys", errBadRequest, maxSearchableKeys)
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if k == "" || len(k) > maxKeyLen {
+			return fmt.Errorf("%w: searchable keys must be 1 to %d bytes", errBadRequest, maxKeyLen)
+		}
+		if seen[k] {
+			return fmt.Errorf("%w: duplicate searchable key %q", errBadRequest, k)
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+// newAPIKey returns a random ingestion key for a new project.
+func newAPIKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+func TestValidateProject(t *testing.T) {
+	str := func(s string) *string { return &s }
+	days := func(n int32) *int32 { return &n }
+	tests := []struct {
+		name    string
+		pname   *string
+		ttlDays *int32
+		keys    []string
+		ok      bool
+	}{
+		{"all set", str("shop"), days(30), []string{"user", "status"}, true},
+		{"nothing set", nil, nil, nil, true},
+		{"empty name", str(""), nil, nil, false},
+		{"long name", str(strings.Repeat("n", maxProjectNameLen+1)), nil, nil, false},
+		{"zero ttl", nil, days(0), nil, false},
+		{"ttl too long", nil, days(maxProjectTTLDays + 1), nil, false},
+		{"empty key", nil, nil, []string{""}, false},
+		{"long key", nil, nil, []string{strings.Repeat("k", maxKeyLen+1)}, false},
+		{"duplicate key", nil, nil, []string{"user", "user"}, false},
+		{"too many keys", nil, nil, make([]string, maxSearchableKeys+1), false},
+	}
+	for _, tt := range tests {
+		err := validateProject(tt.pname, tt.ttlDays, tt.keys)
+		if (err == nil) != tt.ok || (err != nil && !errors.Is(err, errBadRequest)) {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+	}
+}
+
+// fakeRow scans fixed values, like a pgx.Row.
+type fakeRow []interface{}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r[i]))
+	}
+	return nil
+}
+
+func TestScanProject(t *testing.T) {
+	created := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	row := func(role string) fakeRow {
+		return fakeRow{testProject, "shop", int32(30), []string{"user"}, role, false, created, "secret-key"}
+	}
+	p, err := scanProject(row("owner"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Id != testProject || p.Name != "shop" || p.TtlDays != 30 || p.CreatedAt != created.UnixNano() || p.ApiKey != "secret-key" {
+		t.Errorf("owner sees %v", p)
+	}
+	// only owners see the ingestion key
+	if p, _ := scanProject(row("member")); p.ApiKey != "" {
+		t.Errorf("member sees API key %q", p.ApiKey)
+	}
+}
+
+func TestNewAPIKey(t *testing.T) {
+	a, err := newAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := newAPIKey()
+	if len(a) != 32 || a == b {
+		t.Errorf("keys %q and %q, want two distinct 32 character keys", a, b)
+	}
+}
+
+func TestDecodeUpdateProject(t *testing.T) {
+	tests := []struct {
+		body     string
+		wantKeys bool
+	}{
+		{`{"name":"shop"}`, false},
+		{`{"searchable_keys":[]}`, true},
+		{`{"searchable_keys":["user"]}`, true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPatch, "/v1/projects/"+testProject, strings.NewReader(tt.body))
+		r.SetPathValue("id", testProject)
+		r.Header.Set("Authorization", "Bearer tok")
+		req, err := decodeUpdateProject(r)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.body, err)
+		}
+		if req.UpdateSearchableKeys != tt.wantKeys || req.ProjectId != testProject || req.Token != "tok" {
+			t.Errorf("%s: request = %v", tt.body, req)
+		}
+	}
+	if req, _ := decodeUpdateProject(httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"name":"shop"}`))); req.Name == nil || *req.Name != "shop" || req.TtlDays != nil {
+		t.Errorf("name only: request = %v", req)
+	}
+	if _, err := decodeUpdateProject(httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"name":`))); err == nil {
+		t.Error("malformed body decoded")
+	}
+}
+
+// TestAuthorizeOwner covers the checks made before ownership is looked up.
+func TestAuthorizeOwner(t *testing.T) {
+	s := newTestServer(&fakeClickHouse{})
+	tests := []struct {
+		name, token, projectID string
+		want                   error
+	}{
+		{"no token", "", testProject, errUnauthenticated},
+		{"no project", testToken(t, testUser), "", errBadRequest},
+		{"project id not a UUID", testToken(t, testUser), "shop", errBadRequest},
+	}
+	for _, tt := range tests {
+		if _, err := s.authorizeOwner(context.Background(), tt.token, tt.projectID); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	_, err := s.UpdateProject(context.Background(), &querypb.UpdateProjectRequest{ProjectId: testProject})
+	if httpStatus(err) != http.StatusUnauthorized {
+		t.Errorf("update without a token: %v", err)
+	}
+}
+
+// TestInvalidateProject checks that a change to a project drops its cached
+// settings and memberships, and only those.
+func TestInvalidateProject(t *testing.T) {
+	const otherProject = "2d8f6a1b-5c4e-4f3a-9b2c-1a0e9d8c7b6a"
+	s := newTestServer(&fakeClickHouse{}, "user")
+	expires := time.Now().Add(time.Hour)
+	s.projects.entries[otherProject] = projectEntry{expires: expires}
+	s.projects.members[membershipKey{testUser, otherProject}] = membershipEntry{member: true, expires: expires}
+
+	s.projects.invalidate(testProject)
+	if _, ok := s.projects.entries[testProject]; ok {
+		t.Error("settings still cached")
+	}
+	if _, ok := s.projects.members[membershipKey{testUser, testProject}]; ok {
+		t.Error("membership still cached")
+	}
+	if len(s.projects.entries) != 1 || len(s.projects.members) != 1 {
+		t.Errorf("other project dropped: %d settings, %d memberships cached", len(s.projects.entries), len(s.projects.members))
+	}
+}
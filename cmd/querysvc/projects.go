@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const maxCachedMemberships = 100000
+
+// projectCatalog reads project settings from CockroachDB, caching them
+// briefly since every search needs them.
+type projectCatalog struct {
+	db  *pgxpool.Pool
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]projectEntry
+	members map[membershipKey]membershipEntry
+}
+
+type membershipKey struct {
+	userID, projectID string
+}
+
+type membershipEntry struct {
+	member   bool
+	archived bool
+	expires  time.Time
+}
+
+type projectEntry struct {
+	searchableKeys []string
+	expires        time.Time
+}
+
+func newProjectCatalog(db *pgxpool.Pool, ttl time.Duration) *projectCatalog {
+	return &projectCatalog{
+		db:      db,
+		ttl:     ttl,
+		entries: map[string]projectEntry{},
+		members: map[membershipKey]membershipEntry{},
+	}
+}
+
+// searchableKeys returns the data keys a project allows filtering on.
+func (c *projectCatalog) searchableKeys(ctx context.Context, projectID string) ([]string, error) {
+	c.mu.Lock()
+	e, ok := c.entries[projectID]
+	c.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.searchableKeys, nil
+	}
+
+	var keys []string
+	err := c.db.QueryRow(ctx,
+		`SELECT searchable_keys FROM projects WHERE id = $1::UUID`, projectID,
+	).Scan(&keys)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[projectID] = projectEntry{searchableKeys: keys, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return keys, nil
+}
+
+// invalidate drops the cached settings and memberships of a project after
+// it changes.
+func (c *projectCatalog) invalidate(projectID string) {
+	c.mu.Lock()
+	delete(c.entries, projectID)
+	for key := range c.members {
+		if key.projectID == projectID {
+			delete(c.members, key)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// membership reports whether userID is in project_members of projectID and
+// whether that project is archived. Answers, including negative ones, are
+// cached for the catalog's ttl, so a revoked membership takes at most that
+// long to apply.
+func (c *projectCatalog) membership(ctx context.Context, userID, projectID string) (member, archived bool, err error) {
+	key := membershipKey{userID, projectID}
+	c.mu.Lock()
+	e, ok := c.members[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.member, e.archived, nil
+	}
+
+	err = c.db.QueryRow(ctx,
+		`SELECT p.archived_at IS NOT NULL
+		   FROM project_members m JOIN projects p ON p.id = m.project_id
+		  WHERE m.user_id = $1::UUID AND m.project_id = $2::UUID`,
+		userID, projectID,
+	).Scan(&archived)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		member = false
+	case err != nil:
+		return false, false, err
+	default:
+		member = true
+	}
+
+	c.mu.Lock()
+	if len(c.members) >= maxCachedMemberships {
+		// cheap bound on memory; entries are refilled on demand
+		clear(c.members)
+	}
+	c.members[key] = membershipEntry{member: member, archived: archived, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return member, archived, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+	"github.com/parishadmk/log-system-analysis/internal/querylang"
+)
+
+const maxQueryLen = 10000
+
+var columnTypeNames = map[querylang.ColumnType]string{
+	querylang.String: "string",
+	querylang.Int:    "int",
+	querylang.Float:  "float",
+	querylang.Map:    "map",
+}
+
+// RunQuery runs a query written in the query language
+func (s *queryServer) RunQuery(ctx context.Context, req *querypb.QueryRequest) (*querypb.QueryResponse, error) {
+	ctx, err := s.authorize(ctx, req.Token, req.ProjectId)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	resp, err := s.runQuery(ctx, req)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	return resp, nil
+}
+
+func (s *queryServer) runQuery(ctx context.Context, req *querypb.QueryRequest) (*querypb.QueryResponse, error) {
+	if req.ProjectId == "" {
+		return nil, fmt.Errorf("%w: project_id is required", errBadRequest)
+	}
+	if len(req.Query) > maxQueryLen {
+		return nil, fmt.Errorf("%w: query is longer than %d bytes", errBadRequest, maxQueryLen)
+	}
+	q, err := querylang.Parse(req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBadRequest, err)
+	}
+	window, err := parseTimeRange(req.From, req.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	scope, scopeArgs, err := s.eventPredicate(ctx, req.ProjectId, window, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	searchable, err := s.projects.searchableKeys(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	if searchable == nil {
+		// nil would allow any key
+		searchable = []string{}
+	}
+	compiled, err := querylang.Compile(q, querylang.Options{
+		ProjectID:    req.ProjectId,
+		Keys:         searchable,
+		Scope:        strings.TrimPrefix(scope, " AND "),
+		ScopeArgs:    scopeArgs,
+		DefaultLimit: defaultSearchLimit,
+		MaxLimit:     maxSearchLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBadRequest, err)
+	}
+
+	rows, err := s.chDB.QueryContext(ctx, compiled.SQL, compiled.Args...)
+	if err != nil {
+		zapLog.Error("clickhouse query", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &querypb.QueryResponse{Rows: []*querypb.QueryRow{}}
+	var (
+		dests   []interface{}
+		formats []func() string
+	)
+	for _, col := range compiled.Columns {
+		resp.Columns = append(resp.Columns, &querypb.QueryColumn{Name: col.Name, Type: columnTypeNames[col.Type]})
+		switch col.Type {
+		case querylang.Int:
+			v := new(int64)
+			dests = append(dests, v)
+			formats = append(formats, func() string { return strconv.FormatInt(*v, 10) })
+		case querylang.Float:
+			v := new(float64)
+			dests = append(dests, v)
+			formats = append(formats, func() string {
+				if math.IsNaN(*v) || math.IsInf(*v, 0) {
+					return ""
+				}
+				return strconv.FormatFloat(*v, 'g', -1, 64)
+			})
+		case querylang.Map:
+			keys, values := new([]string), new([]string)
+			dests = append(dests, keys, values)
+			formats = append(formats, func() string {
+				m := make(map[string]string, len(*keys))
+				for i, k := range *keys {
+					if i < len(*values) {
+						m[k] = (*values)[i]
+					}
+				}
+				b, _ := json.Marshal(m)
+				return string(b)
+			})
+		default:
+			v := new(string)
+			dests = append(dests, v)
+			formats = append(formats, func() string { return *v })
+		}
+	}
+	for rows.Next() {
+		if err := rows.Scan(dests...); err != nil {
+			zapLog.Error("row scan", zap.Error(err))
+			return nil, err
+		}
+		row := &querypb.QueryRow{Values: make([]string, len(formats))}
+		for i, format := range formats {
+			row.Values[i] = format()
+		}
+		resp.Rows = append(resp.Rows, row)
+	}
+	return resp, rows.Err()
+}
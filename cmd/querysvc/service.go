@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	ingestpb "github.com/parishadmk/log-system-analysis/internal/api/ingest"
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+var (
+	errUnauthenticated = errors.New("missing or invalid token")
+	errForbidden       = errors.New("not a member of this project")
+	errBadRequest      = errors.New("bad request")
+	errNotFound        = errors.New("not found")
+)
+
+// queryServer implements QueryService; the HTTP handlers call the same
+// methods so that both transports return identical results.
+type queryServer struct {
+	querypb.UnimplementedQueryServiceServer
+	chDB     *sql.DB
+	cassSess *gocql.Session
+	projects *projectCatalog
+	jwtKey   []byte
+}
+
+// SearchEvents queries ClickHouse for event summaries
+func (s *queryServer) SearchEvents(ctx context.Context, req *querypb.SearchRequest) (*querypb.SearchResponse, error) {
+	ctx, err := s.authorize(ctx, req.Token, req.ProjectId)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	resp, err := s.searchEvents(ctx, req)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	return resp, nil
+}
+
+// searchSort maps the sort option of a search onto ORDER BY columns.
+var searchSort = map[string]string{
+	"count":     "cnt",
+	"last_seen": "last_seen",
+	"name":      "event_name",
+}
+
+// searchOrderBy renders the ORDER BY of a search for sort, a searchSort key
+// with an optional "-" for descending order; the default is "-count". Ties
+// are broken by name.
+func searchOrderBy(sort string) (string, error) {
+	sortKey := strings.TrimPrefix(sort, "-")
+	desc := strings.HasPrefix(sort, "-")
+	if sort == "" {
+		sortKey, desc = "count", true
+	}
+	orderBy, ok := searchSort[sortKey]
+	if !ok {
+		return "", fmt.Errorf("%w: sort must be count, last_seen or name", errBadRequest)
+	}
+	if desc {
+		orderBy += " DESC"
+	}
+	if sortKey != "name" {
+		orderBy += ", event_name"
+	}
+	return orderBy, nil
+}
+
+const (
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
+func (s *queryServer) searchEvents(ctx context.Context, req *querypb.SearchRequest) (*querypb.SearchResponse, error) {
+	if req.ProjectId == "" {
+		return nil, fmt.Errorf("%w: project_id is required", errBadRequest)
+	}
+	window, err := parseTimeRange(req.From, req.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	orderBy, err := searchOrderBy(req.Sort)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit || req.Offset < 0 {
+		return nil, fmt.Errorf("%w: limit must be at most %d and offset not negative", errBadRequest, maxSearchLimit)
+	}
+
+	// build SQL; FINAL folds rows stored twice for the same event_id
+	sqlStr := `SELECT event_name,
+                      toUnixTimestamp64Nano(max(timestamp)) AS last_seen,
+                      toUnixTimestamp64Nano(max(received_at)) AS last_received,
+                      count() AS cnt,
+                      count() OVER () AS total
+               FROM logs FINAL
+               WHERE project_id = ?`
+	args := []interface{}{req.ProjectId}
+	where, whereArgs, err := s.eventPredicate(ctx, req.ProjectId, window, req.EventNames, req.Filters, req.Conditions)
+	if err != nil {
+		return nil, err
+	}
+	sqlStr += where
+	args = append(args, whereArgs...)
+	sqlStr += " GROUP BY event_name ORDER BY " + orderBy + " LIMIT ? OFFSET ?"
+	args = append(args, limit, req.Offset)
+
+	rows, err := s.chDB.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		zapLog.Error("clickhouse search", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &querypb.SearchResponse{}
+	for rows.Next() {
+		ev := &querypb.EventSummary{}
+		if err := rows.Scan(&ev.Name, &ev.LastSeen, &ev.LastReceived, &ev.Count, &resp.Total); err != nil {
+			zapLog.Error("row scan", zap.Error(err))
+			return nil, err
+		}
+		resp.Events = append(resp.Events, ev)
+	}
+	return resp, rows.Err()
+}
+
+// eventPredicate renders the conditions a search places on logs rows beyond
+// project_id: the time window, event names and data filters. The result is
+// empty or starts with " AND ".
+func (s *queryServer) eventPredicate(ctx context.Context, projectID string, window timeRange, eventNames []string, filters map[string]string, conds []*querypb.Filter) (string, []interface{}, error) {
+	var (
+		sqlStr string
+		args   []interface{}
+	)
+	if pred, predArgs := window.sql("timestamp"); pred != "" {
+		sqlStr += " AND " + pred
+		args = append(args, predArgs...)
+	}
+	if len(eventNames) > 0 {
+		sqlStr += " AND event_name IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(eventNames)), ", ") + ")"
+		for _, name := range eventNames {
+			args = append(args, name)
+		}
+	}
+	if len(filters) > 0 || len(conds) > 0 {
+		searchable, err := s.projects.searchableKeys(ctx, projectID)
+		if err != nil {
+			return "", nil, err
+		}
+		where, whereArgs, err := compileFilters(searchable, filters, conds)
+		if err != nil {
+			return "", nil, err
+		}
+		sqlStr += " AND " + where
+		args = append(args, whereArgs...)
+	}
+	return sqlStr, args, nil
+}
+
+// GetEventDetail retrieves one event from Cassandra
+func (s *queryServer) GetEventDetail(ctx context.Context, req *querypb.EventDetailRequest) (*querypb.EventDetailResponse, error) {
+	ctx, err := s.authorize(ctx, req.Token, req.ProjectId)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	resp, err := s.getEventDetail(ctx, req)
+	if err != nil {
+		return nil, grpcQueryError(err)
+	}
+	return resp, nil
+}
+
+const (
+	defaultDetailPageSize = 1
+	maxDetailPageSize     = 100
+)
+
+// detailRow is one occurrence read from logs.events_by_time.
+type detailRow struct {
+	eventTime time.Time
+	partition int32
+	offset    int64
+	entry     *ingestpb.LogPayload
+}
+
+func (r detailRow) cursor(older bool) string {
+	return detailCursor{older: older, eventTime: r.eventTime, partition: r.partition, offset: r.offset}.encode()
+}
+
+// getEventDetail pages through the occurrences of an event, newest first.
+func (s *queryServer) getEventDetail(ctx context.Context, req *querypb.EventDetailRequest) (*querypb.EventDetailResponse, error) {
+	if req.ProjectId == "" || req.EventName == "" {
+		return nil, fmt.Errorf("%w: project_id and event_name are required", errBadRequest)
+	}
+	pid, err := gocql.ParseUUID(req.ProjectId)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid project_id", errBadRequest)
+	}
+	size := int(req.PageSize)
+	if size <= 0 {
+		size = defaultDetailPageSize
+	}
+	if size > maxDetailPageSize {
+		return nil, fmt.Errorf("%w: page_size must be at most %d", errBadRequest, maxDetailPageSize)
+	}
+	var cur *detailCursor
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cur = &c
+	}
+
+	// one extra row tells whether there is a further page
+	newer := cur != nil && !cur.older
+	rows, err := s.readOccurrences(ctx, pid, req.EventName, cur, size+1)
+	if err != nil {
+		zapLog.Error("cassandra detail", zap.Error(err))
+		return nil, err
+	}
+	if cur == nil && len(rows) == 0 {
+		return nil, errNotFound
+	}
+	more := len(rows) > size
+	if more {
+		rows = rows[:size]
+	}
+	if newer {
+		slices.Reverse(rows)
+	}
+
+	resp := &querypb.EventDetailResponse{}
+	for _, r := range rows {
+		resp.Entries = append(resp.Entries, r.entry)
+	}
+	if len(rows) == 0 {
+		// nothing beyond the cursor; offer the way back
+		back := *cur
+		back.older = !cur.older
+		if newer {
+			resp.NextCursor = back.encode()
+		} else {
+			resp.PrevCursor = back.encode()
+		}
+		return resp, nil
+	}
+	if (newer && more) || (cur != nil && !newer) {
+		resp.PrevCursor = rows[0].cursor(false)
+	}
+	if (!newer && more) || newer {
+		resp.NextCursor = rows[len(rows)-1].cursor(true)
+	}
+	resp.Entry = resp.Entries[0]
+	resp.Cursor = resp.NextCursor
+	return resp, nil
+}
+
+// readOccurrences reads up to n occurrences of an event beyond cur (from
+// the newest when cur is nil) in paging order: newest first when paging to
+// older ones, oldest first otherwise. It walks the event's day partitions
+// from logs.event_days until n rows are found.
+func (s *queryServer) readOccurrences(ctx context.Context, pid gocql.UUID, name string, cur *detailCursor, n int) ([]detailRow, error) {
+	newer := cur != nil && !cur.older
+	daysCQL := `SELECT day FROM logs.event_days WHERE project_id=? AND event_name=?`
+	daysArgs := []interface{}{pid, name}
+	var curDay time.Time
+	if cur != nil {
+		curDay = cur.eventTime.UTC().Truncate(24 * time.Hour)
+		if newer {
+			daysCQL += ` AND day >= ? ORDER BY day ASC`
+		} else {
+			daysCQL += ` AND day <= ?`
+		}
+		daysArgs = append(daysArgs, curDay)
+	}
+	days := s.cassSess.Query(daysCQL, daysArgs...).WithContext(ctx).PageSize(100).Iter()
+
+	var rows []detailRow
+	var day time.Time
+	for len(rows) < n && days.Scan(&day) {
+		cql := `
+      SELECT event_time, kafka_partition, kafka_offset, event_id, received_at, data
+        FROM logs.events_by_time
+       WHERE project_id=? AND event_name=? AND day=?`
+		args := []interface{}{pid, name, day}
+		if cur != nil && day.Equal(curDay) {
+			if newer {
+				cql += ` AND (event_time, kafka_partition, kafka_offset) > (?, ?, ?)`
+			} else {
+				cql += ` AND (event_time, kafka_partition, kafka_offset) < (?, ?, ?)`
+			}
+			args = append(args, cur.eventTime, cur.partition, cur.offset)
+		}
+		if newer {
+			cql += ` ORDER BY event_time ASC, kafka_partition ASC, kafka_offset ASC`
+		}
+		cql += ` LIMIT ?`
+		args = append(args, n-len(rows))
+
+		iter := s.cassSess.Query(cql, args...).WithContext(ctx).Iter()
+		for {
+			var receivedAt time.Time
+			r := detailRow{entry: &ingestpb.LogPayload{Name: name}}
+			if !iter.Scan(&r.eventTime, &r.partition, &r.offset, &r.entry.EventId, &receivedAt, &r.entry.Data) {
+				break
+			}
+			r.entry.Timestamp = r.eventTime.UnixNano()
+			r.entry.ReceivedAt = receivedAt.UnixNano()
+			rows = append(rows, r)
+		}
+		if err := iter.Close(); err != nil {
+			days.Close()
+			return nil, err
+		}
+	}
+	if err := days.Close(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// grpcQueryError maps a query error onto a gRPC status.
+func grpcQueryError(err error) error {
+	switch {
+	case errors.Is(err, errUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, errForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, errBadRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, errNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, "server error")
+	}
+}
+
+// httpStatus maps the status of a QueryService call onto an HTTP status.
+func httpStatus(err error) int {
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
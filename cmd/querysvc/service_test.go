@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	querypb "github.com/parishadmk/log-system-analysis/internal/api/query"
+)
+
+const (
+	testProject = "7b0b7a51-4a4b-4a8e-9d1e-5c1f0f4f7e11"
+	testUser    = "0f6d1c3e-8a52-4f0e-9b1a-2d3c4e5f6a7b"
+)
+
+var testJWTKey = []byte("test-secret")
+
+// fakeClickHouse is a database/sql driver that records the statements it
+// is sent and answers each with the next of its results.
+type fakeClickHouse struct {
+	mu      sync.Mutex
+	queries []fakeQuery
+	results [][][]driver.Value
+	err     error
+}
+
+type fakeQuery struct {
+	sql  string
+	args []interface{}
+}
+
+func (f *fakeClickHouse) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeClickHouse) Driver() driver.Driver                        { return nil }
+
+// query returns the i-th recorded statement.
+func (f *fakeClickHouse) query(t *testing.T, i int) fakeQuery {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i >= len(f.queries) {
+		t.Fatalf("%d queries sent, want more than %d", len(f.queries), i)
+	}
+	return f.queries[i]
+}
+
+type fakeConn struct{ f *fakeClickHouse }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+// CheckNamedValue keeps arguments as they are, so tests see the Go values
+// the service bound.
+func (c fakeConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	q := fakeQuery{sql: query}
+	for _, a := range args {
+		q.args = append(q.args, a.Value)
+	}
+	c.f.queries = append(c.f.queries, q)
+	if c.f.err != nil {
+		return nil, c.f.err
+	}
+	rows := &fakeRows{}
+	if len(c.f.results) > 0 {
+		rows.rows, c.f.results = c.f.results[0], c.f.results[1:]
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// newTestServer returns a queryServer on ch whose catalog already knows
+// testProject, with searchable keys, and testUser as one of its members.
+func newTestServer(ch *fakeClickHouse, searchable ...string) *queryServer {
+	zapLog = zap.NewNop()
+	expires := time.Now().Add(time.Hour)
+	projects := newProjectCatalog(nil, time.Hour)
+	projects.entries[testProject] = projectEntry{searchableKeys: searchable, expires: expires}
+	projects.members[membershipKey{testUser, testProject}] = membershipEntry{member: true, expires: expires}
+	return &queryServer{chDB: sql.OpenDB(ch), projects: projects, jwtKey: testJWTKey}
+}
+
+// testToken signs a JWT for userID with testJWTKey.
+func testToken(t *testing.T, userID string) string {
+	t.Helper()
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{UserID: userID}).SignedString(testJWTKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+func TestQueryErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+		http int
+	}{
+		{errUnauthenticated, codes.Unauthenticated, http.StatusUnauthorized},
+		{errForbidden, codes.PermissionDenied, http.StatusForbidden},
+		{errors.Join(errBadRequest, errors.New("limit")), codes.InvalidArgument, http.StatusBadRequest},
+		{errNotFound, codes.NotFound, http.StatusNotFound},
+		{errors.New("clickhouse: connection refused"), codes.Internal, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		err := grpcQueryError(tt.err)
+		if status.Code(err) != tt.code || httpStatus(err) != tt.http {
+			t.Errorf("%v: %s, HTTP %d, want %s, %d", tt.err, status.Code(err), httpStatus(err), tt.code, tt.http)
+		}
+	}
+	// internal errors are not passed on to clients
+	if msg := status.Convert(grpcQueryError(errors.New("dsn secret"))).Message(); msg != "server error" {
+		t.Errorf("internal error message = %q", msg)
+	}
+}
+
+func TestSearchEvents(t *testing.T) {
+	ch := &fakeClickHouse{results: [][][]driver.Value{{
+		{"login", int64(300), int64(301), int64(7), int64(2)},
+		{"logout", int64(200), int64(201), int64(3), int64(2)},
+	}}}
+	s := newTestServer(ch)
+	resp, err := s.SearchEvents(context.Background(), &querypb.SearchRequest{
+		Token:     testToken(t, testUser),
+		ProjectId: testProject,
+		Sort:      "name",
+		Limit:     5,
+		Offset:    10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &querypb.SearchResponse{Total: 2, Events: []*querypb.EventSummary{
+		{Name: "login", LastSeen: 300, LastReceived: 301, Count: 7},
+		{Name: "logout", LastSeen: 200, LastReceived: 201, Count: 3},
+	}}
+	if !proto.Equal(resp, want) {
+		t.Errorf("response = %v, want %v", resp, want)
+	}
+	q := ch.query(t, 0)
+	if !strings.Contains(q.sql, "ORDER BY event_name LIMIT ? OFFSET ?") {
+		t.Errorf("sql = %s", q.sql)
+	}
+	if want := []interface{}{testProject, int32(5), int32(10)}; !reflect.DeepEqual(q.args, want) {
+		t.Errorf("args = %#v, want %#v", q.args, want)
+	}
+}
+
+// TestQueryToken checks that the token is taken from the request or else
+// from the authorization metadata.
+func TestQueryToken(t *testing.T) {
+	s := newTestServer(&fakeClickHouse{})
+	tok := testToken(t, testUser)
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		token string
+		code  codes.Code
+	}{
+		{"field", context.Background(), tok, codes.OK},
+		{"metadata", metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+tok)), "", codes.OK},
+		{"none", context.Background(), "", codes.Unauthenticated},
+		{"not bearer", metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", tok)), "", codes.Unauthenticated},
+		{"bad signature", context.Background(), tok[:len(tok)-2] + "xx", codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		_, err := s.SearchEvents(tt.ctx, &querypb.SearchRequest{Token: tt.token, ProjectId: testProject})
+		if status.Code(err) != tt.code {
+			t.Errorf("%s: err = %v, want %s", tt.name, err, tt.code)
+		}
+	}
+}
+
+// TestHTTPMatchesGRPC checks that the HTTP handlers return what the
+// QueryService methods return, errors included.
+func TestHTTPMatchesGRPC(t *testing.T) {
+	row := []driver.Value{"login", int64(300), int64(301), int64(7), int64(1)}
+	ch := &fakeClickHouse{results: [][][]driver.Value{{row}, {row}}}
+	s := newTestServer(ch)
+	tok := testToken(t, testUser)
+
+	want, err := s.SearchEvents(context.Background(), &querypb.SearchRequest{Token: tok, ProjectId: testProject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/search", strings.NewReader(`{"project_id":"`+testProject+`"}`))
+	req.Header.Set("Authorization", "Bearer "+tok)
+	rec := httptest.NewRecorder()
+	s.handleSearch(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var got querypb.SearchResponse
+	if err := protojson.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(&got, want) {
+		t.Errorf("HTTP response = %v, gRPC %v", &got, want)
+	}
+	if q0, q1 := ch.query(t, 0), ch.query(t, 1); q0.sql != q1.sql || !reflect.DeepEqual(q0.args, q1.args) {
+		t.Errorf("HTTP query %v differs from gRPC query %v", q1, q0)
+	}
+
+	tests := []struct {
+		name, auth, body string
+		code             int
+	}{
+		{"no token", "", `{"project_id":"` + testProject + `"}`, http.StatusUnauthorized},
+		{"bad sort", "Bearer " + tok, `{"project_id":"` + testProject + `","sort":"size"}`, http.StatusBadRequest},
+		{"bad JSON", "Bearer " + tok, `{"project_id":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/v1/search", strings.NewReader(tt.body))
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		s.handleSearch(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.code)
+		}
+	}
+	// the body's token is used when there is no header
+	body, _ := json.Marshal(map[string]string{"project_id": testProject, "token": tok})
+	rec = httptest.NewRecorder()
+	s.handleSearch(rec, httptest.NewRequest(http.MethodPost, "/v1/search", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Errorf("token in body: status = %d", rec.Code)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeRange is a half-open window [from, to) over event timestamps; a zero
+// bound is open.
+type timeRange struct {
+	from, to time.Time
+}
+
+// parseTimeRange reads the from/to strings of a request.
+func parseTimeRange(from, to string, now time.Time) (timeRange, error) {
+	var (
+		r   timeRange
+		err error
+	)
+	if r.from, err = parseTimeBound(from, now); err != nil {
+		return r, fmt.Errorf("%w: from: %v", errBadRequest, err)
+	}
+	if r.to, err = parseTimeBound(to, now); err != nil {
+		return r, fmt.Errorf("%w: to: %v", errBadRequest, err)
+	}
+	if !r.from.IsZero() && !r.to.IsZero() && !r.from.Before(r.to) {
+		return r, fmt.Errorf("%w: from must be before to", errBadRequest)
+	}
+	return r, nil
+}
+
+// bounded closes an open window: to defaults to now and from to span
+// before to.
+func (r timeRange) bounded(now time.Time, span time.Duration) (timeRange, error) {
+	if r.to.IsZero() {
+		r.to = now
+	}
+	if r.from.IsZero() {
+		r.from = r.to.Add(-span)
+	}
+	if !r.from.Before(r.to) {
+		return r, fmt.Errorf("%w: from must be before to", errBadRequest)
+	}
+	return r, nil
+}
+
+// parseTimeBound accepts RFC 3339, Unix nanoseconds, "now" and offsets from
+// now such as "-15m" or "-7d".
+func parseTimeBound(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return time.Time{}, nil
+	case s == "now":
+		return now, nil
+	case strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+"):
+		d, err := parseDuration(s[1:])
+		if err != nil {
+			return time.Time{}, err
+		}
+		if s[0] == '-' {
+			d = -d
+		}
+		return now.Add(d), nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, n), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not RFC 3339, Unix nanos or a relative time", s)
+	}
+	return t, nil
+}
+
+// parseDuration is time.ParseDuration plus a "d" (day) unit.
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// sql renders the window as a predicate on column. The extra condition on
+// toYYYYMMDD(column) mirrors the logs partition key so that ClickHouse
+// skips partitions outside the window. to is exclusive, so a window ending
+// at midnight does not read the next day's partition.
+func (r timeRange) sql(column string) (string, []interface{}) {
+	var (
+		preds []string
+		args  []interface{}
+	)
+	if !r.from.IsZero() {
+		preds = append(preds, "toYYYYMMDD("+column+") >= ?", column+" >= fromUnixTimestamp64Nano(toInt64(?))")
+		args = append(args, yyyymmdd(r.from), r.from.UnixNano())
+	}
+	if !r.to.IsZero() {
+		preds = append(preds, "toYYYYMMDD("+column+") <= ?", column+" < fromUnixTimestamp64Nano(toInt64(?))")
+		args = append(args, yyyymmdd(r.to.Add(-time.Nanosecond)), r.to.UnixNano())
+	}
+	return strings.Join(preds, " AND "), args
+}
+
+func yyyymmdd(t time.Time) int {
+	t = t.UTC()
+	return t.Year()*10000 + int(t.Month())*100 + t.Day()
+}
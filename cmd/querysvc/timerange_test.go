@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		from, to string
+		want     timeRange
+	}{
+		{"", "", timeRange{}},
+		{"-15m", "now", timeRange{from: now.Add(-15 * time.Minute), to: now}},
+		{"-7d", "", timeRange{from: now.Add(-7 * 24 * time.Hour)}},
+		{"", "+1h", timeRange{to: now.Add(time.Hour)}},
+		{"2026-10-16T00:00:00Z", "2026-10-17T00:00:00.5+02:00", timeRange{
+			from: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2026, 10, 16, 22, 0, 0, 5e8, time.UTC),
+		}},
+		{"1700000000000000000", "", timeRange{from: time.Unix(0, 1700000000000000000)}},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeRange(tt.from, tt.to, now)
+		if err != nil {
+			t.Errorf("parseTimeRange(%q, %q): %v", tt.from, tt.to, err)
+			continue
+		}
+		if !got.from.Equal(tt.want.from) || !got.to.Equal(tt.want.to) {
+			t.Errorf("parseTimeRange(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	bad := [][2]string{
+		{"yesterday", ""},
+		{"", "-15x"},
+		{"-d", ""},
+		{"now", "-1h"},
+		{"-1h", "-1h"},
+	}
+	for _, b := range bad {
+		if _, err := parseTimeRange(b[0], b[1], now); !errors.Is(err, errBadRequest) {
+			t.Errorf("parseTimeRange(%q, %q) = %v, want errBadRequest", b[0], b[1], err)
+		}
+	}
+}
+
+func TestTimeRangeBounded(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	got, err := timeRange{}.bounded(now, time.Hour)
+	if err != nil || !got.from.Equal(now.Add(-time.Hour)) || !got.to.Equal(now) {
+		t.Errorf("open window = %v, %v", got, err)
+	}
+	to := now.Add(-24 * time.Hour)
+	got, err = timeRange{to: to}.bounded(now, time.Hour)
+	if err != nil || !got.from.Equal(to.Add(-time.Hour)) || !got.to.Equal(to) {
+		t.Errorf("open from = %v, %v", got, err)
+	}
+	if _, err := (timeRange{from: now.Add(time.Hour)}).bounded(now, time.Hour); !errors.Is(err, errBadRequest) {
+		t.Errorf("from after now: err = %v, want errBadRequest", err)
+	}
+}
+
+// TestTimeRangeSQL checks the partition predicates at day boundaries: the
+// toYYYYMMDD bounds must cover every day the window touches and, since to
+// is exclusive, no more.
+func TestTimeRangeSQL(t *testing.T) {
+	day := func(d, h, m, s, ns int) time.Time { return time.Date(2026, 10, d, h, m, s, ns, time.UTC) }
+	const (
+		fromPred = "toYYYYMMDD(timestamp) >= ? AND timestamp >= fromUnixTimestamp64Nano(toInt64(?))"
+		toPred   = "toYYYYMMDD(timestamp) <= ? AND timestamp < fromUnixTimestamp64Nano(toInt64(?))"
+	)
+	tests := []struct {
+		name string
+		r    timeRange
+		sql  string
+		args []interface{}
+	}{
+		{"open", timeRange{}, "", nil},
+		{
+			"one whole day",
+			timeRange{from: day(17, 0, 0, 0, 0), to: day(18, 0, 0, 0, 0)},
+			fromPred + " AND " + toPred,
+			[]interface{}{20261017, day(17, 0, 0, 0, 0).UnixNano(), 20261017, day(18, 0, 0, 0, 0).UnixNano()},
+		},
+		{
+			"ends just after midnight",
+			timeRange{from: day(17, 23, 0, 0, 0), to: day(18, 0, 0, 0, 1)},
+			fromPred + " AND " + toPred,
+			[]interface{}{20261017, day(17, 23, 0, 0, 0).UnixNano(), 20261018, day(18, 0, 0, 0, 1).UnixNano()},
+		},
+		{
+			"starts at the last nanosecond of a day",
+			timeRange{from: day(16, 23, 59, 59, 999999999)},
+			fromPred,
+			[]interface{}{20261016, day(16, 23, 59, 59, 999999999).UnixNano()},
+		},
+		{
+			"to only, at midnight",
+			timeRange{to: day(1, 0, 0, 0, 0)},
+			toPred,
+			[]interface{}{20260930, day(1, 0, 0, 0, 0).UnixNano()},
+		},
+		{
+			"bounds in another zone use the UTC day",
+			timeRange{
+				from: time.Date(2026, 10, 17, 1, 0, 0, 0, time.FixedZone("+03:00", 3*3600)),
+				to:   time.Date(2026, 10, 17, 23, 0, 0, 0, time.FixedZone("-05:00", -5*3600)),
+			},
+			fromPred + " AND " + toPred,
+			[]interface{}{20261016, day(16, 22, 0, 0, 0).UnixNano(), 20261018, day(18, 4, 0, 0, 0).UnixNano()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.r.sql("timestamp")
+			if sql != tt.sql {
+				t.Errorf("sql = %q, want %q", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestEventPredicate(t *testing.T) {
+	s := &queryServer{}
+	window := timeRange{from: time.Unix(0, 100), to: time.Unix(0, 200)}
+	sql, args, err := s.eventPredicate(context.Background(), "p1", window, []string{"login", "logout"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSQL := " AND toYYYYMMDD(timestamp) >= ? AND timestamp >= fromUnixTimestamp64Nano(toInt64(?))" +
+		" AND toYYYYMMDD(timestamp) <= ? AND timestamp < fromUnixTimestamp64Nano(toInt64(?))" +
+		" AND event_name IN (?, ?)"
+	if sql != wantSQL {
+		t.Errorf("sql = %q, want %q", sql, wantSQL)
+	}
+	wantArgs := []interface{}{19700101, int64(100), 19700101, int64(200), "login", "logout"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+
+	if sql, args, err := s.eventPredicate(context.Background(), "p1", timeRange{}, nil, nil, nil); sql != "" || args != nil || err != nil {
+		t.Errorf("empty predicate = %q, %v, %v", sql, args, err)
+	}
+}
+
+func TestSearchOrderBy(t *testing.T) {
+	tests := map[string]string{
+		"":           "cnt DESC, event_name",
+		"count":      "cnt, event_name",
+		"-count":     "cnt DESC, event_name",
+		"last_seen":  "last_seen, event_name",
+		"-last_seen": "last_seen DESC, event_name",
+		"name":       "event_name",
+		"-name":      "event_name DESC",
+	}
+	for sort, want := range tests {
+		got, err := searchOrderBy(sort)
+		if err != nil || got != want {
+			t.Errorf("searchOrderBy(%q) = %q, %v, want %q", sort, got, err, want)
+		}
+	}
+	for _, sort := range []string{"cnt", "--count", "event_name", "count; DROP TABLE logs"} {
+		if _, err := searchOrderBy(sort); !errors.Is(err, errBadRequest) {
+			t.Errorf("searchOrderBy(%q) = %v, want errBadRequest", sort, err)
+		}
+	}
+}
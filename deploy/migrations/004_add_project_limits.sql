@@ -0,0 +1,5 @@
+-- per-project ingest limits enforced by the gateway; 0 means unlimited
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS rate_limit_eps INT8 NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS rate_limit_bps INT8 NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS daily_quota_events INT8 NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS daily_quota_bytes INT8 NOT NULL DEFAULT 0;
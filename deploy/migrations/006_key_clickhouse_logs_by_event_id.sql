@@ -0,0 +1,22 @@
+-- Rebuild logs keyed by event ID: ReplacingMergeTree collapses rows written
+-- twice for the same event. Existing rows get their Kafka position as ID.
+CREATE TABLE IF NOT EXISTS logs_by_event_id (
+  project_id String,
+  timestamp DateTime64(9, 'UTC'),
+  event_name String,
+  data Map(String, String),
+  kafka_partition UInt32,
+  kafka_offset UInt64,
+  event_id String
+) ENGINE = ReplacingMergeTree()
+PARTITION BY toYYYYMMDD(timestamp)
+ORDER BY (project_id, timestamp, event_id);
+
+INSERT INTO logs_by_event_id
+SELECT project_id, timestamp, event_name, data, kafka_partition, kafka_offset,
+       concat('kafka:', toString(kafka_partition), ':', toString(kafka_offset))
+FROM logs;
+
+EXCHANGE TABLES logs AND logs_by_event_id;
+
+DROP TABLE logs_by_event_id;
@@ -0,0 +1,16 @@
+-- per-project event schema validated by the gateway at ingest
+-- strictness: 'off', 'reject' (invalid events get a per-field error) or
+-- 'quarantine' (invalid events are tagged and routed to the quarantine topic)
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS schema_strictness STRING NOT NULL DEFAULT 'off'
+    CHECK (schema_strictness IN ('off', 'reject', 'quarantine'));
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS required_keys STRING[] NOT NULL DEFAULT ARRAY[]:::STRING[];
+-- empty allows any event name
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS allowed_event_names STRING[] NOT NULL DEFAULT ARRAY[]:::STRING[];
+-- expected value type of searchable keys: "string", "int", "float", "bool",
+-- "timestamp", or an array of allowed values for an enum, e.g.
+-- '{"status": "int", "level": ["debug", "info", "warn", "error"]}'
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS key_types JSONB NOT NULL DEFAULT '{}';
+-- limits on an event's data; 0 means unlimited
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS max_keys INT8 NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS max_key_bytes INT8 NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS max_value_bytes INT8 NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+-- per-project handling of event timestamps outside the accepted skew window
+-- around the gateway's receive time: 'keep', 'clamp' or 'reject';
+-- a skew of 0 seconds means unbounded
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS timestamp_policy STRING NOT NULL DEFAULT 'keep'
+    CHECK (timestamp_policy IN ('keep', 'clamp', 'reject'));
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS max_past_skew_seconds INT8 NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS max_future_skew_seconds INT8 NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Time the gateway received each event; rows written before it was
+-- recorded fall back to the event timestamp
+ALTER TABLE logs ADD COLUMN IF NOT EXISTS received_at DateTime64(9, 'UTC') DEFAULT timestamp;
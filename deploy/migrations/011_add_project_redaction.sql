@@ -0,0 +1,11 @@
+-- per-project PII redaction applied by the gateway before events reach Kafka,
+-- a JSON array of rules, e.g.
+-- '[{"detector": "email", "action": "hash"},
+--   {"detector": "credit_card"},
+--   {"keys": ["password", "authorization"], "action": "drop_key"},
+--   {"name": "ssn", "pattern": "\\d{3}-\\d{2}-\\d{4}", "action": "mask"}]'
+-- detectors: email, ipv4, ipv6, credit_card, jwt, bearer
+-- actions: mask (default), hash, drop_key
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS redaction_rules JSONB NOT NULL DEFAULT '[]';
+-- secret mixed into hashed values so they cannot be matched across projects
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS redaction_salt STRING NOT NULL DEFAULT gen_random_uuid()::STRING;
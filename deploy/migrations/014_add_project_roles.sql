@@ -0,0 +1,14 @@
+-- members are 'owner' (may update and archive the project) or 'member'
+ALTER TABLE project_members ADD COLUMN IF NOT EXISTS role STRING NOT NULL DEFAULT 'member'
+    CHECK (role IN ('owner', 'member'));
+-- existing projects get their earliest-registered member as owner, so that
+-- someone can update and archive them
+UPDATE project_members SET role = 'owner'
+ WHERE (project_id, user_id) IN (
+   SELECT DISTINCT ON (m.project_id) m.project_id, m.user_id
+     FROM project_members m JOIN users u ON u.id = m.user_id
+    WHERE NOT EXISTS (SELECT 1 FROM project_members o
+                       WHERE o.project_id = m.project_id AND o.role = 'owner')
+    ORDER BY m.project_id, u.created_at, m.user_id);
+-- set when an owner archives the project; its API key stops working
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
@@ -21,6 +21,272 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type SchemaStrictness int32
+
+const (
+	SchemaStrictness_SCHEMA_OFF        SchemaStrictness = 0 // events are not validated
+	SchemaStrictness_SCHEMA_REJECT     SchemaStrictness = 1 // invalid events are rejected
+	SchemaStrictness_SCHEMA_QUARANTINE SchemaStrictness = 2 // invalid events are tagged and routed aside
+)
+
+// Enum value maps for SchemaStrictness.
+var (
+	SchemaStrictness_name = map[int32]string{
+		0: "SCHEMA_OFF",
+		1: "SCHEMA_REJECT",
+		2: "SCHEMA_QUARANTINE",
+	}
+	SchemaStrictness_value = map[string]int32{
+		"SCHEMA_OFF":        0,
+		"SCHEMA_REJECT":     1,
+		"SCHEMA_QUARANTINE": 2,
+	}
+)
+
+func (x SchemaStrictness) Enum() *SchemaStrictness {
+	p := new(SchemaStrictness)
+	*p = x
+	return p
+}
+
+func (x SchemaStrictness) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SchemaStrictness) Descriptor() protoreflect.EnumDescriptor {
+	return file_auth_proto_enumTypes[0].Descriptor()
+}
+
+func (SchemaStrictness) Type() protoreflect.EnumType {
+	return &file_auth_proto_enumTypes[0]
+}
+
+func (x SchemaStrictness) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SchemaStrictness.Descriptor instead.
+func (SchemaStrictness) EnumDescriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{0}
+}
+
+type ValueType int32
+
+const (
+	ValueType_VALUE_STRING    ValueType = 0
+	ValueType_VALUE_INT       ValueType = 1
+	ValueType_VALUE_FLOAT     ValueType = 2
+	ValueType_VALUE_BOOL      ValueType = 3
+	ValueType_VALUE_TIMESTAMP ValueType = 4 // RFC 3339 or Unix seconds/millis/nanos
+	ValueType_VALUE_ENUM      ValueType = 5
+)
+
+// Enum value maps for ValueType.
+var (
+	ValueType_name = map[int32]string{
+		0: "VALUE_STRING",
+		1: "VALUE_INT",
+		2: "VALUE_FLOAT",
+		3: "VALUE_BOOL",
+		4: "VALUE_TIMESTAMP",
+		5: "VALUE_ENUM",
+	}
+	ValueType_value = map[string]int32{
+		"VALUE_STRING":    0,
+		"VALUE_INT":       1,
+		"VALUE_FLOAT":     2,
+		"VALUE_BOOL":      3,
+		"VALUE_TIMESTAMP": 4,
+		"VALUE_ENUM":      5,
+	}
+)
+
+func (x ValueType) Enum() *ValueType {
+	p := new(ValueType)
+	*p = x
+	return p
+}
+
+func (x ValueType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ValueType) Descriptor() protoreflect.EnumDescriptor {
+	return file_auth_proto_enumTypes[1].Descriptor()
+}
+
+func (ValueType) Type() protoreflect.EnumType {
+	return &file_auth_proto_enumTypes[1]
+}
+
+func (x ValueType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ValueType.Descriptor instead.
+func (ValueType) EnumDescriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{1}
+}
+
+type TimestampMode int32
+
+const (
+	TimestampMode_TIMESTAMP_KEEP   TimestampMode = 0 // stored as sent
+	TimestampMode_TIMESTAMP_CLAMP  TimestampMode = 1 // moved to the nearest edge of the window
+	TimestampMode_TIMESTAMP_REJECT TimestampMode = 2 // the event is rejected
+)
+
+// Enum value maps for TimestampMode.
+var (
+	TimestampMode_name = map[int32]string{
+		0: "TIMESTAMP_KEEP",
+		1: "TIMESTAMP_CLAMP",
+		2: "TIMESTAMP_REJECT",
+	}
+	TimestampMode_value = map[string]int32{
+		"TIMESTAMP_KEEP":   0,
+		"TIMESTAMP_CLAMP":  1,
+		"TIMESTAMP_REJECT": 2,
+	}
+)
+
+func (x TimestampMode) Enum() *TimestampMode {
+	p := new(TimestampMode)
+	*p = x
+	return p
+}
+
+func (x TimestampMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TimestampMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_auth_proto_enumTypes[2].Descriptor()
+}
+
+func (TimestampMode) Type() protoreflect.EnumType {
+	return &file_auth_proto_enumTypes[2]
+}
+
+func (x TimestampMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TimestampMode.Descriptor instead.
+func (TimestampMode) EnumDescriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{2}
+}
+
+type Detector int32
+
+const (
+	Detector_DETECTOR_NONE        Detector = 0
+	Detector_DETECTOR_EMAIL       Detector = 1
+	Detector_DETECTOR_IPV4        Detector = 2
+	Detector_DETECTOR_IPV6        Detector = 3
+	Detector_DETECTOR_CREDIT_CARD Detector = 4 // digit runs passing the Luhn check
+	Detector_DETECTOR_JWT         Detector = 5
+	Detector_DETECTOR_BEARER      Detector = 6 // "Bearer <token>"
+)
+
+// Enum value maps for Detector.
+var (
+	Detector_name = map[int32]string{
+		0: "DETECTOR_NONE",
+		1: "DETECTOR_EMAIL",
+		2: "DETECTOR_IPV4",
+		3: "DETECTOR_IPV6",
+		4: "DETECTOR_CREDIT_CARD",
+		5: "DETECTOR_JWT",
+		6: "DETECTOR_BEARER",
+	}
+	Detector_value = map[string]int32{
+		"DETECTOR_NONE":        0,
+		"DETECTOR_EMAIL":       1,
+		"DETECTOR_IPV4":        2,
+		"DETECTOR_IPV6":        3,
+		"DETECTOR_CREDIT_CARD": 4,
+		"DETECTOR_JWT":         5,
+		"DETECTOR_BEARER":      6,
+	}
+)
+
+func (x Detector) Enum() *Detector {
+	p := new(Detector)
+	*p = x
+	return p
+}
+
+func (x Detector) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Detector) Descriptor() protoreflect.EnumDescriptor {
+	return file_auth_proto_enumTypes[3].Descriptor()
+}
+
+func (Detector) Type() protoreflect.EnumType {
+	return &file_auth_proto_enumTypes[3]
+}
+
+func (x Detector) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Detector.Descriptor instead.
+func (Detector) EnumDescriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{3}
+}
+
+type RedactionAction int32
+
+const (
+	RedactionAction_REDACT_MASK     RedactionAction = 0 // replaced with [REDACTED:<rule>]
+	RedactionAction_REDACT_HASH     RedactionAction = 1 // replaced with a salted hash, so equal values still match
+	RedactionAction_REDACT_DROP_KEY RedactionAction = 2 // the whole key is removed
+)
+
+// Enum value maps for RedactionAction.
+var (
+	RedactionAction_name = map[int32]string{
+		0: "REDACT_MASK",
+		1: "REDACT_HASH",
+		2: "REDACT_DROP_KEY",
+	}
+	RedactionAction_value = map[string]int32{
+		"REDACT_MASK":     0,
+		"REDACT_HASH":     1,
+		"REDACT_DROP_KEY": 2,
+	}
+)
+
+func (x RedactionAction) Enum() *RedactionAction {
+	p := new(RedactionAction)
+	*p = x
+	return p
+}
+
+func (x RedactionAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RedactionAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_auth_proto_enumTypes[4].Descriptor()
+}
+
+func (RedactionAction) Type() protoreflect.EnumType {
+	return &file_auth_proto_enumTypes[4]
+}
+
+func (x RedactionAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RedactionAction.Descriptor instead.
+func (RedactionAction) EnumDescriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{4}
+}
+
 type LoginRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
@@ -119,7 +385,7 @@ func (x *LoginResponse) GetToken() string {
 
 type ApiKeyRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"` // optional; resolved from api_key when empty
 	ApiKey        string                 `protobuf:"bytes,2,opt,name=api_key,json=apiKey,proto3" json:"api_key,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -172,6 +438,11 @@ func (x *ApiKeyRequest) GetApiKey() string {
 type ApiKeyResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Valid         bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	ProjectId     string                 `protobuf:"bytes,2,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"` // the project the key belongs to, when valid
+	Limits        *ProjectLimits         `protobuf:"bytes,3,opt,name=limits,proto3" json:"limits,omitempty"`
+	Schema        *ProjectSchema         `protobuf:"bytes,4,opt,name=schema,proto3" json:"schema,omitempty"`
+	Timestamps    *TimestampPolicy       `protobuf:"bytes,5,opt,name=timestamps,proto3" json:"timestamps,omitempty"`
+	Redaction     *RedactionPolicy       `protobuf:"bytes,6,opt,name=redaction,proto3" json:"redaction,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -213,6 +484,448 @@ func (x *ApiKeyResponse) GetValid() bool {
 	return false
 }
 
+func (x *ApiKeyResponse) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *ApiKeyResponse) GetLimits() *ProjectLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *ApiKeyResponse) GetSchema() *ProjectSchema {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+func (x *ApiKeyResponse) GetTimestamps() *TimestampPolicy {
+	if x != nil {
+		return x.Timestamps
+	}
+	return nil
+}
+
+func (x *ApiKeyResponse) GetRedaction() *RedactionPolicy {
+	if x != nil {
+		return x.Redaction
+	}
+	return nil
+}
+
+// Ingest limits of a project; 0 means unlimited.
+type ProjectLimits struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	EventsPerSecond int64                  `protobuf:"varint,1,opt,name=events_per_second,json=eventsPerSecond,proto3" json:"events_per_second,omitempty"`
+	BytesPerSecond  int64                  `protobuf:"varint,2,opt,name=bytes_per_second,json=bytesPerSecond,proto3" json:"bytes_per_second,omitempty"`
+	DailyEvents     int64                  `protobuf:"varint,3,opt,name=daily_events,json=dailyEvents,proto3" json:"daily_events,omitempty"`
+	DailyBytes      int64                  `protobuf:"varint,4,opt,name=daily_bytes,json=dailyBytes,proto3" json:"daily_bytes,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ProjectLimits) Reset() {
+	*x = ProjectLimits{}
+	mi := &file_auth_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProjectLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProjectLimits) ProtoMessage() {}
+
+func (x *ProjectLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProjectLimits.ProtoReflect.Descriptor instead.
+func (*ProjectLimits) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ProjectLimits) GetEventsPerSecond() int64 {
+	if x != nil {
+		return x.EventsPerSecond
+	}
+	return 0
+}
+
+func (x *ProjectLimits) GetBytesPerSecond() int64 {
+	if x != nil {
+		return x.BytesPerSecond
+	}
+	return 0
+}
+
+func (x *ProjectLimits) GetDailyEvents() int64 {
+	if x != nil {
+		return x.DailyEvents
+	}
+	return 0
+}
+
+func (x *ProjectLimits) GetDailyBytes() int64 {
+	if x != nil {
+		return x.DailyBytes
+	}
+	return 0
+}
+
+// Event schema of a project, enforced by the gateway at ingest.
+type ProjectSchema struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Strictness    SchemaStrictness       `protobuf:"varint,1,opt,name=strictness,proto3,enum=auth.SchemaStrictness" json:"strictness,omitempty"`
+	RequiredKeys  []string               `protobuf:"bytes,2,rep,name=required_keys,json=requiredKeys,proto3" json:"required_keys,omitempty"`
+	EventNames    []string               `protobuf:"bytes,3,rep,name=event_names,json=eventNames,proto3" json:"event_names,omitempty"`                                                                     // allowed event names; empty allows any
+	KeyTypes      map[string]*KeyType    `protobuf:"bytes,4,rep,name=key_types,json=keyTypes,proto3" json:"key_types,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // expected type of data values, by key
+	MaxKeys       int32                  `protobuf:"varint,5,opt,name=max_keys,json=maxKeys,proto3" json:"max_keys,omitempty"`                                                                             // limits on data; 0 means unlimited
+	MaxKeyBytes   int32                  `protobuf:"varint,6,opt,name=max_key_bytes,json=maxKeyBytes,proto3" json:"max_key_bytes,omitempty"`
+	MaxValueBytes int32                  `protobuf:"varint,7,opt,name=max_value_bytes,json=maxValueBytes,proto3" json:"max_value_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProjectSchema) Reset() {
+	*x = ProjectSchema{}
+	mi := &file_auth_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProjectSchema) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProjectSchema) ProtoMessage() {}
+
+func (x *ProjectSchema) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProjectSchema.ProtoReflect.Descriptor instead.
+func (*ProjectSchema) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ProjectSchema) GetStrictness() SchemaStrictness {
+	if x != nil {
+		return x.Strictness
+	}
+	return SchemaStrictness_SCHEMA_OFF
+}
+
+func (x *ProjectSchema) GetRequiredKeys() []string {
+	if x != nil {
+		return x.RequiredKeys
+	}
+	return nil
+}
+
+func (x *ProjectSchema) GetEventNames() []string {
+	if x != nil {
+		return x.EventNames
+	}
+	return nil
+}
+
+func (x *ProjectSchema) GetKeyTypes() map[string]*KeyType {
+	if x != nil {
+		return x.KeyTypes
+	}
+	return nil
+}
+
+func (x *ProjectSchema) GetMaxKeys() int32 {
+	if x != nil {
+		return x.MaxKeys
+	}
+	return 0
+}
+
+func (x *ProjectSchema) GetMaxKeyBytes() int32 {
+	if x != nil {
+		return x.MaxKeyBytes
+	}
+	return 0
+}
+
+func (x *ProjectSchema) GetMaxValueBytes() int32 {
+	if x != nil {
+		return x.MaxValueBytes
+	}
+	return 0
+}
+
+type KeyType struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          ValueType              `protobuf:"varint,1,opt,name=type,proto3,enum=auth.ValueType" json:"type,omitempty"`
+	EnumValues    []string               `protobuf:"bytes,2,rep,name=enum_values,json=enumValues,proto3" json:"enum_values,omitempty"` // allowed values of an ENUM key
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyType) Reset() {
+	*x = KeyType{}
+	mi := &file_auth_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyType) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyType) ProtoMessage() {}
+
+func (x *KeyType) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyType.ProtoReflect.Descriptor instead.
+func (*KeyType) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *KeyType) GetType() ValueType {
+	if x != nil {
+		return x.Type
+	}
+	return ValueType_VALUE_STRING
+}
+
+func (x *KeyType) GetEnumValues() []string {
+	if x != nil {
+		return x.EnumValues
+	}
+	return nil
+}
+
+// How the gateway treats event timestamps outside the accepted skew window
+// around the time it received them.
+type TimestampPolicy struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Mode             TimestampMode          `protobuf:"varint,1,opt,name=mode,proto3,enum=auth.TimestampMode" json:"mode,omitempty"`
+	MaxPastSeconds   int64                  `protobuf:"varint,2,opt,name=max_past_seconds,json=maxPastSeconds,proto3" json:"max_past_seconds,omitempty"`       // 0 means unbounded
+	MaxFutureSeconds int64                  `protobuf:"varint,3,opt,name=max_future_seconds,json=maxFutureSeconds,proto3" json:"max_future_seconds,omitempty"` // 0 means unbounded
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *TimestampPolicy) Reset() {
+	*x = TimestampPolicy{}
+	mi := &file_auth_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimestampPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimestampPolicy) ProtoMessage() {}
+
+func (x *TimestampPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimestampPolicy.ProtoReflect.Descriptor instead.
+func (*TimestampPolicy) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TimestampPolicy) GetMode() TimestampMode {
+	if x != nil {
+		return x.Mode
+	}
+	return TimestampMode_TIMESTAMP_KEEP
+}
+
+func (x *TimestampPolicy) GetMaxPastSeconds() int64 {
+	if x != nil {
+		return x.MaxPastSeconds
+	}
+	return 0
+}
+
+func (x *TimestampPolicy) GetMaxFutureSeconds() int64 {
+	if x != nil {
+		return x.MaxFutureSeconds
+	}
+	return 0
+}
+
+// PII redaction applied by the gateway to event data before it reaches Kafka.
+type RedactionPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Salt          string                 `protobuf:"bytes,1,opt,name=salt,proto3" json:"salt,omitempty"` // project secret mixed into HASH replacements
+	Rules         []*RedactionRule       `protobuf:"bytes,2,rep,name=rules,proto3" json:"rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RedactionPolicy) Reset() {
+	*x = RedactionPolicy{}
+	mi := &file_auth_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedactionPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedactionPolicy) ProtoMessage() {}
+
+func (x *RedactionPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedactionPolicy.ProtoReflect.Descriptor instead.
+func (*RedactionPolicy) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RedactionPolicy) GetSalt() string {
+	if x != nil {
+		return x.Salt
+	}
+	return ""
+}
+
+func (x *RedactionPolicy) GetRules() []*RedactionRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+// A rule matches data values by detector or pattern, or whole entries by key
+// name, and applies its action to what it matched.
+type RedactionRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // reported in metrics; defaults to the detector or "rule<N>"
+	Detector      Detector               `protobuf:"varint,2,opt,name=detector,proto3,enum=auth.Detector" json:"detector,omitempty"`
+	Pattern       string                 `protobuf:"bytes,3,opt,name=pattern,proto3" json:"pattern,omitempty"` // RE2 regular expression matched against values
+	Keys          []string               `protobuf:"bytes,4,rep,name=keys,proto3" json:"keys,omitempty"`       // key names (case-insensitive) whose values always match
+	Action        RedactionAction        `protobuf:"varint,5,opt,name=action,proto3,enum=auth.RedactionAction" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RedactionRule) Reset() {
+	*x = RedactionRule{}
+	mi := &file_auth_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedactionRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedactionRule) ProtoMessage() {}
+
+func (x *RedactionRule) ProtoReflect() protoreflect.Message {
+	mi := &file_auth_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedactionRule.ProtoReflect.Descriptor instead.
+func (*RedactionRule) Descriptor() ([]byte, []int) {
+	return file_auth_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RedactionRule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RedactionRule) GetDetector() Detector {
+	if x != nil {
+		return x.Detector
+	}
+	return Detector_DETECTOR_NONE
+}
+
+func (x *RedactionRule) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *RedactionRule) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *RedactionRule) GetAction() RedactionAction {
+	if x != nil {
+		return x.Action
+	}
+	return RedactionAction_REDACT_MASK
+}
+
 var File_auth_proto protoreflect.FileDescriptor
 
 const file_auth_proto_rawDesc = "" +
@@ -227,9 +940,84 @@ const file_auth_proto_rawDesc = "" +
 	"\rApiKeyRequest\x12\x1d\n" +
 	"\n" +
 	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x17\n" +
-	"\aapi_key\x18\x02 \x01(\tR\x06apiKey\"&\n" +
+	"\aapi_key\x18\x02 \x01(\tR\x06apiKey\"\x8b\x02\n" +
 	"\x0eApiKeyResponse\x12\x14\n" +
-	"\x05valid\x18\x01 \x01(\bR\x05valid2|\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x02 \x01(\tR\tprojectId\x12+\n" +
+	"\x06limits\x18\x03 \x01(\v2\x13.auth.ProjectLimitsR\x06limits\x12+\n" +
+	"\x06schema\x18\x04 \x01(\v2\x13.auth.ProjectSchemaR\x06schema\x125\n" +
+	"\n" +
+	"timestamps\x18\x05 \x01(\v2\x15.auth.TimestampPolicyR\n" +
+	"timestamps\x123\n" +
+	"\tredaction\x18\x06 \x01(\v2\x15.auth.RedactionPolicyR\tredaction\"\xa9\x01\n" +
+	"\rProjectLimits\x12*\n" +
+	"\x11events_per_second\x18\x01 \x01(\x03R\x0feventsPerSecond\x12(\n" +
+	"\x10bytes_per_second\x18\x02 \x01(\x03R\x0ebytesPerSecond\x12!\n" +
+	"\fdaily_events\x18\x03 \x01(\x03R\vdailyEvents\x12\x1f\n" +
+	"\vdaily_bytes\x18\x04 \x01(\x03R\n" +
+	"dailyBytes\"\x80\x03\n" +
+	"\rProjectSchema\x126\n" +
+	"\n" +
+	"strictness\x18\x01 \x01(\x0e2\x16.auth.SchemaStrictnessR\n" +
+	"strictness\x12#\n" +
+	"\rrequired_keys\x18\x02 \x03(\tR\frequiredKeys\x12\x1f\n" +
+	"\vevent_names\x18\x03 \x03(\tR\n" +
+	"eventNames\x12>\n" +
+	"\tkey_types\x18\x04 \x03(\v2!.auth.ProjectSchema.KeyTypesEntryR\bkeyTypes\x12\x19\n" +
+	"\bmax_keys\x18\x05 \x01(\x05R\amaxKeys\x12\"\n" +
+	"\rmax_key_bytes\x18\x06 \x01(\x05R\vmaxKeyBytes\x12&\n" +
+	"\x0fmax_value_bytes\x18\a \x01(\x05R\rmaxValueBytes\x1aJ\n" +
+	"\rKeyTypesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x05value\x18\x02 \x01(\v2\r.auth.KeyTypeR\x05value:\x028\x01\"O\n" +
+	"\aKeyType\x12#\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x0f.auth.ValueTypeR\x04type\x12\x1f\n" +
+	"\venum_values\x18\x02 \x03(\tR\n" +
+	"enumValues\"\x92\x01\n" +
+	"\x0fTimestampPolicy\x12'\n" +
+	"\x04mode\x18\x01 \x01(\x0e2\x13.auth.TimestampModeR\x04mode\x12(\n" +
+	"\x10max_past_seconds\x18\x02 \x01(\x03R\x0emaxPastSeconds\x12,\n" +
+	"\x12max_future_seconds\x18\x03 \x01(\x03R\x10maxFutureSeconds\"P\n" +
+	"\x0fRedactionPolicy\x12\x12\n" +
+	"\x04salt\x18\x01 \x01(\tR\x04salt\x12)\n" +
+	"\x05rules\x18\x02 \x03(\v2\x13.auth.RedactionRuleR\x05rules\"\xac\x01\n" +
+	"\rRedactionRule\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12*\n" +
+	"\bdetector\x18\x02 \x01(\x0e2\x0e.auth.DetectorR\bdetector\x12\x18\n" +
+	"\apattern\x18\x03 \x01(\tR\apattern\x12\x12\n" +
+	"\x04keys\x18\x04 \x03(\tR\x04keys\x12-\n" +
+	"\x06action\x18\x05 \x01(\x0e2\x15.auth.RedactionActionR\x06action*L\n" +
+	"\x10SchemaStrictness\x12\x0e\n" +
+	"\n" +
+	"SCHEMA_OFF\x10\x00\x12\x11\n" +
+	"\rSCHEMA_REJECT\x10\x01\x12\x15\n" +
+	"\x11SCHEMA_QUARANTINE\x10\x02*r\n" +
+	"\tValueType\x12\x10\n" +
+	"\fVALUE_STRING\x10\x00\x12\r\n" +
+	"\tVALUE_INT\x10\x01\x12\x0f\n" +
+	"\vVALUE_FLOAT\x10\x02\x12\x0e\n" +
+	"\n" +
+	"VALUE_BOOL\x10\x03\x12\x13\n" +
+	"\x0fVALUE_TIMESTAMP\x10\x04\x12\x0e\n" +
+	"\n" +
+	"VALUE_ENUM\x10\x05*N\n" +
+	"\rTimestampMode\x12\x12\n" +
+	"\x0eTIMESTAMP_KEEP\x10\x00\x12\x13\n" +
+	"\x0fTIMESTAMP_CLAMP\x10\x01\x12\x14\n" +
+	"\x10TIMESTAMP_REJECT\x10\x02*\x98\x01\n" +
+	"\bDetector\x12\x11\n" +
+	"\rDETECTOR_NONE\x10\x00\x12\x12\n" +
+	"\x0eDETECTOR_EMAIL\x10\x01\x12\x11\n" +
+	"\rDETECTOR_IPV4\x10\x02\x12\x11\n" +
+	"\rDETECTOR_IPV6\x10\x03\x12\x18\n" +
+	"\x14DETECTOR_CREDIT_CARD\x10\x04\x12\x10\n" +
+	"\fDETECTOR_JWT\x10\x05\x12\x13\n" +
+	"\x0fDETECTOR_BEARER\x10\x06*H\n" +
+	"\x0fRedactionAction\x12\x0f\n" +
+	"\vREDACT_MASK\x10\x00\x12\x0f\n" +
+	"\vREDACT_HASH\x10\x01\x12\x13\n" +
+	"\x0fREDACT_DROP_KEY\x10\x022|\n" +
 	"\vAuthService\x120\n" +
 	"\x05Login\x12\x12.auth.LoginRequest\x1a\x13.auth.LoginResponse\x12;\n" +
 	"\x0eValidateApiKey\x12\x13.auth.ApiKeyRequest\x1a\x14.auth.ApiKeyResponseB=Z;github.com/parishadmk/log-system-analysis/internal/api/authb\x06proto3"
@@ -246,23 +1034,48 @@ func file_auth_proto_rawDescGZIP() []byte {
 	return file_auth_proto_rawDescData
 }
 
-var file_auth_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_auth_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_auth_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_auth_proto_goTypes = []any{
-	(*LoginRequest)(nil),   // 0: auth.LoginRequest
-	(*LoginResponse)(nil),  // 1: auth.LoginResponse
-	(*ApiKeyRequest)(nil),  // 2: auth.ApiKeyRequest
-	(*ApiKeyResponse)(nil), // 3: auth.ApiKeyResponse
+	(SchemaStrictness)(0),   // 0: auth.SchemaStrictness
+	(ValueType)(0),          // 1: auth.ValueType
+	(TimestampMode)(0),      // 2: auth.TimestampMode
+	(Detector)(0),           // 3: auth.Detector
+	(RedactionAction)(0),    // 4: auth.RedactionAction
+	(*LoginRequest)(nil),    // 5: auth.LoginRequest
+	(*LoginResponse)(nil),   // 6: auth.LoginResponse
+	(*ApiKeyRequest)(nil),   // 7: auth.ApiKeyRequest
+	(*ApiKeyResponse)(nil),  // 8: auth.ApiKeyResponse
+	(*ProjectLimits)(nil),   // 9: auth.ProjectLimits
+	(*ProjectSchema)(nil),   // 10: auth.ProjectSchema
+	(*KeyType)(nil),         // 11: auth.KeyType
+	(*TimestampPolicy)(nil), // 12: auth.TimestampPolicy
+	(*RedactionPolicy)(nil), // 13: auth.RedactionPolicy
+	(*RedactionRule)(nil),   // 14: auth.RedactionRule
+	nil,                     // 15: auth.ProjectSchema.KeyTypesEntry
 }
 var file_auth_proto_depIdxs = []int32{
-	0, // 0: auth.AuthService.Login:input_type -> auth.LoginRequest
-	2, // 1: auth.AuthService.ValidateApiKey:input_type -> auth.ApiKeyRequest
-	1, // 2: auth.AuthService.Login:output_type -> auth.LoginResponse
-	3, // 3: auth.AuthService.ValidateApiKey:output_type -> auth.ApiKeyResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	9,  // 0: auth.ApiKeyResponse.limits:type_name -> auth.ProjectLimits
+	10, // 1: auth.ApiKeyResponse.schema:type_name -> auth.ProjectSchema
+	12, // 2: auth.ApiKeyResponse.timestamps:type_name -> auth.TimestampPolicy
+	13, // 3: auth.ApiKeyResponse.redaction:type_name -> auth.RedactionPolicy
+	0,  // 4: auth.ProjectSchema.strictness:type_name -> auth.SchemaStrictness
+	15, // 5: auth.ProjectSchema.key_types:type_name -> auth.ProjectSchema.KeyTypesEntry
+	1,  // 6: auth.KeyType.type:type_name -> auth.ValueType
+	2,  // 7: auth.TimestampPolicy.mode:type_name -> auth.TimestampMode
+	14, // 8: auth.RedactionPolicy.rules:type_name -> auth.RedactionRule
+	3,  // 9: auth.RedactionRule.detector:type_name -> auth.Detector
+	4,  // 10: auth.RedactionRule.action:type_name -> auth.RedactionAction
+	11, // 11: auth.ProjectSchema.KeyTypesEntry.value:type_name -> auth.KeyType
+	5,  // 12: auth.AuthService.Login:input_type -> auth.LoginRequest
+	7,  // 13: auth.AuthService.ValidateApiKey:input_type -> auth.ApiKeyRequest
+	6,  // 14: auth.AuthService.Login:output_type -> auth.LoginResponse
+	8,  // 15: auth.AuthService.ValidateApiKey:output_type -> auth.ApiKeyResponse
+	14, // [14:16] is the sub-list for method output_type
+	12, // [12:14] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_auth_proto_init() }
@@ -275,13 +1088,14 @@ func file_auth_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_auth_proto_rawDesc), len(file_auth_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      5,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_auth_proto_goTypes,
 		DependencyIndexes: file_auth_proto_depIdxs,
+		EnumInfos:         file_auth_proto_enumTypes,
 		MessageInfos:      file_auth_proto_msgTypes,
 	}.Build()
 	File_auth_proto = out.File
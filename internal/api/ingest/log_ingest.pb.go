@@ -82,10 +82,15 @@ func (x *LogRequest) GetPayload() *LogPayload {
 }
 
 type LogPayload struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix nanos
-	Data          map[string]string      `protobuf:"bytes,3,rep,name=data,proto3" json:"data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Name      string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Timestamp int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix nanos
+	Data      map[string]string      `protobuf:"bytes,3,rep,name=data,proto3" json:"data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Optional client-chosen unique ID. Events re-sent with the same ID (for
+	// example retries after a timeout) are stored only once.
+	EventId string `protobuf:"bytes,4,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	// Unix nanos at which the gateway received the event; set by the gateway.
+	ReceivedAt    int64 `protobuf:"varint,5,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -141,6 +146,20 @@ func (x *LogPayload) GetData() map[string]string {
 	return nil
 }
 
+func (x *LogPayload) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *LogPayload) GetReceivedAt() int64 {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return 0
+}
+
 type LogResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Accepted      bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
@@ -185,6 +204,360 @@ func (x *LogResponse) GetAccepted() bool {
 	return false
 }
 
+type LogBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	ApiKey        string                 `protobuf:"bytes,2,opt,name=api_key,json=apiKey,proto3" json:"api_key,omitempty"`
+	Payloads      []*LogPayload          `protobuf:"bytes,3,rep,name=payloads,proto3" json:"payloads,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogBatchRequest) Reset() {
+	*x = LogBatchRequest{}
+	mi := &file_log_ingest_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogBatchRequest) ProtoMessage() {}
+
+func (x *LogBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_log_ingest_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogBatchRequest.ProtoReflect.Descriptor instead.
+func (*LogBatchRequest) Descriptor() ([]byte, []int) {
+	return file_log_ingest_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LogBatchRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *LogBatchRequest) GetApiKey() string {
+	if x != nil {
+		return x.ApiKey
+	}
+	return ""
+}
+
+func (x *LogBatchRequest) GetPayloads() []*LogPayload {
+	if x != nil {
+		return x.Payloads
+	}
+	return nil
+}
+
+type LogResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"` // position in LogBatchRequest.payloads
+	Accepted      bool                   `protobuf:"varint,2,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"` // set when accepted is false
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogResult) Reset() {
+	*x = LogResult{}
+	mi := &file_log_ingest_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogResult) ProtoMessage() {}
+
+func (x *LogResult) ProtoReflect() protoreflect.Message {
+	mi := &file_log_ingest_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogResult.ProtoReflect.Descriptor instead.
+func (*LogResult) Descriptor() ([]byte, []int) {
+	return file_log_ingest_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LogResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *LogResult) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *LogResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type LogBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*LogResult           `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Accepted      int32                  `protobuf:"varint,2,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected      int32                  `protobuf:"varint,3,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogBatchResponse) Reset() {
+	*x = LogBatchResponse{}
+	mi := &file_log_ingest_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogBatchResponse) ProtoMessage() {}
+
+func (x *LogBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_log_ingest_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogBatchResponse.ProtoReflect.Descriptor instead.
+func (*LogBatchResponse) Descriptor() ([]byte, []int) {
+	return file_log_ingest_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LogBatchResponse) GetResults() []*LogResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *LogBatchResponse) GetAccepted() int32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *LogBatchResponse) GetRejected() int32 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+type StreamLogRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"` // required on the first message of a stream only
+	ApiKey        string                 `protobuf:"bytes,2,opt,name=api_key,json=apiKey,proto3" json:"api_key,omitempty"`          // required on the first message of a stream only
+	Sequence      uint64                 `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`                   // client-assigned, strictly increasing per agent
+	Payload       *LogPayload            `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamLogRequest) Reset() {
+	*x = StreamLogRequest{}
+	mi := &file_log_ingest_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamLogRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamLogRequest) ProtoMessage() {}
+
+func (x *StreamLogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_log_ingest_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamLogRequest.ProtoReflect.Descriptor instead.
+func (*StreamLogRequest) Descriptor() ([]byte, []int) {
+	return file_log_ingest_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StreamLogRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *StreamLogRequest) GetApiKey() string {
+	if x != nil {
+		return x.ApiKey
+	}
+	return ""
+}
+
+func (x *StreamLogRequest) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *StreamLogRequest) GetPayload() *LogPayload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type RejectedLog struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sequence      uint64                 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RejectedLog) Reset() {
+	*x = RejectedLog{}
+	mi := &file_log_ingest_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RejectedLog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RejectedLog) ProtoMessage() {}
+
+func (x *RejectedLog) ProtoReflect() protoreflect.Message {
+	mi := &file_log_ingest_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RejectedLog.ProtoReflect.Descriptor instead.
+func (*RejectedLog) Descriptor() ([]byte, []int) {
+	return file_log_ingest_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RejectedLog) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *RejectedLog) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type StreamLogAck struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// every event up to and including this sequence is durable in Kafka
+	LastSequence uint64 `protobuf:"varint,1,opt,name=last_sequence,json=lastSequence,proto3" json:"last_sequence,omitempty"`
+	// events since the previous ack that were rejected and will not be retried
+	Rejected      []*RejectedLog `protobuf:"bytes,2,rep,name=rejected,proto3" json:"rejected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamLogAck) Reset() {
+	*x = StreamLogAck{}
+	mi := &file_log_ingest_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamLogAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamLogAck) ProtoMessage() {}
+
+func (x *StreamLogAck) ProtoReflect() protoreflect.Message {
+	mi := &file_log_ingest_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamLogAck.ProtoReflect.Descriptor instead.
+func (*StreamLogAck) Descriptor() ([]byte, []int) {
+	return file_log_ingest_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StreamLogAck) GetLastSequence() uint64 {
+	if x != nil {
+		return x.LastSequence
+	}
+	return 0
+}
+
+func (x *StreamLogAck) GetRejected() []*RejectedLog {
+	if x != nil {
+		return x.Rejected
+	}
+	return nil
+}
+
 var File_log_ingest_proto protoreflect.FileDescriptor
 
 const file_log_ingest_proto_rawDesc = "" +
@@ -195,19 +568,50 @@ const file_log_ingest_proto_rawDesc = "" +
 	"\n" +
 	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x17\n" +
 	"\aapi_key\x18\x02 \x01(\tR\x06apiKey\x12,\n" +
-	"\apayload\x18\x03 \x01(\v2\x12.ingest.LogPayloadR\apayload\"\xa9\x01\n" +
+	"\apayload\x18\x03 \x01(\v2\x12.ingest.LogPayloadR\apayload\"\xe5\x01\n" +
 	"\n" +
 	"LogPayload\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
 	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x120\n" +
-	"\x04data\x18\x03 \x03(\v2\x1c.ingest.LogPayload.DataEntryR\x04data\x1a7\n" +
+	"\x04data\x18\x03 \x03(\v2\x1c.ingest.LogPayload.DataEntryR\x04data\x12\x19\n" +
+	"\bevent_id\x18\x04 \x01(\tR\aeventId\x12\x1f\n" +
+	"\vreceived_at\x18\x05 \x01(\x03R\n" +
+	"receivedAt\x1a7\n" +
 	"\tDataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\")\n" +
 	"\vLogResponse\x12\x1a\n" +
-	"\baccepted\x18\x01 \x01(\bR\baccepted2C\n" +
+	"\baccepted\x18\x01 \x01(\bR\baccepted\"y\n" +
+	"\x0fLogBatchRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x17\n" +
+	"\aapi_key\x18\x02 \x01(\tR\x06apiKey\x12.\n" +
+	"\bpayloads\x18\x03 \x03(\v2\x12.ingest.LogPayloadR\bpayloads\"S\n" +
+	"\tLogResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x1a\n" +
+	"\baccepted\x18\x02 \x01(\bR\baccepted\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"w\n" +
+	"\x10LogBatchResponse\x12+\n" +
+	"\aresults\x18\x01 \x03(\v2\x11.ingest.LogResultR\aresults\x12\x1a\n" +
+	"\baccepted\x18\x02 \x01(\x05R\baccepted\x12\x1a\n" +
+	"\brejected\x18\x03 \x01(\x05R\brejected\"\x94\x01\n" +
+	"\x10StreamLogRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x17\n" +
+	"\aapi_key\x18\x02 \x01(\tR\x06apiKey\x12\x1a\n" +
+	"\bsequence\x18\x03 \x01(\x04R\bsequence\x12,\n" +
+	"\apayload\x18\x04 \x01(\v2\x12.ingest.LogPayloadR\apayload\"?\n" +
+	"\vRejectedLog\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"d\n" +
+	"\fStreamLogAck\x12#\n" +
+	"\rlast_sequence\x18\x01 \x01(\x04R\flastSequence\x12/\n" +
+	"\brejected\x18\x02 \x03(\v2\x13.ingest.RejectedLogR\brejected2\xc8\x01\n" +
 	"\rIngestService\x122\n" +
-	"\aSendLog\x12\x12.ingest.LogRequest\x1a\x13.ingest.LogResponseB?Z=github.com/parishadmk/log-system-analysis/internal/api/ingestb\x06proto3"
+	"\aSendLog\x12\x12.ingest.LogRequest\x1a\x13.ingest.LogResponse\x12A\n" +
+	"\fSendLogBatch\x12\x17.ingest.LogBatchRequest\x1a\x18.ingest.LogBatchResponse\x12@\n" +
+	"\n" +
+	"StreamLogs\x12\x18.ingest.StreamLogRequest\x1a\x14.ingest.StreamLogAck(\x010\x01B?Z=github.com/parishadmk/log-system-analysis/internal/api/ingestb\x06proto3"
 
 var (
 	file_log_ingest_proto_rawDescOnce sync.Once
@@ -221,23 +625,37 @@ func file_log_ingest_proto_rawDescGZIP() []byte {
 	return file_log_ingest_proto_rawDescData
 }
 
-var file_log_ingest_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_log_ingest_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_log_ingest_proto_goTypes = []any{
-	(*LogRequest)(nil),  // 0: ingest.LogRequest
-	(*LogPayload)(nil),  // 1: ingest.LogPayload
-	(*LogResponse)(nil), // 2: ingest.LogResponse
-	nil,                 // 3: ingest.LogPayload.DataEntry
+	(*LogRequest)(nil),       // 0: ingest.LogRequest
+	(*LogPayload)(nil),       // 1: ingest.LogPayload
+	(*LogResponse)(nil),      // 2: ingest.LogResponse
+	(*LogBatchRequest)(nil),  // 3: ingest.LogBatchRequest
+	(*LogResult)(nil),        // 4: ingest.LogResult
+	(*LogBatchResponse)(nil), // 5: ingest.LogBatchResponse
+	(*StreamLogRequest)(nil), // 6: ingest.StreamLogRequest
+	(*RejectedLog)(nil),      // 7: ingest.RejectedLog
+	(*StreamLogAck)(nil),     // 8: ingest.StreamLogAck
+	nil,                      // 9: ingest.LogPayload.DataEntry
 }
 var file_log_ingest_proto_depIdxs = []int32{
 	1, // 0: ingest.LogRequest.payload:type_name -> ingest.LogPayload
-	3, // 1: ingest.LogPayload.data:type_name -> ingest.LogPayload.DataEntry
-	0, // 2: ingest.IngestService.SendLog:input_type -> ingest.LogRequest
-	2, // 3: ingest.IngestService.SendLog:output_type -> ingest.LogResponse
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	9, // 1: ingest.LogPayload.data:type_name -> ingest.LogPayload.DataEntry
+	1, // 2: ingest.LogBatchRequest.payloads:type_name -> ingest.LogPayload
+	4, // 3: ingest.LogBatchResponse.results:type_name -> ingest.LogResult
+	1, // 4: ingest.StreamLogRequest.payload:type_name -> ingest.LogPayload
+	7, // 5: ingest.StreamLogAck.rejected:type_name -> ingest.RejectedLog
+	0, // 6: ingest.IngestService.SendLog:input_type -> ingest.LogRequest
+	3, // 7: ingest.IngestService.SendLogBatch:input_type -> ingest.LogBatchRequest
+	6, // 8: ingest.IngestService.StreamLogs:input_type -> ingest.StreamLogRequest
+	2, // 9: ingest.IngestService.SendLog:output_type -> ingest.LogResponse
+	5, // 10: ingest.IngestService.SendLogBatch:output_type -> ingest.LogBatchResponse
+	8, // 11: ingest.IngestService.StreamLogs:output_type -> ingest.StreamLogAck
+	9, // [9:12] is the sub-list for method output_type
+	6, // [6:9] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_log_ingest_proto_init() }
@@ -251,7 +669,7 @@ func file_log_ingest_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_log_ingest_proto_rawDesc), len(file_log_ingest_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
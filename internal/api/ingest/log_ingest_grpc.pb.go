@@ -19,7 +19,9 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	IngestService_SendLog_FullMethodName = "/ingest.IngestService/SendLog"
+	IngestService_SendLog_FullMethodName      = "/ingest.IngestService/SendLog"
+	IngestService_SendLogBatch_FullMethodName = "/ingest.IngestService/SendLogBatch"
+	IngestService_StreamLogs_FullMethodName   = "/ingest.IngestService/StreamLogs"
 )
 
 // IngestServiceClient is the client API for IngestService service.
@@ -27,6 +29,11 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type IngestServiceClient interface {
 	SendLog(ctx context.Context, in *LogRequest, opts ...grpc.CallOption) (*LogResponse, error)
+	SendLogBatch(ctx context.Context, in *LogBatchRequest, opts ...grpc.CallOption) (*LogBatchResponse, error)
+	// StreamLogs keeps one authenticated stream open for long-lived agents.
+	// The gateway acks periodically with the last durable sequence number so
+	// that an agent can resume after a disconnect.
+	StreamLogs(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamLogRequest, StreamLogAck], error)
 }
 
 type ingestServiceClient struct {
@@ -47,11 +54,39 @@ func (c *ingestServiceClient) SendLog(ctx context.Context, in *LogRequest, opts
 	return out, nil
 }
 
+func (c *ingestServiceClient) SendLogBatch(ctx context.Context, in *LogBatchRequest, opts ...grpc.CallOption) (*LogBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogBatchResponse)
+	err := c.cc.Invoke(ctx, IngestService_SendLogBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ingestServiceClient) StreamLogs(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamLogRequest, StreamLogAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &IngestService_ServiceDesc.Streams[0], IngestService_StreamLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamLogRequest, StreamLogAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IngestService_StreamLogsClient = grpc.BidiStreamingClient[StreamLogRequest, StreamLogAck]
+
 // IngestServiceServer is the server API for IngestService service.
 // All implementations must embed UnimplementedIngestServiceServer
 // for forward compatibility.
 type IngestServiceServer interface {
 	SendLog(context.Context, *LogRequest) (*LogResponse, error)
+	SendLogBatch(context.Context, *LogBatchRequest) (*LogBatchResponse, error)
+	// StreamLogs keeps one authenticated stream open for long-lived agents.
+	// The gateway acks periodically with the last durable sequence number so
+	// that an agent can resume after a disconnect.
+	StreamLogs(grpc.BidiStreamingServer[StreamLogRequest, StreamLogAck]) error
 	mustEmbedUnimplementedIngestServiceServer()
 }
 
@@ -65,6 +100,12 @@ type UnimplementedIngestServiceServer struct{}
 func (UnimplementedIngestServiceServer) SendLog(context.Context, *LogRequest) (*LogResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendLog not implemented")
 }
+func (UnimplementedIngestServiceServer) SendLogBatch(context.Context, *LogBatchRequest) (*LogBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendLogBatch not implemented")
+}
+func (UnimplementedIngestServiceServer) StreamLogs(grpc.BidiStreamingServer[StreamLogRequest, StreamLogAck]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamLogs not implemented")
+}
 func (UnimplementedIngestServiceServer) mustEmbedUnimplementedIngestServiceServer() {}
 func (UnimplementedIngestServiceServer) testEmbeddedByValue()                       {}
 
@@ -104,6 +145,31 @@ func _IngestService_SendLog_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _IngestService_SendLogBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IngestServiceServer).SendLogBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IngestService_SendLogBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngestServiceServer).SendLogBatch(ctx, req.(*LogBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IngestService_StreamLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(IngestServiceServer).StreamLogs(&grpc.GenericServerStream[StreamLogRequest, StreamLogAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IngestService_StreamLogsServer = grpc.BidiStreamingServer[StreamLogRequest, StreamLogAck]
+
 // IngestService_ServiceDesc is the grpc.ServiceDesc for IngestService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,7 +181,18 @@ var IngestService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SendLog",
 			Handler:    _IngestService_SendLog_Handler,
 		},
+		{
+			MethodName: "SendLogBatch",
+			Handler:    _IngestService_SendLogBatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamLogs",
+			Handler:       _IngestService_StreamLogs_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "log_ingest.proto",
 }
@@ -0,0 +1,314 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: loki_push.proto
+
+package loki
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PushRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Streams       []*StreamAdapter       `protobuf:"bytes,1,rep,name=streams,proto3" json:"streams,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PushRequest) Reset() {
+	*x = PushRequest{}
+	mi := &file_loki_push_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushRequest) ProtoMessage() {}
+
+func (x *PushRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_loki_push_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushRequest.ProtoReflect.Descriptor instead.
+func (*PushRequest) Descriptor() ([]byte, []int) {
+	return file_loki_push_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PushRequest) GetStreams() []*StreamAdapter {
+	if x != nil {
+		return x.Streams
+	}
+	return nil
+}
+
+type StreamAdapter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Labels        string                 `protobuf:"bytes,1,opt,name=labels,proto3" json:"labels,omitempty"` // Prometheus label set, e.g. {job="varlogs", host="a"}
+	Entries       []*EntryAdapter        `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
+	Hash          uint64                 `protobuf:"varint,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamAdapter) Reset() {
+	*x = StreamAdapter{}
+	mi := &file_loki_push_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamAdapter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamAdapter) ProtoMessage() {}
+
+func (x *StreamAdapter) ProtoReflect() protoreflect.Message {
+	mi := &file_loki_push_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamAdapter.ProtoReflect.Descriptor instead.
+func (*StreamAdapter) Descriptor() ([]byte, []int) {
+	return file_loki_push_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StreamAdapter) GetLabels() string {
+	if x != nil {
+		return x.Labels
+	}
+	return ""
+}
+
+func (x *StreamAdapter) GetEntries() []*EntryAdapter {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *StreamAdapter) GetHash() uint64 {
+	if x != nil {
+		return x.Hash
+	}
+	return 0
+}
+
+type EntryAdapter struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Line               string                 `protobuf:"bytes,2,opt,name=line,proto3" json:"line,omitempty"`
+	StructuredMetadata []*LabelPairAdapter    `protobuf:"bytes,3,rep,name=structuredMetadata,proto3" json:"structuredMetadata,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *EntryAdapter) Reset() {
+	*x = EntryAdapter{}
+	mi := &file_loki_push_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntryAdapter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntryAdapter) ProtoMessage() {}
+
+func (x *EntryAdapter) ProtoReflect() protoreflect.Message {
+	mi := &file_loki_push_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntryAdapter.ProtoReflect.Descriptor instead.
+func (*EntryAdapter) Descriptor() ([]byte, []int) {
+	return file_loki_push_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *EntryAdapter) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *EntryAdapter) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+func (x *EntryAdapter) GetStructuredMetadata() []*LabelPairAdapter {
+	if x != nil {
+		return x.StructuredMetadata
+	}
+	return nil
+}
+
+type LabelPairAdapter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LabelPairAdapter) Reset() {
+	*x = LabelPairAdapter{}
+	mi := &file_loki_push_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LabelPairAdapter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LabelPairAdapter) ProtoMessage() {}
+
+func (x *LabelPairAdapter) ProtoReflect() protoreflect.Message {
+	mi := &file_loki_push_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LabelPairAdapter.ProtoReflect.Descriptor instead.
+func (*LabelPairAdapter) Descriptor() ([]byte, []int) {
+	return file_loki_push_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LabelPairAdapter) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LabelPairAdapter) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+var File_loki_push_proto protoreflect.FileDescriptor
+
+const file_loki_push_proto_rawDesc = "" +
+	"\n" +
+	"\x0floki_push.proto\x12\blogproto\x1a\x1fgoogle/protobuf/timestamp.proto\"@\n" +
+	"\vPushRequest\x121\n" +
+	"\astreams\x18\x01 \x03(\v2\x17.logproto.StreamAdapterR\astreams\"m\n" +
+	"\rStreamAdapter\x12\x16\n" +
+	"\x06labels\x18\x01 \x01(\tR\x06labels\x120\n" +
+	"\aentries\x18\x02 \x03(\v2\x16.logproto.EntryAdapterR\aentries\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\x04R\x04hash\"\xa8\x01\n" +
+	"\fEntryAdapter\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\tR\x04line\x12J\n" +
+	"\x12structuredMetadata\x18\x03 \x03(\v2\x1a.logproto.LabelPairAdapterR\x12structuredMetadata\"<\n" +
+	"\x10LabelPairAdapter\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05valueB=Z;github.com/parishadmk/log-system-analysis/internal/api/lokib\x06proto3"
+
+var (
+	file_loki_push_proto_rawDescOnce sync.Once
+	file_loki_push_proto_rawDescData []byte
+)
+
+func file_loki_push_proto_rawDescGZIP() []byte {
+	file_loki_push_proto_rawDescOnce.Do(func() {
+		file_loki_push_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_loki_push_proto_rawDesc), len(file_loki_push_proto_rawDesc)))
+	})
+	return file_loki_push_proto_rawDescData
+}
+
+var file_loki_push_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_loki_push_proto_goTypes = []any{
+	(*PushRequest)(nil),           // 0: logproto.PushRequest
+	(*StreamAdapter)(nil),         // 1: logproto.StreamAdapter
+	(*EntryAdapter)(nil),          // 2: logproto.EntryAdapter
+	(*LabelPairAdapter)(nil),      // 3: logproto.LabelPairAdapter
+	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+}
+var file_loki_push_proto_depIdxs = []int32{
+	1, // 0: logproto.PushRequest.streams:type_name -> logproto.StreamAdapter
+	2, // 1: logproto.StreamAdapter.entries:type_name -> logproto.EntryAdapter
+	4, // 2: logproto.EntryAdapter.timestamp:type_name -> google.protobuf.Timestamp
+	3, // 3: logproto.EntryAdapter.structuredMetadata:type_name -> logproto.LabelPairAdapter
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_loki_push_proto_init() }
+func file_loki_push_proto_init() {
+	if File_loki_push_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_loki_push_proto_rawDesc), len(file_loki_push_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_loki_push_proto_goTypes,
+		DependencyIndexes: file_loki_push_proto_depIdxs,
+		MessageInfos:      file_loki_push_proto_msgTypes,
+	}.Build()
+	File_loki_push_proto = out.File
+	file_loki_push_proto_goTypes = nil
+	file_loki_push_proto_depIdxs = nil
+}
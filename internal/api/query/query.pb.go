@@ -22,11 +22,24 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// token may be left empty when the JWT is sent as "authorization: Bearer"
+// metadata instead.
 type SearchRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
-	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"` // JWT
-	Filters       map[string]string      `protobuf:"bytes,3,rep,name=filters,proto3" json:"filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId  string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Token      string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`                                                                               // JWT
+	Filters    map[string]string      `protobuf:"bytes,3,rep,name=filters,proto3" json:"filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // data[key] = value; shorthand for op "="
+	Conditions []*Filter              `protobuf:"bytes,4,rep,name=conditions,proto3" json:"conditions,omitempty"`                                                                     // ANDed with filters
+	// Time window over event timestamps: RFC 3339, Unix nanos, "now", or
+	// relative to now like "-15m", "-6h", "-7d". Empty means unbounded.
+	From       string   `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	To         string   `protobuf:"bytes,6,opt,name=to,proto3" json:"to,omitempty"`
+	EventNames []string `protobuf:"bytes,7,rep,name=event_names,json=eventNames,proto3" json:"event_names,omitempty"` // only these events; empty means all
+	// "count", "last_seen" or "name", prefixed with "-" for descending;
+	// defaults to "-count"
+	Sort          string `protobuf:"bytes,8,opt,name=sort,proto3" json:"sort,omitempty"`
+	Limit         int32  `protobuf:"varint,9,opt,name=limit,proto3" json:"limit,omitempty"` // defaults to 100, at most 1000
+	Offset        int32  `protobuf:"varint,10,opt,name=offset,proto3" json:"offset,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -82,18 +95,140 @@ func (x *SearchRequest) GetFilters() map[string]string {
 	return nil
 }
 
+func (x *SearchRequest) GetConditions() []*Filter {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetEventNames() []string {
+	if x != nil {
+		return x.EventNames
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// A condition on one data key, which must be one of the project's
+// searchable_keys.
+type Filter struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// "=", "!=", "in", "not_in", "prefix", "contains", "regex",
+	// ">", ">=", "<", "<=" (numeric), "exists" or "not_exists"
+	Op            string   `protobuf:"bytes,2,opt,name=op,proto3" json:"op,omitempty"`
+	Value         string   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Values        []string `protobuf:"bytes,4,rep,name=values,proto3" json:"values,omitempty"` // for "in" and "not_in"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Filter) Reset() {
+	*x = Filter{}
+	mi := &file_query_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Filter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Filter) ProtoMessage() {}
+
+func (x *Filter) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Filter.ProtoReflect.Descriptor instead.
+func (*Filter) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Filter) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Filter) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *Filter) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Filter) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
 type EventSummary struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	LastSeen      int64                  `protobuf:"varint,2,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
 	Count         int64                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	LastReceived  int64                  `protobuf:"varint,4,opt,name=last_received,json=lastReceived,proto3" json:"last_received,omitempty"` // latest gateway receive time, Unix nanos
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *EventSummary) Reset() {
 	*x = EventSummary{}
-	mi := &file_query_proto_msgTypes[1]
+	mi := &file_query_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -105,7 +240,7 @@ func (x *EventSummary) String() string {
 func (*EventSummary) ProtoMessage() {}
 
 func (x *EventSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_query_proto_msgTypes[1]
+	mi := &file_query_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -118,7 +253,7 @@ func (x *EventSummary) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EventSummary.ProtoReflect.Descriptor instead.
 func (*EventSummary) Descriptor() ([]byte, []int) {
-	return file_query_proto_rawDescGZIP(), []int{1}
+	return file_query_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *EventSummary) GetName() string {
@@ -142,16 +277,24 @@ func (x *EventSummary) GetCount() int64 {
 	return 0
 }
 
+func (x *EventSummary) GetLastReceived() int64 {
+	if x != nil {
+		return x.LastReceived
+	}
+	return 0
+}
+
 type SearchResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Events        []*EventSummary        `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"` // number of matching event names, ignoring limit/offset
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SearchResponse) Reset() {
 	*x = SearchResponse{}
-	mi := &file_query_proto_msgTypes[2]
+	mi := &file_query_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -163,7 +306,7 @@ func (x *SearchResponse) String() string {
 func (*SearchResponse) ProtoMessage() {}
 
 func (x *SearchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_query_proto_msgTypes[2]
+	mi := &file_query_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -176,7 +319,7 @@ func (x *SearchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
 func (*SearchResponse) Descriptor() ([]byte, []int) {
-	return file_query_proto_rawDescGZIP(), []int{2}
+	return file_query_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *SearchResponse) GetEvents() []*EventSummary {
@@ -186,19 +329,27 @@ func (x *SearchResponse) GetEvents() []*EventSummary {
 	return nil
 }
 
+func (x *SearchResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
 type EventDetailRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
 	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
 	EventName     string                 `protobuf:"bytes,3,opt,name=event_name,json=eventName,proto3" json:"event_name,omitempty"`
-	Cursor        string                 `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"` // optional paging cursor
+	Cursor        string                 `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`                      // next_cursor or prev_cursor of a previous page; empty for the newest
+	PageSize      int32                  `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"` // defaults to 1, at most 100
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *EventDetailRequest) Reset() {
 	*x = EventDetailRequest{}
-	mi := &file_query_proto_msgTypes[3]
+	mi := &file_query_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -210,7 +361,7 @@ func (x *EventDetailRequest) String() string {
 func (*EventDetailRequest) ProtoMessage() {}
 
 func (x *EventDetailRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_query_proto_msgTypes[3]
+	mi := &file_query_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -223,7 +374,7 @@ func (x *EventDetailRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EventDetailRequest.ProtoReflect.Descriptor instead.
 func (*EventDetailRequest) Descriptor() ([]byte, []int) {
-	return file_query_proto_rawDescGZIP(), []int{3}
+	return file_query_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *EventDetailRequest) GetProjectId() string {
@@ -254,18 +405,29 @@ func (x *EventDetailRequest) GetCursor() string {
 	return ""
 }
 
+func (x *EventDetailRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+// Occurrences of an event, newest first.
 type EventDetailResponse struct {
 	state  protoimpl.MessageState `protogen:"open.v1"`
-	Cursor string                 `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Cursor string                 `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"` // same as next_cursor
 	// qualify with the package from log_ingest.proto
-	Entry         *ingest.LogPayload `protobuf:"bytes,2,opt,name=entry,proto3" json:"entry,omitempty"`
+	Entry         *ingest.LogPayload   `protobuf:"bytes,2,opt,name=entry,proto3" json:"entry,omitempty"` // first of entries
+	Entries       []*ingest.LogPayload `protobuf:"bytes,3,rep,name=entries,proto3" json:"entries,omitempty"`
+	NextCursor    string               `protobuf:"bytes,4,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"` // older occurrences; empty on the last page
+	PrevCursor    string               `protobuf:"bytes,5,opt,name=prev_cursor,json=prevCursor,proto3" json:"prev_cursor,omitempty"` // newer occurrences; empty on the first page
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *EventDetailResponse) Reset() {
 	*x = EventDetailResponse{}
-	mi := &file_query_proto_msgTypes[4]
+	mi := &file_query_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -277,7 +439,7 @@ func (x *EventDetailResponse) String() string {
 func (*EventDetailResponse) ProtoMessage() {}
 
 func (x *EventDetailResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_query_proto_msgTypes[4]
+	mi := &file_query_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -290,7 +452,7 @@ func (x *EventDetailResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EventDetailResponse.ProtoReflect.Descriptor instead.
 func (*EventDetailResponse) Descriptor() ([]byte, []int) {
-	return file_query_proto_rawDescGZIP(), []int{4}
+	return file_query_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *EventDetailResponse) GetCursor() string {
@@ -307,38 +469,1878 @@ func (x *EventDetailResponse) GetEntry() *ingest.LogPayload {
 	return nil
 }
 
-var File_query_proto protoreflect.FileDescriptor
+func (x *EventDetailResponse) GetEntries() []*ingest.LogPayload {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
 
-const file_query_proto_rawDesc = "" +
-	"\n" +
-	"\vquery.proto\x12\x05query\x1a\x10log_ingest.proto\"\xbd\x01\n" +
-	"\rSearchRequest\x12\x1d\n" +
-	"\n" +
-	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x14\n" +
-	"\x05token\x18\x02 \x01(\tR\x05token\x12;\n" +
-	"\afilters\x18\x03 \x03(\v2!.query.SearchRequest.FiltersEntryR\afilters\x1a:\n" +
-	"\fFiltersEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"U\n" +
-	"\fEventSummary\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
-	"\tlast_seen\x18\x02 \x01(\x03R\blastSeen\x12\x14\n" +
-	"\x05count\x18\x03 \x01(\x03R\x05count\"=\n" +
-	"\x0eSearchResponse\x12+\n" +
-	"\x06events\x18\x01 \x03(\v2\x13.query.EventSummaryR\x06events\"\x80\x01\n" +
-	"\x12EventDetailRequest\x12\x1d\n" +
-	"\n" +
-	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x14\n" +
-	"\x05token\x18\x02 \x01(\tR\x05token\x12\x1d\n" +
-	"\n" +
-	"event_name\x18\x03 \x01(\tR\teventName\x12\x16\n" +
-	"\x06cursor\x18\x04 \x01(\tR\x06cursor\"W\n" +
-	"\x13EventDetailResponse\x12\x16\n" +
-	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x12(\n" +
-	"\x05entry\x18\x02 \x01(\v2\x12.ingest.LogPayloadR\x05entry2\x94\x01\n" +
+func (x *EventDetailResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *EventDetailResponse) GetPrevCursor() string {
+	if x != nil {
+		return x.PrevCursor
+	}
+	return ""
+}
+
+// Event counts over time. Filters, from/to and event_names are as in
+// SearchRequest; the window defaults to the 24 hours before to (or now).
+type HistogramRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId  string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Token      string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Filters    map[string]string      `protobuf:"bytes,3,rep,name=filters,proto3" json:"filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Conditions []*Filter              `protobuf:"bytes,4,rep,name=conditions,proto3" json:"conditions,omitempty"`
+	From       string                 `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	To         string                 `protobuf:"bytes,6,opt,name=to,proto3" json:"to,omitempty"`
+	EventNames []string               `protobuf:"bytes,7,rep,name=event_names,json=eventNames,proto3" json:"event_names,omitempty"`
+	// bucket width such as "30s", "5m", "1h" or "1d"; empty picks one giving
+	// about 60 buckets. At most 1000 buckets.
+	Interval string `protobuf:"bytes,8,opt,name=interval,proto3" json:"interval,omitempty"`
+	// "event_name" or "data.<key>" for a searchable key: one series per
+	// value. Empty gives a single series.
+	SplitBy       string `protobuf:"bytes,9,opt,name=split_by,json=splitBy,proto3" json:"split_by,omitempty"`
+	SplitLimit    int32  `protobuf:"varint,10,opt,name=split_limit,json=splitLimit,proto3" json:"split_limit,omitempty"` // series kept, by total count; defaults to 10, at most 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistogramRequest) Reset() {
+	*x = HistogramRequest{}
+	mi := &file_query_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistogramRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistogramRequest) ProtoMessage() {}
+
+func (x *HistogramRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistogramRequest.ProtoReflect.Descriptor instead.
+func (*HistogramRequest) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *HistogramRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *HistogramRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *HistogramRequest) GetFilters() map[string]string {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *HistogramRequest) GetConditions() []*Filter {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
+func (x *HistogramRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *HistogramRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *HistogramRequest) GetEventNames() []string {
+	if x != nil {
+		return x.EventNames
+	}
+	return nil
+}
+
+func (x *HistogramRequest) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *HistogramRequest) GetSplitBy() string {
+	if x != nil {
+		return x.SplitBy
+	}
+	return ""
+}
+
+func (x *HistogramRequest) GetSplitLimit() int32 {
+	if x != nil {
+		return x.SplitLimit
+	}
+	return 0
+}
+
+type HistogramResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interval      int64                  `protobuf:"varint,1,opt,name=interval,proto3" json:"interval,omitempty"`      // bucket width, nanoseconds
+	Buckets       []int64                `protobuf:"varint,2,rep,packed,name=buckets,proto3" json:"buckets,omitempty"` // bucket start times, Unix nanos
+	Series        []*HistogramSeries     `protobuf:"bytes,3,rep,name=series,proto3" json:"series,omitempty"`           // by total count, descending
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistogramResponse) Reset() {
+	*x = HistogramResponse{}
+	mi := &file_query_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistogramResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistogramResponse) ProtoMessage() {}
+
+func (x *HistogramResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistogramResponse.ProtoReflect.Descriptor instead.
+func (*HistogramResponse) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HistogramResponse) GetInterval() int64 {
+	if x != nil {
+		return x.Interval
+	}
+	return 0
+}
+
+func (x *HistogramResponse) GetBuckets() []int64 {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *HistogramResponse) GetSeries() []*HistogramSeries {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+type HistogramSeries struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`           // split value; empty without split_by
+	Other         bool                   `protobuf:"varint,2,opt,name=other,proto3" json:"other,omitempty"`          // counts of all values beyond split_limit
+	Counts        []int64                `protobuf:"varint,3,rep,packed,name=counts,proto3" json:"counts,omitempty"` // one per bucket
+	Total         int64                  `protobuf:"varint,4,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistogramSeries) Reset() {
+	*x = HistogramSeries{}
+	mi := &file_query_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistogramSeries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistogramSeries) ProtoMessage() {}
+
+func (x *HistogramSeries) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistogramSeries.ProtoReflect.Descriptor instead.
+func (*HistogramSeries) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HistogramSeries) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *HistogramSeries) GetOther() bool {
+	if x != nil {
+		return x.Other
+	}
+	return false
+}
+
+func (x *HistogramSeries) GetCounts() []int64 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+func (x *HistogramSeries) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+// Value breakdowns of data keys over the events matching a search. Filters,
+// from/to and event_names are as in SearchRequest.
+type FacetsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Filters       map[string]string      `protobuf:"bytes,3,rep,name=filters,proto3" json:"filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Conditions    []*Filter              `protobuf:"bytes,4,rep,name=conditions,proto3" json:"conditions,omitempty"`
+	From          string                 `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,6,opt,name=to,proto3" json:"to,omitempty"`
+	EventNames    []string               `protobuf:"bytes,7,rep,name=event_names,json=eventNames,proto3" json:"event_names,omitempty"`
+	Keys          []string               `protobuf:"bytes,8,rep,name=keys,proto3" json:"keys,omitempty"`    // searchable keys to break down, at most 20
+	Limit         int32                  `protobuf:"varint,9,opt,name=limit,proto3" json:"limit,omitempty"` // top values per key; defaults to 10, at most 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FacetsRequest) Reset() {
+	*x = FacetsRequest{}
+	mi := &file_query_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FacetsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FacetsRequest) ProtoMessage() {}
+
+func (x *FacetsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FacetsRequest.ProtoReflect.Descriptor instead.
+func (*FacetsRequest) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FacetsRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *FacetsRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *FacetsRequest) GetFilters() map[string]string {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *FacetsRequest) GetConditions() []*Filter {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
+func (x *FacetsRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *FacetsRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *FacetsRequest) GetEventNames() []string {
+	if x != nil {
+		return x.EventNames
+	}
+	return nil
+}
+
+func (x *FacetsRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *FacetsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type FacetsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int64                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`  // matching events
+	Facets        []*Facet               `protobuf:"bytes,2,rep,name=facets,proto3" json:"facets,omitempty"` // in the order of keys
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FacetsResponse) Reset() {
+	*x = FacetsResponse{}
+	mi := &file_query_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FacetsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FacetsResponse) ProtoMessage() {}
+
+func (x *FacetsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FacetsResponse.ProtoReflect.Descriptor instead.
+func (*FacetsResponse) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *FacetsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *FacetsResponse) GetFacets() []*Facet {
+	if x != nil {
+		return x.Facets
+	}
+	return nil
+}
+
+type Facet struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Values        []*FacetValue          `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`      // by count, descending
+	Distinct      int64                  `protobuf:"varint,3,opt,name=distinct,proto3" json:"distinct,omitempty"` // distinct values; approximate for large cardinalities
+	Missing       int64                  `protobuf:"varint,4,opt,name=missing,proto3" json:"missing,omitempty"`   // matching events without the key
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Facet) Reset() {
+	*x = Facet{}
+	mi := &file_query_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Facet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Facet) ProtoMessage() {}
+
+func (x *Facet) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Facet.ProtoReflect.Descriptor instead.
+func (*Facet) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Facet) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Facet) GetValues() []*FacetValue {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *Facet) GetDistinct() int64 {
+	if x != nil {
+		return x.Distinct
+	}
+	return 0
+}
+
+func (x *Facet) GetMissing() int64 {
+	if x != nil {
+		return x.Missing
+	}
+	return 0
+}
+
+type FacetValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FacetValue) Reset() {
+	*x = FacetValue{}
+	mi := &file_query_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FacetValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FacetValue) ProtoMessage() {}
+
+func (x *FacetValue) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FacetValue.ProtoReflect.Descriptor instead.
+func (*FacetValue) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FacetValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *FacetValue) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// Numeric statistics of data keys over the events matching a search.
+// Filters, from/to and event_names are as in SearchRequest.
+type AggregateRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId  string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Token      string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Filters    map[string]string      `protobuf:"bytes,3,rep,name=filters,proto3" json:"filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Conditions []*Filter              `protobuf:"bytes,4,rep,name=conditions,proto3" json:"conditions,omitempty"`
+	From       string                 `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	To         string                 `protobuf:"bytes,6,opt,name=to,proto3" json:"to,omitempty"`
+	EventNames []string               `protobuf:"bytes,7,rep,name=event_names,json=eventNames,proto3" json:"event_names,omitempty"`
+	Metrics    []*Metric              `protobuf:"bytes,8,rep,name=metrics,proto3" json:"metrics,omitempty"` // at most 20
+	// "event_name" or "data.<key>" for searchable keys
+	GroupBy []string `protobuf:"bytes,9,rep,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	// also group by time buckets of this width, like "5m" or "1h"; "auto"
+	// picks one giving about 60 buckets over from/to (default last 24 hours)
+	Interval      string `protobuf:"bytes,10,opt,name=interval,proto3" json:"interval,omitempty"`
+	Limit         int32  `protobuf:"varint,11,opt,name=limit,proto3" json:"limit,omitempty"` // groups; defaults to 100, at most 1000
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AggregateRequest) Reset() {
+	*x = AggregateRequest{}
+	mi := &file_query_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AggregateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AggregateRequest) ProtoMessage() {}
+
+func (x *AggregateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AggregateRequest.ProtoReflect.Descriptor instead.
+func (*AggregateRequest) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AggregateRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *AggregateRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *AggregateRequest) GetFilters() map[string]string {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *AggregateRequest) GetConditions() []*Filter {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
+func (x *AggregateRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *AggregateRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *AggregateRequest) GetEventNames() []string {
+	if x != nil {
+		return x.EventNames
+	}
+	return nil
+}
+
+func (x *AggregateRequest) GetMetrics() []*Metric {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *AggregateRequest) GetGroupBy() []string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return nil
+}
+
+func (x *AggregateRequest) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *AggregateRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// A statistic of one searchable data key, whose values are cast to numbers.
+type Metric struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "count", "sum", "avg", "min", "max", "quantiles" or "histogram";
+	// "count" without a key counts events
+	Op            string    `protobuf:"bytes,1,opt,name=op,proto3" json:"op,omitempty"`
+	Key           string    `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Quantiles     []float64 `protobuf:"fixed64,3,rep,packed,name=quantiles,proto3" json:"quantiles,omitempty"` // for "quantiles"; defaults to 0.5, 0.95, 0.99
+	Bins          int32     `protobuf:"varint,4,opt,name=bins,proto3" json:"bins,omitempty"`                   // for "histogram"; defaults to 10, at most 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Metric) Reset() {
+	*x = Metric{}
+	mi := &file_query_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metric) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metric) ProtoMessage() {}
+
+func (x *Metric) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metric.ProtoReflect.Descriptor instead.
+func (*Metric) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Metric) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *Metric) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Metric) GetQuantiles() []float64 {
+	if x != nil {
+		return x.Quantiles
+	}
+	return nil
+}
+
+func (x *Metric) GetBins() int32 {
+	if x != nil {
+		return x.Bins
+	}
+	return 0
+}
+
+type AggregateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Groups        []*AggregateGroup      `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups,omitempty"`      // by bucket, then by events descending
+	Interval      int64                  `protobuf:"varint,2,opt,name=interval,proto3" json:"interval,omitempty"` // bucket width in nanoseconds, when grouped by time
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AggregateResponse) Reset() {
+	*x = AggregateResponse{}
+	mi := &file_query_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AggregateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AggregateResponse) ProtoMessage() {}
+
+func (x *AggregateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AggregateResponse.ProtoReflect.Descriptor instead.
+func (*AggregateResponse) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *AggregateResponse) GetGroups() []*AggregateGroup {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+func (x *AggregateResponse) GetInterval() int64 {
+	if x != nil {
+		return x.Interval
+	}
+	return 0
+}
+
+type AggregateGroup struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`      // values of group_by, in order
+	Bucket        int64                  `protobuf:"varint,2,opt,name=bucket,proto3" json:"bucket,omitempty"` // bucket start, Unix nanos
+	Events        int64                  `protobuf:"varint,3,opt,name=events,proto3" json:"events,omitempty"`
+	Metrics       []*MetricResult        `protobuf:"bytes,4,rep,name=metrics,proto3" json:"metrics,omitempty"` // in the order of metrics
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AggregateGroup) Reset() {
+	*x = AggregateGroup{}
+	mi := &file_query_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AggregateGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AggregateGroup) ProtoMessage() {}
+
+func (x *AggregateGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AggregateGroup.ProtoReflect.Descriptor instead.
+func (*AggregateGroup) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AggregateGroup) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *AggregateGroup) GetBucket() int64 {
+	if x != nil {
+		return x.Bucket
+	}
+	return 0
+}
+
+func (x *AggregateGroup) GetEvents() int64 {
+	if x != nil {
+		return x.Events
+	}
+	return 0
+}
+
+func (x *AggregateGroup) GetMetrics() []*MetricResult {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+type MetricResult struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Value     float64                `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"` // count, sum, avg, min or max
+	Quantiles []float64              `protobuf:"fixed64,2,rep,packed,name=quantiles,proto3" json:"quantiles,omitempty"`
+	Bins      []*HistogramBin        `protobuf:"bytes,3,rep,name=bins,proto3" json:"bins,omitempty"`
+	Count     int64                  `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"` // numeric values
+	// values present but not numeric, left out of the statistic, with a
+	// few examples
+	Invalid        int64    `protobuf:"varint,5,opt,name=invalid,proto3" json:"invalid,omitempty"`
+	InvalidSamples []string `protobuf:"bytes,6,rep,name=invalid_samples,json=invalidSamples,proto3" json:"invalid_samples,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MetricResult) Reset() {
+	*x = MetricResult{}
+	mi := &file_query_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricResult) ProtoMessage() {}
+
+func (x *MetricResult) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricResult.ProtoReflect.Descriptor instead.
+func (*MetricResult) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *MetricResult) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *MetricResult) GetQuantiles() []float64 {
+	if x != nil {
+		return x.Quantiles
+	}
+	return nil
+}
+
+func (x *MetricResult) GetBins() []*HistogramBin {
+	if x != nil {
+		return x.Bins
+	}
+	return nil
+}
+
+func (x *MetricResult) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *MetricResult) GetInvalid() int64 {
+	if x != nil {
+		return x.Invalid
+	}
+	return 0
+}
+
+func (x *MetricResult) GetInvalidSamples() []string {
+	if x != nil {
+		return x.InvalidSamples
+	}
+	return nil
+}
+
+// An adaptive histogram bin; count is estimated.
+type HistogramBin struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lower         float64                `protobuf:"fixed64,1,opt,name=lower,proto3" json:"lower,omitempty"`
+	Upper         float64                `protobuf:"fixed64,2,opt,name=upper,proto3" json:"upper,omitempty"`
+	Count         float64                `protobuf:"fixed64,3,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistogramBin) Reset() {
+	*x = HistogramBin{}
+	mi := &file_query_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistogramBin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistogramBin) ProtoMessage() {}
+
+func (x *HistogramBin) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistogramBin.ProtoReflect.Descriptor instead.
+func (*HistogramBin) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *HistogramBin) GetLower() float64 {
+	if x != nil {
+		return x.Lower
+	}
+	return 0
+}
+
+func (x *HistogramBin) GetUpper() float64 {
+	if x != nil {
+		return x.Upper
+	}
+	return 0
+}
+
+func (x *HistogramBin) GetCount() float64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// A query in the query language, like
+// event:checkout AND data.status>=500 | stats count() by data.region, bin(1m)
+type QueryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Query         string                 `protobuf:"bytes,3,opt,name=query,proto3" json:"query,omitempty"`
+	From          string                 `protobuf:"bytes,4,opt,name=from,proto3" json:"from,omitempty"` // time window as in SearchRequest
+	To            string                 `protobuf:"bytes,5,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryRequest) Reset() {
+	*x = QueryRequest{}
+	mi := &file_query_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRequest) ProtoMessage() {}
+
+func (x *QueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *QueryRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *QueryRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *QueryRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *QueryRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *QueryRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+// A table of results. Without stats the columns are timestamp, event and
+// data; with stats the group keys followed by the aggregates.
+type QueryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Columns       []*QueryColumn         `protobuf:"bytes,1,rep,name=columns,proto3" json:"columns,omitempty"`
+	Rows          []*QueryRow            `protobuf:"bytes,2,rep,name=rows,proto3" json:"rows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryResponse) Reset() {
+	*x = QueryResponse{}
+	mi := &file_query_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryResponse) ProtoMessage() {}
+
+func (x *QueryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryResponse.ProtoReflect.Descriptor instead.
+func (*QueryResponse) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *QueryResponse) GetColumns() []*QueryColumn {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *QueryResponse) GetRows() []*QueryRow {
+	if x != nil {
+		return x.Rows
+	}
+	return nil
+}
+
+type QueryColumn struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"` // "string", "int", "float" or "map"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryColumn) Reset() {
+	*x = QueryColumn{}
+	mi := &file_query_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryColumn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryColumn) ProtoMessage() {}
+
+func (x *QueryColumn) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryColumn.ProtoReflect.Descriptor instead.
+func (*QueryColumn) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *QueryColumn) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *QueryColumn) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+// Values in column order: numbers in decimal, maps as JSON objects, and an
+// empty string for an aggregate over no values.
+type QueryRow struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []string               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryRow) Reset() {
+	*x = QueryRow{}
+	mi := &file_query_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRow) ProtoMessage() {}
+
+func (x *QueryRow) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRow.ProtoReflect.Descriptor instead.
+func (*QueryRow) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *QueryRow) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type Project struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	TtlDays        int32                  `protobuf:"varint,3,opt,name=ttl_days,json=ttlDays,proto3" json:"ttl_days,omitempty"`
+	SearchableKeys []string               `protobuf:"bytes,4,rep,name=searchable_keys,json=searchableKeys,proto3" json:"searchable_keys,omitempty"`
+	Role           string                 `protobuf:"bytes,5,opt,name=role,proto3" json:"role,omitempty"` // the caller's role: "owner" or "member"
+	Archived       bool                   `protobuf:"varint,6,opt,name=archived,proto3" json:"archived,omitempty"`
+	CreatedAt      int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // Unix nanos
+	ApiKey         string                 `protobuf:"bytes,8,opt,name=api_key,json=apiKey,proto3" json:"api_key,omitempty"`           // only shown to owners
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Project) Reset() {
+	*x = Project{}
+	mi := &file_query_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Project) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Project) ProtoMessage() {}
+
+func (x *Project) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Project.ProtoReflect.Descriptor instead.
+func (*Project) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Project) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Project) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Project) GetTtlDays() int32 {
+	if x != nil {
+		return x.TtlDays
+	}
+	return 0
+}
+
+func (x *Project) GetSearchableKeys() []string {
+	if x != nil {
+		return x.SearchableKeys
+	}
+	return nil
+}
+
+func (x *Project) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Project) GetArchived() bool {
+	if x != nil {
+		return x.Archived
+	}
+	return false
+}
+
+func (x *Project) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Project) GetApiKey() string {
+	if x != nil {
+		return x.ApiKey
+	}
+	return ""
+}
+
+type ListProjectsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Token           string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	IncludeArchived bool                   `protobuf:"varint,2,opt,name=include_archived,json=includeArchived,proto3" json:"include_archived,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ListProjectsRequest) Reset() {
+	*x = ListProjectsRequest{}
+	mi := &file_query_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProjectsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProjectsRequest) ProtoMessage() {}
+
+func (x *ListProjectsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProjectsRequest.ProtoReflect.Descriptor instead.
+func (*ListProjectsRequest) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListProjectsRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ListProjectsRequest) GetIncludeArchived() bool {
+	if x != nil {
+		return x.IncludeArchived
+	}
+	return false
+}
+
+// The caller's projects, by name.
+type ListProjectsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Projects      []*Project             `protobuf:"bytes,1,rep,name=projects,proto3" json:"projects,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProjectsResponse) Reset() {
+	*x = ListProjectsResponse{}
+	mi := &file_query_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProjectsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProjectsResponse) ProtoMessage() {}
+
+func (x *ListProjectsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProjectsResponse.ProtoReflect.Descriptor instead.
+func (*ListProjectsResponse) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ListProjectsResponse) GetProjects() []*Project {
+	if x != nil {
+		return x.Projects
+	}
+	return nil
+}
+
+// The caller becomes the owner of the new project.
+type CreateProjectRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Token          string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	TtlDays        int32                  `protobuf:"varint,3,opt,name=ttl_days,json=ttlDays,proto3" json:"ttl_days,omitempty"`
+	SearchableKeys []string               `protobuf:"bytes,4,rep,name=searchable_keys,json=searchableKeys,proto3" json:"searchable_keys,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateProjectRequest) Reset() {
+	*x = CreateProjectRequest{}
+	mi := &file_query_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateProjectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateProjectRequest) ProtoMessage() {}
+
+func (x *CreateProjectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateProjectRequest.ProtoReflect.Descriptor instead.
+func (*CreateProjectRequest) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *CreateProjectRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *CreateProjectRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateProjectRequest) GetTtlDays() int32 {
+	if x != nil {
+		return x.TtlDays
+	}
+	return 0
+}
+
+func (x *CreateProjectRequest) GetSearchableKeys() []string {
+	if x != nil {
+		return x.SearchableKeys
+	}
+	return nil
+}
+
+// Owners only. Fields left unset keep their current value.
+type UpdateProjectRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Token          string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	ProjectId      string                 `protobuf:"bytes,2,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Name           *string                `protobuf:"bytes,3,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	TtlDays        *int32                 `protobuf:"varint,4,opt,name=ttl_days,json=ttlDays,proto3,oneof" json:"ttl_days,omitempty"`
+	SearchableKeys []string               `protobuf:"bytes,5,rep,name=searchable_keys,json=searchableKeys,proto3" json:"searchable_keys,omitempty"` // replaces the keys when not empty
+	// replaces the keys even with an empty searchable_keys; over HTTP a
+	// searchable_keys field in the body sets it
+	UpdateSearchableKeys bool `protobuf:"varint,6,opt,name=update_searchable_keys,json=updateSearchableKeys,proto3" json:"update_searchable_keys,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *UpdateProjectRequest) Reset() {
+	*x = UpdateProjectRequest{}
+	mi := &file_query_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateProjectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateProjectRequest) ProtoMessage() {}
+
+func (x *UpdateProjectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateProjectRequest.ProtoReflect.Descriptor instead.
+func (*UpdateProjectRequest) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *UpdateProjectRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *UpdateProjectRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *UpdateProjectRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *UpdateProjectRequest) GetTtlDays() int32 {
+	if x != nil && x.TtlDays != nil {
+		return *x.TtlDays
+	}
+	return 0
+}
+
+func (x *UpdateProjectRequest) GetSearchableKeys() []string {
+	if x != nil {
+		return x.SearchableKeys
+	}
+	return nil
+}
+
+func (x *UpdateProjectRequest) GetUpdateSearchableKeys() bool {
+	if x != nil {
+		return x.UpdateSearchableKeys
+	}
+	return false
+}
+
+// Owners only. An archived project stops accepting events and can no longer
+// be searched; it stays listed with include_archived.
+type ArchiveProjectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	ProjectId     string                 `protobuf:"bytes,2,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArchiveProjectRequest) Reset() {
+	*x = ArchiveProjectRequest{}
+	mi := &file_query_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArchiveProjectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArchiveProjectRequest) ProtoMessage() {}
+
+func (x *ArchiveProjectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_query_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArchiveProjectRequest.ProtoReflect.Descriptor instead.
+func (*ArchiveProjectRequest) Descriptor() ([]byte, []int) {
+	return file_query_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ArchiveProjectRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ArchiveProjectRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+var File_query_proto protoreflect.FileDescriptor
+
+const file_query_proto_rawDesc = "" +
+	"\n" +
+	"\vquery.proto\x12\x05query\x1a\x10log_ingest.proto\"\xf3\x02\n" +
+	"\rSearchRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12;\n" +
+	"\afilters\x18\x03 \x03(\v2!.query.SearchRequest.FiltersEntryR\afilters\x12-\n" +
+	"\n" +
+	"conditions\x18\x04 \x03(\v2\r.query.FilterR\n" +
+	"conditions\x12\x12\n" +
+	"\x04from\x18\x05 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x06 \x01(\tR\x02to\x12\x1f\n" +
+	"\vevent_names\x18\a \x03(\tR\n" +
+	"eventNames\x12\x12\n" +
+	"\x04sort\x18\b \x01(\tR\x04sort\x12\x14\n" +
+	"\x05limit\x18\t \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\n" +
+	" \x01(\x05R\x06offset\x1a:\n" +
+	"\fFiltersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"X\n" +
+	"\x06Filter\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x0e\n" +
+	"\x02op\x18\x02 \x01(\tR\x02op\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\x12\x16\n" +
+	"\x06values\x18\x04 \x03(\tR\x06values\"z\n" +
+	"\fEventSummary\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\tlast_seen\x18\x02 \x01(\x03R\blastSeen\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x03R\x05count\x12#\n" +
+	"\rlast_received\x18\x04 \x01(\x03R\flastReceived\"S\n" +
+	"\x0eSearchResponse\x12+\n" +
+	"\x06events\x18\x01 \x03(\v2\x13.query.EventSummaryR\x06events\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\"\x9d\x01\n" +
+	"\x12EventDetailRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x1d\n" +
+	"\n" +
+	"event_name\x18\x03 \x01(\tR\teventName\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\"\xc7\x01\n" +
+	"\x13EventDetailResponse\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x12(\n" +
+	"\x05entry\x18\x02 \x01(\v2\x12.ingest.LogPayloadR\x05entry\x12,\n" +
+	"\aentries\x18\x03 \x03(\v2\x12.ingest.LogPayloadR\aentries\x12\x1f\n" +
+	"\vnext_cursor\x18\x04 \x01(\tR\n" +
+	"nextCursor\x12\x1f\n" +
+	"\vprev_cursor\x18\x05 \x01(\tR\n" +
+	"prevCursor\"\x8f\x03\n" +
+	"\x10HistogramRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12>\n" +
+	"\afilters\x18\x03 \x03(\v2$.query.HistogramRequest.FiltersEntryR\afilters\x12-\n" +
+	"\n" +
+	"conditions\x18\x04 \x03(\v2\r.query.FilterR\n" +
+	"conditions\x12\x12\n" +
+	"\x04from\x18\x05 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x06 \x01(\tR\x02to\x12\x1f\n" +
+	"\vevent_names\x18\a \x03(\tR\n" +
+	"eventNames\x12\x1a\n" +
+	"\binterval\x18\b \x01(\tR\binterval\x12\x19\n" +
+	"\bsplit_by\x18\t \x01(\tR\asplitBy\x12\x1f\n" +
+	"\vsplit_limit\x18\n" +
+	" \x01(\x05R\n" +
+	"splitLimit\x1a:\n" +
+	"\fFiltersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"y\n" +
+	"\x11HistogramResponse\x12\x1a\n" +
+	"\binterval\x18\x01 \x01(\x03R\binterval\x12\x18\n" +
+	"\abuckets\x18\x02 \x03(\x03R\abuckets\x12.\n" +
+	"\x06series\x18\x03 \x03(\v2\x16.query.HistogramSeriesR\x06series\"k\n" +
+	"\x0fHistogramSeries\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x14\n" +
+	"\x05other\x18\x02 \x01(\bR\x05other\x12\x16\n" +
+	"\x06counts\x18\x03 \x03(\x03R\x06counts\x12\x14\n" +
+	"\x05total\x18\x04 \x01(\x03R\x05total\"\xdb\x02\n" +
+	"\rFacetsRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12;\n" +
+	"\afilters\x18\x03 \x03(\v2!.query.FacetsRequest.FiltersEntryR\afilters\x12-\n" +
+	"\n" +
+	"conditions\x18\x04 \x03(\v2\r.query.FilterR\n" +
+	"conditions\x12\x12\n" +
+	"\x04from\x18\x05 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x06 \x01(\tR\x02to\x12\x1f\n" +
+	"\vevent_names\x18\a \x03(\tR\n" +
+	"eventNames\x12\x12\n" +
+	"\x04keys\x18\b \x03(\tR\x04keys\x12\x14\n" +
+	"\x05limit\x18\t \x01(\x05R\x05limit\x1a:\n" +
+	"\fFiltersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"L\n" +
+	"\x0eFacetsResponse\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x03R\x05total\x12$\n" +
+	"\x06facets\x18\x02 \x03(\v2\f.query.FacetR\x06facets\"z\n" +
+	"\x05Facet\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12)\n" +
+	"\x06values\x18\x02 \x03(\v2\x11.query.FacetValueR\x06values\x12\x1a\n" +
+	"\bdistinct\x18\x03 \x01(\x03R\bdistinct\x12\x18\n" +
+	"\amissing\x18\x04 \x01(\x03R\amissing\"8\n" +
+	"\n" +
+	"FacetValue\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"\xad\x03\n" +
+	"\x10AggregateRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12>\n" +
+	"\afilters\x18\x03 \x03(\v2$.query.AggregateRequest.FiltersEntryR\afilters\x12-\n" +
+	"\n" +
+	"conditions\x18\x04 \x03(\v2\r.query.FilterR\n" +
+	"conditions\x12\x12\n" +
+	"\x04from\x18\x05 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x06 \x01(\tR\x02to\x12\x1f\n" +
+	"\vevent_names\x18\a \x03(\tR\n" +
+	"eventNames\x12'\n" +
+	"\ametrics\x18\b \x03(\v2\r.query.MetricR\ametrics\x12\x19\n" +
+	"\bgroup_by\x18\t \x03(\tR\agroupBy\x12\x1a\n" +
+	"\binterval\x18\n" +
+	" \x01(\tR\binterval\x12\x14\n" +
+	"\x05limit\x18\v \x01(\x05R\x05limit\x1a:\n" +
+	"\fFiltersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\\\n" +
+	"\x06Metric\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\tR\x02op\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x1c\n" +
+	"\tquantiles\x18\x03 \x03(\x01R\tquantiles\x12\x12\n" +
+	"\x04bins\x18\x04 \x01(\x05R\x04bins\"^\n" +
+	"\x11AggregateResponse\x12-\n" +
+	"\x06groups\x18\x01 \x03(\v2\x15.query.AggregateGroupR\x06groups\x12\x1a\n" +
+	"\binterval\x18\x02 \x01(\x03R\binterval\"\x83\x01\n" +
+	"\x0eAggregateGroup\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\x12\x16\n" +
+	"\x06bucket\x18\x02 \x01(\x03R\x06bucket\x12\x16\n" +
+	"\x06events\x18\x03 \x01(\x03R\x06events\x12-\n" +
+	"\ametrics\x18\x04 \x03(\v2\x13.query.MetricResultR\ametrics\"\xc4\x01\n" +
+	"\fMetricResult\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x01R\x05value\x12\x1c\n" +
+	"\tquantiles\x18\x02 \x03(\x01R\tquantiles\x12'\n" +
+	"\x04bins\x18\x03 \x03(\v2\x13.query.HistogramBinR\x04bins\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x03R\x05count\x12\x18\n" +
+	"\ainvalid\x18\x05 \x01(\x03R\ainvalid\x12'\n" +
+	"\x0finvalid_samples\x18\x06 \x03(\tR\x0einvalidSamples\"P\n" +
+	"\fHistogramBin\x12\x14\n" +
+	"\x05lower\x18\x01 \x01(\x01R\x05lower\x12\x14\n" +
+	"\x05upper\x18\x02 \x01(\x01R\x05upper\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x01R\x05count\"}\n" +
+	"\fQueryRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x14\n" +
+	"\x05query\x18\x03 \x01(\tR\x05query\x12\x12\n" +
+	"\x04from\x18\x04 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x05 \x01(\tR\x02to\"b\n" +
+	"\rQueryResponse\x12,\n" +
+	"\acolumns\x18\x01 \x03(\v2\x12.query.QueryColumnR\acolumns\x12#\n" +
+	"\x04rows\x18\x02 \x03(\v2\x0f.query.QueryRowR\x04rows\"5\n" +
+	"\vQueryColumn\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\"\"\n" +
+	"\bQueryRow\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\"\xd9\x01\n" +
+	"\aProject\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x19\n" +
+	"\bttl_days\x18\x03 \x01(\x05R\attlDays\x12'\n" +
+	"\x0fsearchable_keys\x18\x04 \x03(\tR\x0esearchableKeys\x12\x12\n" +
+	"\x04role\x18\x05 \x01(\tR\x04role\x12\x1a\n" +
+	"\barchived\x18\x06 \x01(\bR\barchived\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\x12\x17\n" +
+	"\aapi_key\x18\b \x01(\tR\x06apiKey\"V\n" +
+	"\x13ListProjectsRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12)\n" +
+	"\x10include_archived\x18\x02 \x01(\bR\x0fincludeArchived\"B\n" +
+	"\x14ListProjectsResponse\x12*\n" +
+	"\bprojects\x18\x01 \x03(\v2\x0e.query.ProjectR\bprojects\"\x84\x01\n" +
+	"\x14CreateProjectRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x19\n" +
+	"\bttl_days\x18\x03 \x01(\x05R\attlDays\x12'\n" +
+	"\x0fsearchable_keys\x18\x04 \x03(\tR\x0esearchableKeys\"\xf9\x01\n" +
+	"\x14UpdateProjectRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x02 \x01(\tR\tprojectId\x12\x17\n" +
+	"\x04name\x18\x03 \x01(\tH\x00R\x04name\x88\x01\x01\x12\x1e\n" +
+	"\bttl_days\x18\x04 \x01(\x05H\x01R\attlDays\x88\x01\x01\x12'\n" +
+	"\x0fsearchable_keys\x18\x05 \x03(\tR\x0esearchableKeys\x124\n" +
+	"\x16update_searchable_keys\x18\x06 \x01(\bR\x14updateSearchableKeysB\a\n" +
+	"\x05_nameB\v\n" +
+	"\t_ttl_days\"L\n" +
+	"\x15ArchiveProjectRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x02 \x01(\tR\tprojectId2\x8d\x05\n" +
 	"\fQueryService\x12;\n" +
 	"\fSearchEvents\x12\x14.query.SearchRequest\x1a\x15.query.SearchResponse\x12G\n" +
-	"\x0eGetEventDetail\x12\x19.query.EventDetailRequest\x1a\x1a.query.EventDetailResponseB>Z<github.com/parishadmk/log-system-analysis/internal/api/queryb\x06proto3"
+	"\x0eGetEventDetail\x12\x19.query.EventDetailRequest\x1a\x1a.query.EventDetailResponse\x12A\n" +
+	"\fGetHistogram\x12\x17.query.HistogramRequest\x1a\x18.query.HistogramResponse\x128\n" +
+	"\tGetFacets\x12\x14.query.FacetsRequest\x1a\x15.query.FacetsResponse\x12>\n" +
+	"\tAggregate\x12\x17.query.AggregateRequest\x1a\x18.query.AggregateResponse\x125\n" +
+	"\bRunQuery\x12\x13.query.QueryRequest\x1a\x14.query.QueryResponse\x12G\n" +
+	"\fListProjects\x12\x1a.query.ListProjectsRequest\x1a\x1b.query.ListProjectsResponse\x12<\n" +
+	"\rCreateProject\x12\x1b.query.CreateProjectRequest\x1a\x0e.query.Project\x12<\n" +
+	"\rUpdateProject\x12\x1b.query.UpdateProjectRequest\x1a\x0e.query.Project\x12>\n" +
+	"\x0eArchiveProject\x12\x1c.query.ArchiveProjectRequest\x1a\x0e.query.ProjectB>Z<github.com/parishadmk/log-system-analysis/internal/api/queryb\x06proto3"
 
 var (
 	file_query_proto_rawDescOnce sync.Once
@@ -352,29 +2354,90 @@ func file_query_proto_rawDescGZIP() []byte {
 	return file_query_proto_rawDescData
 }
 
-var file_query_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_query_proto_msgTypes = make([]protoimpl.MessageInfo, 33)
 var file_query_proto_goTypes = []any{
-	(*SearchRequest)(nil),       // 0: query.SearchRequest
-	(*EventSummary)(nil),        // 1: query.EventSummary
-	(*SearchResponse)(nil),      // 2: query.SearchResponse
-	(*EventDetailRequest)(nil),  // 3: query.EventDetailRequest
-	(*EventDetailResponse)(nil), // 4: query.EventDetailResponse
-	nil,                         // 5: query.SearchRequest.FiltersEntry
-	(*ingest.LogPayload)(nil),   // 6: ingest.LogPayload
+	(*SearchRequest)(nil),         // 0: query.SearchRequest
+	(*Filter)(nil),                // 1: query.Filter
+	(*EventSummary)(nil),          // 2: query.EventSummary
+	(*SearchResponse)(nil),        // 3: query.SearchResponse
+	(*EventDetailRequest)(nil),    // 4: query.EventDetailRequest
+	(*EventDetailResponse)(nil),   // 5: query.EventDetailResponse
+	(*HistogramRequest)(nil),      // 6: query.HistogramRequest
+	(*HistogramResponse)(nil),     // 7: query.HistogramResponse
+	(*HistogramSeries)(nil),       // 8: query.HistogramSeries
+	(*FacetsRequest)(nil),         // 9: query.FacetsRequest
+	(*FacetsResponse)(nil),        // 10: query.FacetsResponse
+	(*Facet)(nil),                 // 11: query.Facet
+	(*FacetValue)(nil),            // 12: query.FacetValue
+	(*AggregateRequest)(nil),      // 13: query.AggregateRequest
+	(*Metric)(nil),                // 14: query.Metric
+	(*AggregateResponse)(nil),     // 15: query.AggregateResponse
+	(*AggregateGroup)(nil),        // 16: query.AggregateGroup
+	(*MetricResult)(nil),          // 17: query.MetricResult
+	(*HistogramBin)(nil),          // 18: query.HistogramBin
+	(*QueryRequest)(nil),          // 19: query.QueryRequest
+	(*QueryResponse)(nil),         // 20: query.QueryResponse
+	(*QueryColumn)(nil),           // 21: query.QueryColumn
+	(*QueryRow)(nil),              // 22: query.QueryRow
+	(*Project)(nil),               // 23: query.Project
+	(*ListProjectsRequest)(nil),   // 24: query.ListProjectsRequest
+	(*ListProjectsResponse)(nil),  // 25: query.ListProjectsResponse
+	(*CreateProjectRequest)(nil),  // 26: query.CreateProjectRequest
+	(*UpdateProjectRequest)(nil),  // 27: query.UpdateProjectRequest
+	(*ArchiveProjectRequest)(nil), // 28: query.ArchiveProjectRequest
+	nil,                           // 29: query.SearchRequest.FiltersEntry
+	nil,                           // 30: query.HistogramRequest.FiltersEntry
+	nil,                           // 31: query.FacetsRequest.FiltersEntry
+	nil,                           // 32: query.AggregateRequest.FiltersEntry
+	(*ingest.LogPayload)(nil),     // 33: ingest.LogPayload
 }
 var file_query_proto_depIdxs = []int32{
-	5, // 0: query.SearchRequest.filters:type_name -> query.SearchRequest.FiltersEntry
-	1, // 1: query.SearchResponse.events:type_name -> query.EventSummary
-	6, // 2: query.EventDetailResponse.entry:type_name -> ingest.LogPayload
-	0, // 3: query.QueryService.SearchEvents:input_type -> query.SearchRequest
-	3, // 4: query.QueryService.GetEventDetail:input_type -> query.EventDetailRequest
-	2, // 5: query.QueryService.SearchEvents:output_type -> query.SearchResponse
-	4, // 6: query.QueryService.GetEventDetail:output_type -> query.EventDetailResponse
-	5, // [5:7] is the sub-list for method output_type
-	3, // [3:5] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	29, // 0: query.SearchRequest.filters:type_name -> query.SearchRequest.FiltersEntry
+	1,  // 1: query.SearchRequest.conditions:type_name -> query.Filter
+	2,  // 2: query.SearchResponse.events:type_name -> query.EventSummary
+	33, // 3: query.EventDetailResponse.entry:type_name -> ingest.LogPayload
+	33, // 4: query.EventDetailResponse.entries:type_name -> ingest.LogPayload
+	30, // 5: query.HistogramRequest.filters:type_name -> query.HistogramRequest.FiltersEntry
+	1,  // 6: query.HistogramRequest.conditions:type_name -> query.Filter
+	8,  // 7: query.HistogramResponse.series:type_name -> query.HistogramSeries
+	31, // 8: query.FacetsRequest.filters:type_name -> query.FacetsRequest.FiltersEntry
+	1,  // 9: query.FacetsRequest.conditions:type_name -> query.Filter
+	11, // 10: query.FacetsResponse.facets:type_name -> query.Facet
+	12, // 11: query.Facet.values:type_name -> query.FacetValue
+	32, // 12: query.AggregateRequest.filters:type_name -> query.AggregateRequest.FiltersEntry
+	1,  // 13: query.AggregateRequest.conditions:type_name -> query.Filter
+	14, // 14: query.AggregateRequest.metrics:type_name -> query.Metric
+	16, // 15: query.AggregateResponse.groups:type_name -> query.AggregateGroup
+	17, // 16: query.AggregateGroup.metrics:type_name -> query.MetricResult
+	18, // 17: query.MetricResult.bins:type_name -> query.HistogramBin
+	21, // 18: query.QueryResponse.columns:type_name -> query.QueryColumn
+	22, // 19: query.QueryResponse.rows:type_name -> query.QueryRow
+	23, // 20: query.ListProjectsResponse.projects:type_name -> query.Project
+	0,  // 21: query.QueryService.SearchEvents:input_type -> query.SearchRequest
+	4,  // 22: query.QueryService.GetEventDetail:input_type -> query.EventDetailRequest
+	6,  // 23: query.QueryService.GetHistogram:input_type -> query.HistogramRequest
+	9,  // 24: query.QueryService.GetFacets:input_type -> query.FacetsRequest
+	13, // 25: query.QueryService.Aggregate:input_type -> query.AggregateRequest
+	19, // 26: query.QueryService.RunQuery:input_type -> query.QueryRequest
+	24, // 27: query.QueryService.ListProjects:input_type -> query.ListProjectsRequest
+	26, // 28: query.QueryService.CreateProject:input_type -> query.CreateProjectRequest
+	27, // 29: query.QueryService.UpdateProject:input_type -> query.UpdateProjectRequest
+	28, // 30: query.QueryService.ArchiveProject:input_type -> query.ArchiveProjectRequest
+	3,  // 31: query.QueryService.SearchEvents:output_type -> query.SearchResponse
+	5,  // 32: query.QueryService.GetEventDetail:output_type -> query.EventDetailResponse
+	7,  // 33: query.QueryService.GetHistogram:output_type -> query.HistogramResponse
+	10, // 34: query.QueryService.GetFacets:output_type -> query.FacetsResponse
+	15, // 35: query.QueryService.Aggregate:output_type -> query.AggregateResponse
+	20, // 36: query.QueryService.RunQuery:output_type -> query.QueryResponse
+	25, // 37: query.QueryService.ListProjects:output_type -> query.ListProjectsResponse
+	23, // 38: query.QueryService.CreateProject:output_type -> query.Project
+	23, // 39: query.QueryService.UpdateProject:output_type -> query.Project
+	23, // 40: query.QueryService.ArchiveProject:output_type -> query.Project
+	31, // [31:41] is the sub-list for method output_type
+	21, // [21:31] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_query_proto_init() }
@@ -382,13 +2445,14 @@ func file_query_proto_init() {
 	if File_query_proto != nil {
 		return
 	}
+	file_query_proto_msgTypes[27].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_query_proto_rawDesc), len(file_query_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   33,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
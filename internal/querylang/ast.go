@@ -0,0 +1,230 @@
+package querylang
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query is a parsed query: an optional filter and the pipes after it.
+type Query struct {
+	Filter Expr // nil matches every event
+	Stats  *Stats
+	Sort   []SortKey
+	Limit  int // 0 when not given
+
+	limitPos int
+}
+
+// Expr is a boolean condition on events.
+type Expr interface {
+	Pos() int
+	String() string
+}
+
+// Binary is Op ("AND" or "OR") applied to X and Y.
+type Binary struct {
+	Op   string
+	X, Y Expr
+	pos  int
+}
+
+// Not negates X.
+type Not struct {
+	X   Expr
+	pos int
+}
+
+// Comparison tests a field against one value, or a list of them for "in".
+type Comparison struct {
+	Field  Field
+	Op     string // ":", "=", "!=", ">", ">=", "<", "<=", "~" or "in"
+	Value  Value
+	Values []Value // for "in"
+}
+
+// Field is the event name or a data key.
+type Field struct {
+	Key string // data key; empty for the event name
+	pos int
+}
+
+// Value is a literal. A bare word ending in "*" is a prefix pattern after
+// ":", and a lone "*" matches any value.
+type Value struct {
+	Text   string
+	Quoted bool
+	pos    int
+}
+
+// Stats aggregates the filtered events, optionally in groups.
+type Stats struct {
+	Aggs []Agg
+	By   []GroupKey
+	pos  int
+}
+
+// Agg is an aggregate function with an optional data field argument.
+type Agg struct {
+	Func  string // count, sum, avg, min, max, distinct, p50, p90, p95, p99
+	Arg   *Field
+	Alias string
+	pos   int
+}
+
+// GroupKey groups by a field, or by time buckets of width Bin.
+type GroupKey struct {
+	Field *Field
+	Bin   time.Duration
+	pos   int
+}
+
+// SortKey orders the results by an output column.
+type SortKey struct {
+	Name string
+	Desc bool
+	pos  int
+}
+
+func (e *Binary) Pos() int     { return e.pos }
+func (e *Not) Pos() int        { return e.pos }
+func (e *Comparison) Pos() int { return e.Field.pos }
+func (f Field) Pos() int       { return f.pos }
+func (v Value) Pos() int       { return v.pos }
+func (s *Stats) Pos() int      { return s.pos }
+func (a Agg) Pos() int         { return a.pos }
+func (g GroupKey) Pos() int    { return g.pos }
+func (k SortKey) Pos() int     { return k.pos }
+
+// The String methods print the AST as S-expressions.
+
+func (q *Query) String() string {
+	var parts []string
+	if q.Filter != nil {
+		parts = append(parts, "(where "+q.Filter.String()+")")
+	}
+	if q.Stats != nil {
+		parts = append(parts, q.Stats.String())
+	}
+	if len(q.Sort) > 0 {
+		keys := make([]string, len(q.Sort))
+		for i, k := range q.Sort {
+			keys[i] = k.String()
+		}
+		parts = append(parts, "(sort "+strings.Join(keys, " ")+")")
+	}
+	if q.Limit > 0 {
+		parts = append(parts, "(limit "+strconv.Itoa(q.Limit)+")")
+	}
+	return "(query" + prefixed(parts) + ")"
+}
+
+func (e *Binary) String() string {
+	return "(" + strings.ToLower(e.Op) + " " + e.X.String() + " " + e.Y.String() + ")"
+}
+
+func (e *Not) String() string { return "(not " + e.X.String() + ")" }
+
+func (e *Comparison) String() string {
+	if e.Op == "in" {
+		vals := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			vals[i] = v.String()
+		}
+		return "(in " + e.Field.String() + " " + strings.Join(vals, " ") + ")"
+	}
+	return "(" + e.Op + " " + e.Field.String() + " " + e.Value.String() + ")"
+}
+
+func (f Field) String() string {
+	if f.Key == "" {
+		return "event"
+	}
+	if isIdent(f.Key) {
+		return "data." + f.Key
+	}
+	return "data." + quote(f.Key)
+}
+
+func (v Value) String() string {
+	if v.Quoted {
+		return quote(v.Text)
+	}
+	return v.Text
+}
+
+func (s *Stats) String() string {
+	aggs := make([]string, len(s.Aggs))
+	for i, a := range s.Aggs {
+		aggs[i] = a.String()
+		if a.Alias != "" {
+			aggs[i] = "(as " + aggs[i] + " " + a.Alias + ")"
+		}
+	}
+	out := "(stats " + strings.Join(aggs, " ")
+	if len(s.By) > 0 {
+		keys := make([]string, len(s.By))
+		for i, g := range s.By {
+			keys[i] = g.String()
+		}
+		out += " (by " + strings.Join(keys, " ") + ")"
+	}
+	return out + ")"
+}
+
+// String is the default column name of the aggregate, like "p95(data.ms)".
+func (a Agg) String() string {
+	if a.Arg == nil {
+		return a.Func + "()"
+	}
+	return a.Func + "(" + a.Arg.String() + ")"
+}
+
+func (g GroupKey) String() string {
+	if g.Field != nil {
+		return g.Field.String()
+	}
+	return "bin(" + formatDuration(g.Bin) + ")"
+}
+
+func (k SortKey) String() string {
+	if k.Desc {
+		return "-" + k.Name
+	}
+	return k.Name
+}
+
+func prefixed(parts []string) string {
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parts, " ")
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || r == '-' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// formatDuration prints whole days as "1d" and otherwise like
+// time.Duration, without zero units ("1m" rather than "1m0s").
+func formatDuration(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return strconv.FormatInt(int64(d/(24*time.Hour)), 10) + "d"
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,291 @@
+package querylang
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ColumnType is the Go type a result column scans into.
+type ColumnType int
+
+const (
+	String ColumnType = iota // string
+	Int                      // int64
+	Float                    // float64; NaN when nothing was aggregated
+	Map                      // two SQL columns: []string keys, []string values
+)
+
+// Column is a result column, named as the user refers to it in sort.
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Compiled is a query ready to run on ClickHouse. Every literal of the query
+// is in Args; the SQL text only holds fixed identifiers and placeholders.
+type Compiled struct {
+	SQL     string
+	Args    []interface{}
+	Columns []Column
+}
+
+// Options scope a compiled query.
+type Options struct {
+	ProjectID string
+	// Keys are the data keys the query may use; nil allows any key.
+	Keys []string
+	// Scope is an extra predicate over logs ANDed with the filter, such as
+	// a time window, with its arguments.
+	Scope     string
+	ScopeArgs []interface{}
+	// DefaultLimit applies without a limit pipe; larger limits than
+	// MaxLimit are rejected.
+	DefaultLimit int
+	MaxLimit     int
+}
+
+const maxInValues = 1000
+
+// numeric casts a data value to Float64, NULL when it is not a number.
+const numeric = "toFloat64OrNull(data[?])"
+
+// quantiles are the levels of the pNN aggregates.
+var quantiles = map[string]float64{"p50": 0.5, "p90": 0.9, "p95": 0.95, "p99": 0.99}
+
+type compiler struct {
+	opts Options
+}
+
+// Compile turns q into SQL over the logs table. Without stats it returns
+// matching events, newest first; with stats one row per group.
+func Compile(q *Query, opts Options) (*Compiled, error) {
+	c := &compiler{opts: opts}
+	limit := q.Limit
+	if limit == 0 {
+		limit = opts.DefaultLimit
+	}
+	if opts.MaxLimit > 0 && limit > opts.MaxLimit {
+		return nil, errorf(q.limitPos, "limit must be at most %d", opts.MaxLimit)
+	}
+
+	where := "project_id = ?"
+	whereArgs := []interface{}{opts.ProjectID}
+	if opts.Scope != "" {
+		where += " AND " + opts.Scope
+		whereArgs = append(whereArgs, opts.ScopeArgs...)
+	}
+	if q.Filter != nil {
+		pred, args, err := c.expr(q.Filter)
+		if err != nil {
+			return nil, err
+		}
+		where += " AND " + pred
+		whereArgs = append(whereArgs, args...)
+	}
+
+	var (
+		out   = &Compiled{}
+		sel   []string
+		alias = map[string]string{} // column name -> SQL alias
+		group []string
+		order []string
+	)
+	addColumn := func(name string, typ ColumnType, expr, as string, pos int, args ...interface{}) error {
+		if _, dup := alias[name]; dup {
+			return errorf(pos, "duplicate column %q; name one with AS", name)
+		}
+		alias[name] = as
+		sel = append(sel, expr+" AS "+as)
+		out.Args = append(out.Args, args...)
+		out.Columns = append(out.Columns, Column{Name: name, Type: typ})
+		return nil
+	}
+
+	if q.Stats == nil {
+		addColumn("timestamp", Int, "toUnixTimestamp64Nano(timestamp)", "timestamp_ns", 0)
+		addColumn("event", String, "event_name", "event", 0)
+		addColumn("data", Map, "mapKeys(data) AS data_keys, mapValues(data)", "data_values", 0)
+		// sort on the column so that the primary key order is used, and
+		// not on the map
+		alias["timestamp"] = "timestamp"
+		delete(alias, "data")
+		order = []string{"timestamp DESC"}
+	} else {
+		for i, g := range q.Stats.By {
+			as := "g" + strconv.Itoa(i)
+			if g.Field == nil {
+				width := int64(g.Bin)
+				if err := addColumn(g.String(), Int, "intDiv(toUnixTimestamp64Nano(timestamp), ?) * ?", as, g.pos, width, width); err != nil {
+					return nil, err
+				}
+				order = append(order, as)
+			} else {
+				expr, args, err := c.field(*g.Field)
+				if err != nil {
+					return nil, err
+				}
+				if err := addColumn(g.String(), String, expr, as, g.pos, args...); err != nil {
+					return nil, err
+				}
+			}
+			group = append(group, as)
+		}
+		for i, a := range q.Stats.Aggs {
+			expr, typ, args, err := c.agg(a)
+			if err != nil {
+				return nil, err
+			}
+			name := a.String()
+			if a.Alias != "" {
+				name = a.Alias
+			}
+			as := "a" + strconv.Itoa(i)
+			if err := addColumn(name, typ, expr, as, a.pos, args...); err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				order = append(order, as+" DESC")
+			}
+		}
+	}
+	if len(q.Sort) > 0 {
+		order = order[:0]
+		for _, k := range q.Sort {
+			as, ok := alias[k.Name]
+			if !ok {
+				names := make([]string, len(out.Columns))
+				for i, col := range out.Columns {
+					names[i] = col.Name
+				}
+				return nil, errorf(k.pos, "cannot sort by %q; columns are %s", k.Name, strings.Join(names, ", "))
+			}
+			if k.Desc {
+				as += " DESC"
+			}
+			order = append(order, as)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT " + strings.Join(sel, ", "))
+	b.WriteString("\nFROM logs FINAL\nWHERE " + where)
+	if len(group) > 0 {
+		b.WriteString("\nGROUP BY " + strings.Join(group, ", "))
+	}
+	b.WriteString("\nORDER BY " + strings.Join(order, ", "))
+	b.WriteString("\nLIMIT ?")
+	out.SQL = b.String()
+	out.Args = append(out.Args, whereArgs...)
+	out.Args = append(out.Args, limit)
+	return out, nil
+}
+
+func (c *compiler) expr(e Expr) (string, []interface{}, error) {
+	switch e := e.(type) {
+	case *Binary:
+		x, xArgs, err := c.expr(e.X)
+		if err != nil {
+			return "", nil, err
+		}
+		y, yArgs, err := c.expr(e.Y)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(" + x + " " + e.Op + " " + y + ")", append(xArgs, yArgs...), nil
+	case *Not:
+		x, args, err := c.expr(e.X)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT (" + x + ")", args, nil
+	case *Comparison:
+		return c.comparison(e)
+	}
+	return "", nil, fmt.Errorf("querylang: unexpected expression %T", e)
+}
+
+// field renders the column of f, checking data keys against the options.
+func (c *compiler) field(f Field) (string, []interface{}, error) {
+	if f.Key == "" {
+		return "event_name", nil, nil
+	}
+	if c.opts.Keys != nil && !slices.Contains(c.opts.Keys, f.Key) {
+		return "", nil, errorf(f.pos, "%s is not a searchable key of this project", f)
+	}
+	return "data[?]", []interface{}{f.Key}, nil
+}
+
+func (c *compiler) comparison(e *Comparison) (string, []interface{}, error) {
+	col, args, err := c.field(e.Field)
+	if err != nil {
+		return "", nil, err
+	}
+	v := e.Value
+	switch e.Op {
+	case ":":
+		switch {
+		case v.Text == "*" && !v.Quoted:
+			if e.Field.Key == "" {
+				return "1", nil, nil
+			}
+			return "mapContains(data, ?)", args, nil
+		case strings.HasSuffix(v.Text, "*") && !v.Quoted:
+			return "startsWith(" + col + ", ?)", append(args, strings.TrimSuffix(v.Text, "*")), nil
+		}
+		return col + " = ?", append(args, v.Text), nil
+	case "=", "!=":
+		return col + " " + e.Op + " ?", append(args, v.Text), nil
+	case ">", ">=", "<", "<=":
+		if e.Field.Key == "" {
+			return "", nil, errorf(e.Field.pos, "%s only compares data fields", e.Op)
+		}
+		n, err := strconv.ParseFloat(v.Text, 64)
+		if err != nil {
+			return "", nil, errorf(v.pos, "%s needs a number, found %s", e.Op, v)
+		}
+		return numeric + " " + e.Op + " ?", append(args, n), nil
+	case "~":
+		// ClickHouse and Go both use RE2 syntax
+		if _, err := regexp.Compile(v.Text); err != nil {
+			return "", nil, errorf(v.pos, "invalid regular expression: %v", err)
+		}
+		return "match(" + col + ", ?)", append(args, v.Text), nil
+	case "in":
+		if len(e.Values) > maxInValues {
+			return "", nil, errorf(e.Values[maxInValues].pos, "at most %d values in IN", maxInValues)
+		}
+		for _, v := range e.Values {
+			args = append(args, v.Text)
+		}
+		return col + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(e.Values)), ", ") + ")", args, nil
+	}
+	return "", nil, errorf(e.Field.pos, "unknown operator %q", e.Op)
+}
+
+// agg renders an aggregate. Numeric aggregates skip values that are not
+// numbers.
+func (c *compiler) agg(a Agg) (string, ColumnType, []interface{}, error) {
+	if a.Arg == nil {
+		return "count()", Int, nil, nil
+	}
+	if _, _, err := c.field(*a.Arg); err != nil {
+		return "", 0, nil, err
+	}
+	k := a.Arg.Key
+	num := "ifNull(" + numeric + ", 0), isNotNull(" + numeric + ")"
+	switch a.Func {
+	case "count":
+		return "countIf(mapContains(data, ?))", Int, []interface{}{k}, nil
+	case "distinct":
+		return "uniqIf(data[?], mapContains(data, ?))", Int, []interface{}{k, k}, nil
+	case "sum", "avg", "min", "max":
+		return "toFloat64(" + a.Func + "If(" + num + "))", Float, []interface{}{k, k}, nil
+	}
+	if level, ok := quantiles[a.Func]; ok {
+		return "quantileIf(?)(" + num + ")", Float, []interface{}{level, k, k}, nil
+	}
+	return "", 0, nil, errorf(a.pos, "unknown aggregate %q", a.Func)
+}
@@ -0,0 +1,191 @@
+// Package querylang implements the log query language of the query service,
+// for example
+//
+//	event:checkout AND data.status>=500 | stats count() by data.region, bin(1m)
+//
+// A query is a filter over events followed by pipes. Parse turns it into an
+// AST and Compile turns the AST into parameterized ClickHouse SQL over the
+// logs table.
+package querylang
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokWord             // bare word: field, value, keyword or function name
+	tokString           // quoted string, unescaped
+	tokColon            // :
+	tokEq               // =
+	tokNeq              // !=
+	tokGt               // >
+	tokGte              // >=
+	tokLt               // <
+	tokLte              // <=
+	tokMatch            // ~
+	tokLParen           // (
+	tokRParen           // )
+	tokComma            // ,
+	tokPipe             // |
+)
+
+var tokenNames = map[tokenKind]string{
+	tokEOF:    "end of query",
+	tokWord:   "word",
+	tokString: "string",
+	tokColon:  "':'",
+	tokEq:     "'='",
+	tokNeq:    "'!='",
+	tokGt:     "'>'",
+	tokGte:    "'>='",
+	tokLt:     "'<'",
+	tokLte:    "'<='",
+	tokMatch:  "'~'",
+	tokLParen: "'('",
+	tokRParen: "')'",
+	tokComma:  "','",
+	tokPipe:   "'|'",
+}
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // 1-based column of the first character
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokWord:
+		return fmt.Sprintf("%q", t.text)
+	case tokString:
+		return "string " + quote(t.text)
+	}
+	return tokenNames[t.kind]
+}
+
+// Error is a syntax or compile error at a column of the query.
+type Error struct {
+	Pos int // 1-based column
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos, e.Msg)
+}
+
+func errorf(pos int, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// lex splits a query into tokens. Columns count runes, so that they line
+// up with what the user typed.
+func lex(src string) ([]token, error) {
+	var (
+		toks []token
+		i    int
+		col  = 1
+	)
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		start := col
+		if unicode.IsSpace(r) {
+			i += size
+			col++
+			continue
+		}
+		two := ""
+		if i+1 < len(src) {
+			two = src[i : i+2]
+		}
+		switch {
+		case two == "!=" || two == ">=" || two == "<=":
+			kind := map[string]tokenKind{"!=": tokNeq, ">=": tokGte, "<=": tokLte}[two]
+			toks = append(toks, token{kind, two, start})
+			i += 2
+			col += 2
+			continue
+		case r == '"' || r == '\'':
+			text, n, runes, err := lexString(src[i:], r)
+			if err != nil {
+				return nil, errorf(start, "%s", err)
+			}
+			toks = append(toks, token{tokString, text, start})
+			i += n
+			col += runes
+			continue
+		}
+		if kind, ok := punct[r]; ok {
+			toks = append(toks, token{kind, string(r), start})
+			i += size
+			col++
+			continue
+		}
+		if r == '!' {
+			return nil, errorf(start, "unexpected '!'; did you mean '!=' or NOT?")
+		}
+		j := i
+		for j < len(src) {
+			r, size := utf8.DecodeRuneInString(src[j:])
+			if unicode.IsSpace(r) || r == '!' || r == '"' || r == '\'' {
+				break
+			}
+			if _, ok := punct[r]; ok {
+				break
+			}
+			j += size
+			col++
+		}
+		toks = append(toks, token{tokWord, src[i:j], start})
+		i = j
+	}
+	toks = append(toks, token{tokEOF, "", col})
+	return toks, nil
+}
+
+var punct = map[rune]tokenKind{
+	':': tokColon,
+	'=': tokEq,
+	'>': tokGt,
+	'<': tokLt,
+	'~': tokMatch,
+	'(': tokLParen,
+	')': tokRParen,
+	',': tokComma,
+	'|': tokPipe,
+}
+
+// lexString reads a string quoted with q, where a backslash escapes the next
+// character. It returns the unquoted text and the bytes and runes consumed.
+func lexString(src string, q rune) (string, int, int, error) {
+	var (
+		b       strings.Builder
+		escaped bool
+		runes   = 1
+	)
+	for i, r := range src[1:] {
+		runes++
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == q:
+			return b.String(), i + 1 + utf8.RuneLen(r), runes, nil
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return "", 0, 0, fmt.Errorf("unterminated string")
+}
+
+// quote renders s as a double-quoted string the lexer reads back as s.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
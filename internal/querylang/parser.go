@@ -0,0 +1,458 @@
+package querylang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Grammar, with keywords matched case-insensitively:
+//
+//	query   = [or] { "|" pipe }
+//	or      = and { OR and }
+//	and     = unary { [AND] unary }
+//	unary   = NOT unary | "(" or ")" | field op value | field IN "(" value { "," value } ")"
+//	op      = ":" | "=" | "!=" | ">" | ">=" | "<" | "<=" | "~"
+//	field   = "event" | "data." key
+//	pipe    = stats | sort | limit
+//	stats   = "stats" agg { "," agg } [ BY group { "," group } ]
+//	agg     = func "(" [field] ")" [ AS name ]
+//	group   = field | "bin(" duration ")"
+//	sort    = "sort" [ "-" ] column { "," [ "-" ] column }
+//	limit   = "limit" integer
+
+const maxDepth = 100
+
+// aggFuncs are the aggregate functions of stats; those marked true need a
+// data field argument.
+var aggFuncs = map[string]bool{
+	"count":    false,
+	"sum":      true,
+	"avg":      true,
+	"min":      true,
+	"max":      true,
+	"distinct": true,
+	"p50":      true,
+	"p90":      true,
+	"p95":      true,
+	"p99":      true,
+}
+
+type parser struct {
+	toks  []token
+	i     int
+	depth int
+}
+
+// Parse parses a query. Errors are *Error values carrying the column.
+func Parse(src string) (*Query, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	q := &Query{}
+	if p.peek().kind != tokPipe && p.peek().kind != tokEOF {
+		if q.Filter, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+	for p.peek().kind == tokPipe {
+		p.next()
+		if err := p.parsePipe(q); err != nil {
+			return nil, err
+		}
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		if t.kind == tokRParen {
+			return nil, errorf(t.pos, "unmatched ')'")
+		}
+		return nil, errorf(t.pos, "unexpected %s", t)
+	}
+	return q, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the word kw.
+func (p *parser) keyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokWord && strings.EqualFold(t.text, kw)
+}
+
+func (p *parser) expect(kind tokenKind, context string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, errorf(t.pos, "expected %s %s, found %s", tokenNames[kind], context, t)
+	}
+	return t, nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		op := p.next()
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{Op: "OR", X: x, Y: y, pos: op.pos}
+	}
+	return x, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		pos := p.peek().pos
+		if p.keyword("and") {
+			p.next()
+		} else if !p.startsCondition() {
+			return x, nil
+		}
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{Op: "AND", X: x, Y: y, pos: pos}
+	}
+}
+
+// startsCondition reports whether the next token can begin a condition,
+// which makes juxtaposition an implicit AND.
+func (p *parser) startsCondition() bool {
+	t := p.peek()
+	switch t.kind {
+	case tokLParen:
+		return true
+	case tokWord:
+		return !p.keyword("or") && !p.keyword("and")
+	}
+	return false
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, errorf(p.peek().pos, "query is nested too deeply")
+	}
+	t := p.peek()
+	switch {
+	case t.kind == tokWord && strings.EqualFold(t.text, "not"):
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{X: x, pos: t.pos}, nil
+	case t.kind == tokLParen:
+		p.next()
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "to close '('"); err != nil {
+			return nil, err
+		}
+		return x, nil
+	}
+	return p.parseComparison()
+}
+
+var comparisonOps = map[tokenKind]string{
+	tokColon: ":",
+	tokEq:    "=",
+	tokNeq:   "!=",
+	tokGt:    ">",
+	tokGte:   ">=",
+	tokLt:    "<",
+	tokLte:   "<=",
+	tokMatch: "~",
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	f, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+	if p.keyword("in") {
+		p.next()
+		if _, err := p.expect(tokLParen, "after IN"); err != nil {
+			return nil, err
+		}
+		c := &Comparison{Field: f, Op: "in"}
+		for {
+			v, err := p.parseValue("in IN list")
+			if err != nil {
+				return nil, err
+			}
+			c.Values = append(c.Values, v)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+		if _, err := p.expect(tokRParen, "to close IN list"); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	t := p.next()
+	op, ok := comparisonOps[t.kind]
+	if !ok {
+		return nil, errorf(t.pos, "expected an operator after %s, found %s", f, t)
+	}
+	v, err := p.parseValue("after " + op)
+	if err != nil {
+		return nil, err
+	}
+	return &Comparison{Field: f, Op: op, Value: v}, nil
+}
+
+func (p *parser) parseField() (Field, error) {
+	t := p.next()
+	if t.kind != tokWord {
+		return Field{}, errorf(t.pos, "expected a field (event or data.<key>), found %s", t)
+	}
+	switch {
+	case strings.EqualFold(t.text, "event") || strings.EqualFold(t.text, "event_name"):
+		return Field{pos: t.pos}, nil
+	case t.text == "data." && p.peek().kind == tokString:
+		key := p.next()
+		if key.text == "" {
+			return Field{}, errorf(key.pos, "empty data key")
+		}
+		return Field{Key: key.text, pos: t.pos}, nil
+	case strings.HasPrefix(t.text, "data.") && len(t.text) > len("data."):
+		return Field{Key: strings.TrimPrefix(t.text, "data."), pos: t.pos}, nil
+	}
+	return Field{}, errorf(t.pos, "unknown field %q; use event or data.<key>", t.text)
+}
+
+func (p *parser) parseValue(context string) (Value, error) {
+	t := p.next()
+	switch t.kind {
+	case tokWord:
+		return Value{Text: t.text, pos: t.pos}, nil
+	case tokString:
+		return Value{Text: t.text, Quoted: true, pos: t.pos}, nil
+	}
+	return Value{}, errorf(t.pos, "expected a value %s, found %s", context, t)
+}
+
+func (p *parser) parsePipe(q *Query) error {
+	t := p.next()
+	if t.kind != tokWord {
+		return errorf(t.pos, "expected stats, sort or limit after '|', found %s", t)
+	}
+	switch strings.ToLower(t.text) {
+	case "stats":
+		if q.Stats != nil {
+			return errorf(t.pos, "only one stats is allowed")
+		}
+		if len(q.Sort) > 0 || q.Limit > 0 {
+			return errorf(t.pos, "stats must come before sort and limit")
+		}
+		s, err := p.parseStats(t.pos)
+		if err != nil {
+			return err
+		}
+		q.Stats = s
+	case "sort":
+		if len(q.Sort) > 0 {
+			return errorf(t.pos, "only one sort is allowed")
+		}
+		if q.Limit > 0 {
+			return errorf(t.pos, "sort must come before limit")
+		}
+		for {
+			k, err := p.parseSortKey()
+			if err != nil {
+				return err
+			}
+			q.Sort = append(q.Sort, k)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	case "limit":
+		if q.Limit > 0 {
+			return errorf(t.pos, "only one limit is allowed")
+		}
+		n := p.next()
+		v, err := strconv.Atoi(n.text)
+		if n.kind != tokWord || err != nil || v <= 0 {
+			return errorf(n.pos, "limit needs a positive integer, found %s", n)
+		}
+		q.Limit, q.limitPos = v, n.pos
+	default:
+		return errorf(t.pos, "unknown pipe %q; use stats, sort or limit", t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseStats(pos int) (*Stats, error) {
+	s := &Stats{pos: pos}
+	for {
+		a, err := p.parseAgg()
+		if err != nil {
+			return nil, err
+		}
+		s.Aggs = append(s.Aggs, a)
+		if p.peek().kind != tokComma {
+			break
+		}
+		p.next()
+	}
+	if !p.keyword("by") {
+		return s, nil
+	}
+	p.next()
+	for {
+		g, err := p.parseGroupKey()
+		if err != nil {
+			return nil, err
+		}
+		s.By = append(s.By, g)
+		if p.peek().kind != tokComma {
+			break
+		}
+		p.next()
+	}
+	return s, nil
+}
+
+func (p *parser) parseAgg() (Agg, error) {
+	t := p.next()
+	if t.kind != tokWord {
+		return Agg{}, errorf(t.pos, "expected an aggregate like count(), found %s", t)
+	}
+	name := strings.ToLower(t.text)
+	needsArg, ok := aggFuncs[name]
+	if !ok {
+		return Agg{}, errorf(t.pos, "unknown aggregate %q; use count, sum, avg, min, max, distinct, p50, p90, p95 or p99", t.text)
+	}
+	if _, err := p.expect(tokLParen, "after "+name); err != nil {
+		return Agg{}, err
+	}
+	a := Agg{Func: name, pos: t.pos}
+	if p.peek().kind != tokRParen {
+		f, err := p.parseField()
+		if err != nil {
+			return Agg{}, err
+		}
+		if f.Key == "" {
+			return Agg{}, errorf(f.pos, "%s takes a data field", name)
+		}
+		a.Arg = &f
+	} else if needsArg {
+		return Agg{}, errorf(p.peek().pos, "%s needs a data field, like %s(data.latency)", name, name)
+	}
+	if _, err := p.expect(tokRParen, "to close "+name+"("); err != nil {
+		return Agg{}, err
+	}
+	if p.keyword("as") {
+		p.next()
+		alias := p.next()
+		if alias.kind != tokWord || !isIdent(alias.text) {
+			return Agg{}, errorf(alias.pos, "expected a column name after AS, found %s", alias)
+		}
+		a.Alias = alias.text
+	}
+	return a, nil
+}
+
+func (p *parser) parseGroupKey() (GroupKey, error) {
+	t := p.peek()
+	if t.kind == tokWord && strings.EqualFold(t.text, "bin") {
+		p.next()
+		if _, err := p.expect(tokLParen, "after bin"); err != nil {
+			return GroupKey{}, err
+		}
+		d := p.next()
+		width, err := parseDuration(d.text)
+		if d.kind != tokWord || err != nil {
+			return GroupKey{}, errorf(d.pos, "bin needs a duration like 30s, 5m, 1h or 1d, found %s", d)
+		}
+		if width < time.Second || width%time.Second != 0 {
+			return GroupKey{}, errorf(d.pos, "bin width must be a whole number of seconds")
+		}
+		if _, err := p.expect(tokRParen, "to close bin("); err != nil {
+			return GroupKey{}, err
+		}
+		return GroupKey{Bin: width, pos: t.pos}, nil
+	}
+	f, err := p.parseField()
+	if err != nil {
+		return GroupKey{}, err
+	}
+	return GroupKey{Field: &f, pos: f.pos}, nil
+}
+
+// parseSortKey reads a column name as printed in results: a group field,
+// an alias, "bin(1m)" or an aggregate like "count()" or "-p95(data.ms)".
+func (p *parser) parseSortKey() (SortKey, error) {
+	t := p.next()
+	if t.kind != tokWord || t.text == "-" {
+		return SortKey{}, errorf(t.pos, "expected a column to sort by, found %s", t)
+	}
+	k := SortKey{Name: t.text, pos: t.pos}
+	if strings.HasPrefix(k.Name, "-") {
+		k.Name, k.Desc = k.Name[1:], true
+	}
+	if p.peek().kind != tokLParen {
+		return k, nil
+	}
+	p.next()
+	name := strings.ToLower(k.Name)
+	if name == "bin" {
+		d := p.next()
+		width, err := parseDuration(d.text)
+		if d.kind != tokWord || err != nil {
+			return SortKey{}, errorf(d.pos, "bin needs a duration, found %s", d)
+		}
+		k.Name = GroupKey{Bin: width}.String()
+	} else {
+		k.Name = name + "()"
+		if p.peek().kind != tokRParen {
+			f, err := p.parseField()
+			if err != nil {
+				return SortKey{}, err
+			}
+			k.Name = Agg{Func: name, Arg: &f}.String()
+		}
+	}
+	if _, err := p.expect(tokRParen, "to close "+name+"("); err != nil {
+		return SortKey{}, err
+	}
+	return k, nil
+}
+
+// parseDuration is time.ParseDuration plus a "d" (day) unit.
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,143 @@
+package querylang
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+var goldenOptions = Options{
+	ProjectID:    "p1",
+	Keys:         []string{"status", "region", "latency_ms", "bytes", "user", "http.path"},
+	DefaultLimit: 100,
+	MaxLimit:     1000,
+}
+
+var columnTypes = map[ColumnType]string{String: "string", Int: "int", Float: "float", Map: "map"}
+
+// readQueries returns the queries of testdata/queries.txt, skipping blank
+// lines and comments.
+func readQueries(t *testing.T) []string {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "queries.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var queries []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return queries
+}
+
+// checkGolden compares got with testdata/name, or rewrites it with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+		for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+			var g, w string
+			if i < len(gotLines) {
+				g = gotLines[i]
+			}
+			if i < len(wantLines) {
+				w = wantLines[i]
+			}
+			if g != w {
+				t.Fatalf("%s differs at line %d:\n got: %s\nwant: %s\n(run go test -update to accept)", name, i+1, g, w)
+			}
+		}
+	}
+}
+
+// describeError prints err with a caret under the column it points at.
+func describeError(query string, err error) string {
+	var qerr *Error
+	if !errors.As(err, &qerr) {
+		return "unexpected error type: " + err.Error() + "\n"
+	}
+	caret := strings.Repeat(" ", max(qerr.Pos-1, 0)) + "^"
+	return "error: " + err.Error() + "\n  " + query + "\n  " + caret + "\n"
+}
+
+func TestParseGolden(t *testing.T) {
+	var b strings.Builder
+	for _, query := range readQueries(t) {
+		fmt.Fprintf(&b, "# %s\n", query)
+		q, err := Parse(query)
+		if err != nil {
+			b.WriteString(describeError(query, err))
+		} else {
+			b.WriteString(q.String() + "\n")
+		}
+		b.WriteString("\n")
+	}
+	checkGolden(t, "parse.golden", b.String())
+}
+
+func TestCompileGolden(t *testing.T) {
+	var b strings.Builder
+	for _, query := range readQueries(t) {
+		q, err := Parse(query)
+		if err != nil {
+			continue // covered by parse.golden
+		}
+		fmt.Fprintf(&b, "# %s\n", query)
+		c, err := Compile(q, goldenOptions)
+		if err != nil {
+			b.WriteString(describeError(query, err) + "\n")
+			continue
+		}
+		if n := strings.Count(c.SQL, "?"); n != len(c.Args) {
+			t.Errorf("%s: %d placeholders but %d args", query, n, len(c.Args))
+		}
+		b.WriteString(c.SQL + "\n")
+		args := make([]string, len(c.Args))
+		for i, a := range c.Args {
+			args[i] = fmt.Sprintf("%#v", a)
+		}
+		b.WriteString("args: " + strings.Join(args, ", ") + "\n")
+		cols := make([]string, len(c.Columns))
+		for i, col := range c.Columns {
+			cols[i] = col.Name + " " + columnTypes[col.Type]
+		}
+		b.WriteString("columns: " + strings.Join(cols, ", ") + "\n\n")
+	}
+	checkGolden(t, "compile.golden", b.String())
+}
+
+// TestErrorColumns checks that columns count characters, not bytes.
+func TestErrorColumns(t *testing.T) {
+	_, err := Parse("data.région:é OR (")
+	var qerr *Error
+	if !errors.As(err, &qerr) || qerr.Pos != 19 {
+		t.Fatalf("got %v, want an error at column 19", err)
+	}
+}
@@ -11,7 +11,8 @@ export async function login(username: string, password: string) {
 export async function fetchProjects() {
   const token = getToken();
   const res = await fetch(`${BASE}/v1/projects`, { headers: { 'Authorization': `Bearer ${token}` } });
-  return res.json();
+  const data = await res.json();
+  return data.projects ?? [];
 }
 export async function searchEvents(projectId: string, filters: Record<string,string>) {
   const token = getToken();
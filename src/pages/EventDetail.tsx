@@ -3,12 +3,17 @@ import { useParams } from 'react-router-dom';
 import { fetchEventDetail } from '../api';
 export default function EventDetail() {
   const { projectId, eventName } = useParams();
-  const [entry,set]=useState<any>(null);
-  useEffect(()=>{ fetchEventDetail(projectId!,eventName!).then(data=>set(data.entry)); },[]);
-  return entry ? (
+  const [page,setPage]=useState<any>(null);
+  const load=(cursor?: string)=>fetchEventDetail(projectId!,eventName!,cursor).then(data=>{ if(data.entry) setPage(data); });
+  useEffect(()=>{ load(); },[]);
+  return page ? (
     <div className="p-4">
       <h1 className="text-xl mb-4">{eventName}</h1>
-      <pre>{JSON.stringify(entry,null,2)}</pre>
+      <pre>{JSON.stringify(page.entry,null,2)}</pre>
+      <div className="mt-4 space-x-2">
+        <button disabled={!page.prev_cursor} onClick={()=>load(page.prev_cursor)}>Newer</button>
+        <button disabled={!page.next_cursor} onClick={()=>load(page.next_cursor)}>Older</button>
+      </div>
     </div>
   ) : <div>Loading…</div>;
-}
\ No newline at end of file
+}